@@ -0,0 +1,184 @@
+package golden
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//
+// Golden-file tests: each script under a testdata tree is run, its stdout
+// and stderr are compared with the .out and .err files beside it, a missing
+// file means no output. Running tests with -update rewrites them.
+//
+// A script may annotate its source lines with expected errors instead:
+//
+//     x = 1 / 0 // ERROR "Division by zero"
+//
+// each of the quoted strings following ERROR, in Go syntax, is a regular
+// expression matched against the messages of errors reported at the line,
+// in the format "file,line,position: message". Then stderr of the script
+// is checked by the annotations instead of the .err file, every error must
+// be expected and every annotation must match an error.
+//
+
+var update = flag.Bool("update", false, "update the golden files of scripts")
+
+// Runner runs a script, returns what it writes to stdout and stderr.
+type Runner func(t *testing.T, file string) (stdout, stderr string)
+
+var (
+	annotationPattern = regexp.MustCompile(`//\s*ERROR((?:\s+"(?:[^"\\]|\\.)*")+)`)
+	quotedPattern     = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+	errorPattern      = regexp.MustCompile(`^(.*),(\d+),(\d+): (.*)$`)
+)
+
+// Run runs all scripts with extension ext under dir by run, as subtests
+// named by their paths.
+func Run(t *testing.T, dir, ext string, run Runner) {
+	files := []string{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && filepath.Ext(path) == ext {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Can't find scripts: %s", err.Error())
+	}
+	if len(files) == 0 {
+		t.Fatalf("No %s script under %s", ext, dir)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		file := file
+		name, _ := filepath.Rel(dir, file)
+		t.Run(filepath.ToSlash(name), func(t *testing.T) {
+			check(t, file, ext, run)
+		})
+	}
+}
+
+func check(t *testing.T, file, ext string, run Runner) {
+	source, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatalf("Can't read %s: %s", file, err.Error())
+	}
+	annotations, err := parseAnnotations(string(source))
+	if err != nil {
+		t.Fatalf("Invalid annotation in %s: %s", file, err.Error())
+	}
+
+	stdout, stderr := run(t, file)
+	base := strings.TrimSuffix(file, ext)
+	compare(t, base+".out", stdout)
+	if len(annotations) == 0 {
+		compare(t, base+".err", stderr)
+		return
+	}
+	for _, problem := range checkErrors(annotations, stderr) {
+		t.Errorf("%s: %s", file, problem)
+	}
+}
+
+// Compare output with the golden file, or rewrite it with -update.
+func compare(t *testing.T, golden, output string) {
+	if *update {
+		var err error
+		if output == "" {
+			err = os.Remove(golden)
+			if os.IsNotExist(err) {
+				err = nil
+			}
+		} else {
+			err = os.WriteFile(golden, []byte(output), 0644)
+		}
+		if err != nil {
+			t.Fatalf("Can't update %s: %s", golden, err.Error())
+		}
+		return
+	}
+
+	content, err := os.ReadFile(golden)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Can't read %s: %s", golden, err.Error())
+	}
+	if string(content) != output {
+		t.Fatalf("Wrong output of %s: Wanted\n%s\ngot\n%s", golden, content, output)
+	}
+}
+
+// Returns the patterns of expected errors by line.
+func parseAnnotations(source string) (map[int][]*regexp.Regexp, error) {
+	annotations := map[int][]*regexp.Regexp{}
+	for i, line := range strings.Split(source, "\n") {
+		match := annotationPattern.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+		for _, quoted := range quotedPattern.FindAllString(match[1], -1) {
+			pattern, err := strconv.Unquote(quoted)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+			}
+			expression, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", i+1, err.Error())
+			}
+			annotations[i+1] = append(annotations[i+1], expression)
+		}
+	}
+	return annotations, nil
+}
+
+// Check errors reported in stderr against annotations, returns the
+// problems found.
+func checkErrors(annotations map[int][]*regexp.Regexp, stderr string) []string {
+	problems := []string{}
+	matched := map[*regexp.Regexp]bool{}
+	for _, line := range strings.Split(stderr, "\n") {
+		if line == "" {
+			continue
+		}
+		match := errorPattern.FindStringSubmatch(line)
+		if match == nil {
+			problems = append(problems, "unexpected output: "+line)
+			continue
+		}
+		number, _ := strconv.Atoi(match[2])
+		expected := false
+		for _, expression := range annotations[number] {
+			if expression.MatchString(match[4]) {
+				matched[expression] = true
+				expected = true
+			}
+		}
+		if !expected {
+			problems = append(problems, fmt.Sprintf("line %d: unexpected error: %s", number, match[4]))
+		}
+	}
+
+	lines := []int{}
+	for number := range annotations {
+		lines = append(lines, number)
+	}
+	sort.Ints(lines)
+	for _, number := range lines {
+		for _, expression := range annotations[number] {
+			if !matched[expression] {
+				problems = append(problems, fmt.Sprintf("line %d: missing error %q", number, expression))
+			}
+		}
+	}
+	return problems
+}
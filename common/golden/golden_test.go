@@ -0,0 +1,76 @@
+package golden
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnnotations(t *testing.T) {
+	t.Log("Test: annotations ...")
+
+	annotations, err := parseAnnotations(strings.Join([]string{
+		"x = 1",
+		"y = x / 0 // ERROR \"Division\"",
+		"z = a + b // ERROR \"variable a\" \"variable b$\"",
+		"f(x // ERROR " + `"^Missing \"\\)\""`,
+	}, "\n"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	counts := map[int]int{2: 1, 3: 2, 4: 1}
+	if len(annotations) != len(counts) {
+		t.Fatalf("Wrong annotations: %v", annotations)
+	}
+	for line, count := range counts {
+		if len(annotations[line]) != count {
+			t.Fatalf("Wrong annotations of line %d: Wanted %d, got %d", line, count, len(annotations[line]))
+		}
+	}
+	if !annotations[4][0].MatchString("Missing \")\"") {
+		t.Fatalf("Wrong pattern: %s", annotations[4][0])
+	}
+
+	if _, err := parseAnnotations("x // ERROR \"(\""); err == nil {
+		t.Fatalf("Invalid pattern is accepted")
+	}
+
+	t.Log("Passed")
+}
+
+func TestCheckErrors(t *testing.T) {
+	t.Log("Test: check errors ...")
+
+	annotations, _ := parseAnnotations(strings.Join([]string{
+		"x = 1",
+		"y = x / 0 // ERROR \"Division\"",
+		"z = a + b // ERROR \"variable a\" \"variable b$\"",
+	}, "\n"))
+
+	problems := checkErrors(annotations, strings.Join([]string{
+		"file,2,8: Division by zero",
+		"file,3,4: Undefined variable a",
+		"file,3,8: Undefined variable b",
+		"",
+	}, "\n"))
+	if len(problems) != 0 {
+		t.Fatalf("Unexpected problems: %v", problems)
+	}
+
+	problems = checkErrors(annotations, strings.Join([]string{
+		"file,1,0: Undefined variable x",
+		"file,3,4: Undefined variable a",
+		"Internal error",
+		"",
+	}, "\n"))
+	target := []string{
+		"line 1: unexpected error: Undefined variable x",
+		"unexpected output: Internal error",
+		"line 2: missing error \"Division\"",
+		"line 3: missing error \"variable b$\"",
+	}
+	if strings.Join(problems, "\n") != strings.Join(target, "\n") {
+		t.Fatalf("Wrong problems: Wanted\n%s\ngot\n%s", strings.Join(target, "\n"), strings.Join(problems, "\n"))
+	}
+
+	t.Log("Passed")
+}
@@ -0,0 +1,467 @@
+package dap
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/clog"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Debug adapter: a Server serves one debug session over a stream like
+// stdio. The launched script is executed in another goroutine, which is
+// blocked by the step hook while it's stopped.
+//
+
+// scripts have only one thread
+const threadID = 1
+
+type Server struct {
+	reader *bufio.Reader
+	writer io.Writer
+
+	// guards writer and the session state below, the step hook and the
+	// output of the script run in another goroutine
+	lock sync.Mutex
+	seq  int
+
+	launch     *launchArguments
+	configured bool // configurationDone is received
+	started    bool
+	aborted    bool
+	cancel     func()
+	done       chan struct{} // closed once the script ends
+
+	stepper *ast.Stepper
+	entered bool // the script has stopped once
+	pausing bool // a pause request is pending
+	stopped *stop
+	resume  chan bool // resume the stopped script, true aborts it
+}
+
+// where the script is stopped
+type stop struct {
+	location *common.Location
+	env      *ast.Environment
+}
+
+func NewServer(input io.Reader, output io.Writer) *Server {
+	return &Server{
+		reader: bufio.NewReader(input),
+		writer: output,
+
+		done: make(chan struct{}),
+
+		stepper: ast.NewStepper(ast.STEP_CONTINUE),
+		resume:  make(chan bool),
+	}
+}
+
+// Serve handle requests until the session is disconnected or input ends,
+// the script is aborted then if it's still running.
+func (server *Server) Serve() error {
+	defer server.terminate()
+
+	for {
+		content, err := readMessage(server.reader)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		req := &request{}
+		if err = json.Unmarshal(content, req); err != nil {
+			return err
+		}
+		if req.Type == "request" && !server.handle(req) {
+			return nil
+		}
+	}
+}
+
+// Returns false if the session is disconnected.
+func (server *Server) handle(req *request) bool {
+	switch req.Command {
+	case "initialize":
+		server.respond(req, map[string]interface{}{
+			"supportsConfigurationDoneRequest": true,
+		}, nil)
+		server.sendEvent("initialized", nil)
+	case "launch":
+		server.respond(req, nil, server.onLaunch(req))
+		server.start()
+	case "configurationDone":
+		server.lock.Lock()
+		server.configured = true
+		server.lock.Unlock()
+		server.respond(req, nil, nil)
+		server.start()
+	case "setBreakpoints":
+		body, err := server.onSetBreakpoints(req)
+		server.respond(req, body, err)
+	case "threads":
+		server.respond(req, map[string]interface{}{
+			"threads": []thread{{ID: threadID, Name: "main"}},
+		}, nil)
+	case "stackTrace":
+		body, err := server.onStackTrace()
+		server.respond(req, body, err)
+	case "scopes":
+		body, err := server.onScopes(req)
+		server.respond(req, body, err)
+	case "variables":
+		body, err := server.onVariables(req)
+		server.respond(req, body, err)
+	case "continue":
+		server.onResume(req, ast.STEP_CONTINUE, map[string]interface{}{
+			"allThreadsContinued": true,
+		})
+	case "next":
+		server.onResume(req, ast.STEP_OVER, nil)
+	case "stepIn":
+		server.onResume(req, ast.STEP_IN, nil)
+	case "stepOut":
+		server.onResume(req, ast.STEP_OUT, nil)
+	case "pause":
+		server.lock.Lock()
+		server.pausing = true
+		server.stepper.Pause()
+		server.lock.Unlock()
+		server.respond(req, nil, nil)
+	case "disconnect":
+		server.terminate()
+		server.respond(req, nil, nil)
+		return false
+	default:
+		server.respond(req, nil, errors.New("unsupported request "+req.Command))
+	}
+	return true
+}
+
+//
+// messages
+//
+
+func (server *Server) respond(req *request, body interface{}, err error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	server.writeResponse(req, body, err)
+}
+
+// The caller must hold the lock.
+func (server *Server) writeResponse(req *request, body interface{}, err error) {
+	server.seq++
+	resp := &response{
+		message:    message{Seq: server.seq, Type: "response"},
+		RequestSeq: req.Seq,
+		Success:    err == nil,
+		Command:    req.Command,
+		Body:       body,
+	}
+	if err != nil {
+		resp.Message = err.Error()
+	}
+	writeMessage(server.writer, resp)
+}
+
+func (server *Server) sendEvent(name string, body interface{}) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	server.writeEvent(name, body)
+}
+
+// The caller must hold the lock.
+func (server *Server) writeEvent(name string, body interface{}) {
+	server.seq++
+	writeMessage(server.writer, &event{
+		message: message{Seq: server.seq, Type: "event"},
+		Event:   name,
+		Body:    body,
+	})
+}
+
+// Output of the script is sent as output events of category.
+type outputWriter struct {
+	server   *Server
+	category string
+}
+
+func (writer *outputWriter) Write(p []byte) (int, error) {
+	writer.server.sendEvent("output", map[string]interface{}{
+		"category": writer.category,
+		"output":   string(p),
+	})
+	return len(p), nil
+}
+
+//
+// execution
+//
+
+func (server *Server) onLaunch(req *request) error {
+	arguments := &launchArguments{}
+	if err := json.Unmarshal(req.Arguments, arguments); err != nil {
+		return err
+	}
+	if arguments.Program == "" {
+		return errors.New("program is required")
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if server.launch != nil {
+		return errors.New("program is launched already")
+	}
+	server.launch = arguments
+	if arguments.StopOnEntry {
+		server.stepper.Pause()
+	}
+	return nil
+}
+
+// Run the script once it's launched and breakpoints are configured.
+func (server *Server) start() {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	if server.started || server.launch == nil || !server.configured {
+		return
+	}
+	server.started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	server.cancel = cancel
+	go server.run(ctx, server.launch)
+}
+
+func (server *Server) run(ctx context.Context, arguments *launchArguments) {
+	defer close(server.done)
+
+	inter := interpreter.NewInterpreter()
+	inter.UseVM(arguments.VM)
+	inter.SetStdout(&outputWriter{server, "stdout"})
+	inter.SetStderr(&outputWriter{server, "stderr"})
+	if !arguments.NoDebug {
+		inter.SetObserver(server)
+	}
+
+	errs := inter.InterpretContext(ctx, arguments.Program)
+	exitCode := 0
+	if len(errs) > 0 {
+		exitCode = 1
+		buffer := &bytes.Buffer{}
+		clog.NewWriterLogger(buffer).Errors(errs)
+		server.sendEvent("output", map[string]interface{}{
+			"category": "stderr",
+			"output":   buffer.String(),
+		})
+	}
+	server.sendEvent("exited", map[string]interface{}{"exitCode": exitCode})
+	server.sendEvent("terminated", nil)
+}
+
+// OnStep is the step hook of the launched script, which blocks the script
+// while it's stopped.
+func (server *Server) OnStep(location *common.Location, env *ast.Environment) gerror.Error {
+	server.lock.Lock()
+	if server.aborted {
+		server.lock.Unlock()
+		return env.GetLimiter().CheckContext(location)
+	}
+	if !server.stepper.Stop(location, env) {
+		server.lock.Unlock()
+		return nil
+	}
+
+	reason := "step"
+	if !server.entered && server.launch.StopOnEntry {
+		reason = "entry"
+	} else if server.stepper.IsBreakpoint(location) {
+		reason = "breakpoint"
+	} else if server.pausing {
+		reason = "pause"
+	}
+	server.entered = true
+	server.pausing = false
+	server.stopped = &stop{location: location, env: env}
+	server.writeEvent("stopped", map[string]interface{}{
+		"reason":            reason,
+		"threadId":          threadID,
+		"allThreadsStopped": true,
+	})
+	server.lock.Unlock()
+
+	if abort := <-server.resume; abort {
+		return env.GetLimiter().CheckContext(location)
+	}
+	return nil
+}
+
+func (server *Server) onResume(req *request, mode ast.StepMode, body interface{}) {
+	server.lock.Lock()
+	if server.stopped == nil {
+		server.writeResponse(req, nil, errors.New("script isn't stopped"))
+		server.lock.Unlock()
+		return
+	}
+	server.stepper.Resume(mode, server.stopped.env)
+	server.stopped = nil
+	server.writeResponse(req, body, nil)
+	server.lock.Unlock()
+
+	server.resume <- false
+}
+
+// Abort the script and wait until it ends.
+func (server *Server) terminate() {
+	server.lock.Lock()
+	if !server.started || server.aborted {
+		server.lock.Unlock()
+		return
+	}
+	server.aborted = true
+	server.cancel()
+	stopped := server.stopped != nil
+	server.stopped = nil
+	server.lock.Unlock()
+
+	if stopped {
+		server.resume <- true
+	}
+	<-server.done
+}
+
+//
+// inspection
+//
+
+func (server *Server) onSetBreakpoints(req *request) (interface{}, error) {
+	arguments := &setBreakpointsArguments{}
+	if err := json.Unmarshal(req.Arguments, arguments); err != nil {
+		return nil, err
+	}
+
+	lines := []int{}
+	breakpoints := []breakpoint{}
+	for _, b := range arguments.Breakpoints {
+		lines = append(lines, b.Line)
+		breakpoints = append(breakpoints, breakpoint{Verified: true, Line: b.Line})
+	}
+
+	server.lock.Lock()
+	server.stepper.SetBreakpoints(arguments.Source.Path, lines)
+	server.lock.Unlock()
+	return map[string]interface{}{"breakpoints": breakpoints}, nil
+}
+
+// Frames of the stopped script from the innermost one, each of which is
+// at the call location of the frame above it.
+func (server *Server) frames() ([]*stop, error) {
+	if server.stopped == nil {
+		return nil, errors.New("script isn't stopped")
+	}
+
+	frames := []*stop{}
+	location, env := server.stopped.location, server.stopped.env
+	for env != nil {
+		frames = append(frames, &stop{location: location, env: env})
+		location, env = env.GetCallLocation(), env.GetCaller()
+	}
+	return frames, nil
+}
+
+func (server *Server) onStackTrace() (interface{}, error) {
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	frames, err := server.frames()
+	if err != nil {
+		return nil, err
+	}
+
+	stackFrames := []stackFrame{}
+	for i, frame := range frames {
+		name := frame.env.GetFunctionName()
+		if name == "" {
+			name = "<global>"
+		}
+		stackFrame := stackFrame{ID: i, Name: name}
+		if frame.location != nil {
+			stackFrame.Source = &source{
+				Name: filepath.Base(frame.location.GetFileName()),
+				Path: frame.location.GetFileName(),
+			}
+			// columns start at 1
+			stackFrame.Line = frame.location.GetLine()
+			stackFrame.Column = frame.location.GetPosition() + 1
+		}
+		stackFrames = append(stackFrames, stackFrame)
+	}
+	return map[string]interface{}{
+		"stackFrames": stackFrames,
+		"totalFrames": len(stackFrames),
+	}, nil
+}
+
+// Each frame has two scopes, referred by 2*id+1 for locals
+// and 2*id+2 for globals.
+func (server *Server) onScopes(req *request) (interface{}, error) {
+	arguments := &scopesArguments{}
+	if err := json.Unmarshal(req.Arguments, arguments); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{"scopes": []scope{
+		{Name: "Locals", VariablesReference: 2*arguments.FrameID + 1},
+		{Name: "Globals", VariablesReference: 2*arguments.FrameID + 2},
+	}}, nil
+}
+
+func (server *Server) onVariables(req *request) (interface{}, error) {
+	arguments := &variablesArguments{}
+	if err := json.Unmarshal(req.Arguments, arguments); err != nil {
+		return nil, err
+	}
+
+	server.lock.Lock()
+	defer server.lock.Unlock()
+	frames, err := server.frames()
+	if err != nil {
+		return nil, err
+	}
+	id := (arguments.VariablesReference - 1) / 2
+	if arguments.VariablesReference <= 0 || id >= len(frames) {
+		return nil, errors.New("invalid variables reference " +
+			strconv.Itoa(arguments.VariablesReference))
+	}
+
+	env := frames[id].env
+	var values []*types.Variable
+	if arguments.VariablesReference%2 == 1 {
+		values = env.GetLocalVariables()
+	} else {
+		values = interpreter.GlobalVariables(env)
+	}
+
+	variables := []variable{}
+	for _, v := range values {
+		variables = append(variables, variable{
+			Name:  v.GetName(),
+			Value: interpreter.FormatValue(v.GetValue()),
+			Type:  v.GetValue().GetType().String(),
+		})
+	}
+	return map[string]interface{}{"variables": variables}, nil
+}
@@ -0,0 +1,297 @@
+package dap
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var script = []string{
+	"def fib(n) {",
+	"    if (n < 2) {",
+	"        return n",
+	"    }",
+	"    return fib(n - 1) + fib(n - 2)",
+	"}",
+	"total = 0",
+	"for (i = 0; i < 3; i++) {",
+	"    total = total + fib(i)",
+	"}",
+	"Printf(\"%d\\n\", total)",
+}
+
+// client is a scripted DAP client talking to a server in another goroutine.
+type client struct {
+	t        *testing.T
+	writer   io.WriteCloser
+	messages chan []byte // read from the server, so that it never blocks
+	seq      int
+
+	pending []map[string]interface{} // messages read but not expected yet
+	output  string                   // stdout of the script
+	done    chan error               // result of Serve
+}
+
+func newClient(t *testing.T) *client {
+	requestReader, requestWriter := io.Pipe()
+	responseReader, responseWriter := io.Pipe()
+
+	c := &client{
+		t:        t,
+		writer:   requestWriter,
+		messages: make(chan []byte, 1024),
+		done:     make(chan error, 1),
+	}
+	go func() {
+		reader := bufio.NewReader(responseReader)
+		for {
+			content, err := readMessage(reader)
+			if err != nil {
+				close(c.messages)
+				return
+			}
+			c.messages <- content
+		}
+	}()
+	go func() {
+		err := NewServer(requestReader, responseWriter).Serve()
+		responseWriter.Close()
+		c.done <- err
+	}()
+	return c
+}
+
+func (c *client) send(command string, arguments interface{}) {
+	c.seq++
+	req := map[string]interface{}{
+		"seq":     c.seq,
+		"type":    "request",
+		"command": command,
+	}
+	if arguments != nil {
+		req["arguments"] = arguments
+	}
+	if err := writeMessage(c.writer, req); err != nil {
+		c.t.Fatalf("Can't send %s: %s", command, err.Error())
+	}
+}
+
+// Returns the next message of typ named name, the response of a command
+// or an event, other messages are kept for later expectations.
+func (c *client) expect(typ, name string) map[string]interface{} {
+	key := "command"
+	if typ == "event" {
+		key = "event"
+	}
+	for i, msg := range c.pending {
+		if msg["type"] == typ && msg[key] == name {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return msg
+		}
+	}
+
+	for {
+		content, ok := <-c.messages
+		if !ok {
+			c.t.Fatalf("Can't read %s %s", typ, name)
+		}
+		msg := map[string]interface{}{}
+		if err := json.Unmarshal(content, &msg); err != nil {
+			c.t.Fatalf("Invalid message %s: %s", content, err.Error())
+		}
+
+		if msg["type"] == "event" && msg["event"] == "output" {
+			body := msg["body"].(map[string]interface{})
+			if body["category"] == "stdout" {
+				c.output += body["output"].(string)
+				continue
+			}
+		}
+		if msg["type"] == typ && msg[key] == name {
+			return msg
+		}
+		c.pending = append(c.pending, msg)
+	}
+}
+
+// Send a request and returns the body of its successful response.
+func (c *client) request(command string, arguments interface{}) map[string]interface{} {
+	c.send(command, arguments)
+	resp := c.expect("response", command)
+	if resp["success"] != true {
+		c.t.Fatalf("Request %s failed: %v", command, resp["message"])
+	}
+	body, _ := resp["body"].(map[string]interface{})
+	return body
+}
+
+func (c *client) expectStopped(reason string) {
+	body := c.expect("event", "stopped")["body"].(map[string]interface{})
+	if body["reason"] != reason {
+		c.t.Fatalf("Wrong stop reason: Wanted %s, got %v", reason, body["reason"])
+	}
+}
+
+// Check name and line of each frame from the innermost one.
+func (c *client) expectStack(frames ...interface{}) {
+	body := c.request("stackTrace", map[string]interface{}{"threadId": threadID})
+	stackFrames := body["stackFrames"].([]interface{})
+	if len(stackFrames) != len(frames)/2 {
+		c.t.Fatalf("Wrong frame count: Wanted %d, got %d", len(frames)/2, len(stackFrames))
+	}
+	for i, frame := range stackFrames {
+		frame := frame.(map[string]interface{})
+		name, line := frames[2*i], frames[2*i+1]
+		if frame["name"] != name || frame["line"] != float64(line.(int)) {
+			c.t.Fatalf("Wrong frame %d: Wanted %v at %v, got %v at %v",
+				i, name, line, frame["name"], frame["line"])
+		}
+	}
+}
+
+// Check variables of reference, formatted as name=value.
+func (c *client) expectVariables(reference int, target string) {
+	body := c.request("variables", map[string]interface{}{"variablesReference": reference})
+	variables := []string{}
+	for _, v := range body["variables"].([]interface{}) {
+		v := v.(map[string]interface{})
+		variables = append(variables, v["name"].(string)+"="+v["value"].(string))
+	}
+	if result := strings.Join(variables, ","); result != target {
+		c.t.Fatalf("Wrong variables: Wanted (%s), got (%s)", target, result)
+	}
+}
+
+func (c *client) close() {
+	c.writer.Close()
+	if err := <-c.done; err != nil {
+		c.t.Fatalf("Unexpected error: %s", err.Error())
+	}
+}
+
+func writeScript(t *testing.T) string {
+	fileName := filepath.Join(t.TempDir(), "script.gd")
+	if err := os.WriteFile(fileName, []byte(strings.Join(script, "\n")), 0644); err != nil {
+		t.Fatalf("Can't write script: %s", err.Error())
+	}
+	return fileName
+}
+
+func TestSession(t *testing.T) {
+	fileName := writeScript(t)
+
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: debug session, vm %v ...", vm)
+
+		c := newClient(t)
+		body := c.request("initialize", map[string]interface{}{"adapterID": "gdync"})
+		if body["supportsConfigurationDoneRequest"] != true {
+			t.Fatalf("Wrong capabilities: %v", body)
+		}
+		c.expect("event", "initialized")
+
+		c.request("launch", map[string]interface{}{
+			"program":     fileName,
+			"stopOnEntry": true,
+			"vm":          vm,
+		})
+		body = c.request("setBreakpoints", map[string]interface{}{
+			"source":      map[string]interface{}{"path": fileName},
+			"breakpoints": []interface{}{map[string]interface{}{"line": 3}},
+		})
+		if len(body["breakpoints"].([]interface{})) != 1 {
+			t.Fatalf("Wrong breakpoints: %v", body)
+		}
+		c.request("configurationDone", nil)
+
+		c.expectStopped("entry")
+		body = c.request("threads", nil)
+		if len(body["threads"].([]interface{})) != 1 {
+			t.Fatalf("Wrong threads: %v", body)
+		}
+		c.expectStack("<global>", 7)
+
+		c.request("continue", map[string]interface{}{"threadId": threadID})
+		c.expectStopped("breakpoint")
+		c.expectStack("fib", 3, "<global>", 9)
+		body = c.request("scopes", map[string]interface{}{"frameId": 0})
+		if len(body["scopes"].([]interface{})) != 2 {
+			t.Fatalf("Wrong scopes: %v", body)
+		}
+		c.expectVariables(1, "n=0")
+
+		c.request("stepOut", map[string]interface{}{"threadId": threadID})
+		c.expectStopped("step")
+		c.expectStack("<global>", 8)
+		c.request("next", map[string]interface{}{"threadId": threadID})
+		c.expectStopped("step")
+		c.expectStack("<global>", 9)
+		c.expectVariables(2, "i=1,total=0")
+
+		c.request("stepIn", map[string]interface{}{"threadId": threadID})
+		c.expectStopped("step")
+		c.expectStack("fib", 2, "<global>", 9)
+
+		c.request("setBreakpoints", map[string]interface{}{
+			"source":      map[string]interface{}{"path": fileName},
+			"breakpoints": []interface{}{},
+		})
+		c.request("continue", map[string]interface{}{"threadId": threadID})
+		body = c.expect("event", "exited")["body"].(map[string]interface{})
+		if body["exitCode"] != float64(0) {
+			t.Fatalf("Wrong exit code: %v", body["exitCode"])
+		}
+		c.expect("event", "terminated")
+		if c.output != "2\n" {
+			t.Fatalf("Wrong output: Wanted (2\n), got (%s)", c.output)
+		}
+
+		c.request("disconnect", nil)
+		c.close()
+
+		t.Log("Passed")
+	}
+}
+
+func TestDisconnect(t *testing.T) {
+	t.Log("Test: disconnect a stopped session ...")
+
+	fileName := writeScript(t)
+	c := newClient(t)
+	c.request("initialize", nil)
+	c.request("launch", map[string]interface{}{"program": fileName, "stopOnEntry": true})
+	c.request("configurationDone", nil)
+	c.expectStopped("entry")
+
+	// the script is aborted, nothing is printed
+	c.request("disconnect", nil)
+	body := c.expect("event", "exited")["body"].(map[string]interface{})
+	if body["exitCode"] != float64(1) {
+		t.Fatalf("Wrong exit code: %v", body["exitCode"])
+	}
+	if c.output != "" {
+		t.Fatalf("Script isn't aborted: %s", c.output)
+	}
+	c.close()
+
+	t.Log("Passed")
+}
+
+func TestRequestError(t *testing.T) {
+	t.Log("Test: request error ...")
+
+	c := newClient(t)
+	for _, command := range []string{"launch", "continue", "stackTrace", "evaluate"} {
+		c.send(command, map[string]interface{}{})
+		if resp := c.expect("response", command); resp["success"] != false {
+			t.Fatalf("Request %s should fail", command)
+		}
+	}
+	c.close()
+
+	t.Log("Passed")
+}
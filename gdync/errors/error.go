@@ -107,6 +107,67 @@ func NewFunctionDuplicateDefinitionError(name string, firstLoc,
 	}
 }
 
+type StructDuplicateDefinitionError struct {
+	baseError
+}
+
+func NewStructDuplicateDefinitionError(name string, firstLoc,
+	secondLoc *common.Location) *StructDuplicateDefinitionError {
+	format := "Duplicated struct definition %s, at %s, %d, %d"
+	return &StructDuplicateDefinitionError{
+		baseError: baseError{
+			message: fmt.Sprintf(format, name, firstLoc.GetFileName(),
+				firstLoc.GetLine(), firstLoc.GetPosition()),
+			location: secondLoc,
+		},
+	}
+}
+
+type FieldDuplicateDefinitionError struct {
+	baseError
+}
+
+func NewFieldDuplicateDefinitionError(name, field string, firstLoc,
+	secondLoc *common.Location) *FieldDuplicateDefinitionError {
+	format := "Duplicated field %s in struct %s, at %s, %d, %d"
+	return &FieldDuplicateDefinitionError{
+		baseError: baseError{
+			message: fmt.Sprintf(format, field, name, firstLoc.GetFileName(),
+				firstLoc.GetLine(), firstLoc.GetPosition()),
+			location: secondLoc,
+		},
+	}
+}
+
+type CaseDuplicateDefinitionError struct {
+	baseError
+}
+
+func NewCaseDuplicateDefinitionError(value string, firstLoc,
+	secondLoc *common.Location) *CaseDuplicateDefinitionError {
+	format := "Duplicated case %s in switch, at %s, %d, %d"
+	return &CaseDuplicateDefinitionError{
+		baseError: baseError{
+			message: fmt.Sprintf(format, value, firstLoc.GetFileName(),
+				firstLoc.GetLine(), firstLoc.GetPosition()),
+			location: secondLoc,
+		},
+	}
+}
+
+type ImportError struct {
+	baseError
+}
+
+func NewImportError(path, message string, location *common.Location) *ImportError {
+	return &ImportError{
+		baseError: baseError{
+			message:  fmt.Sprintf("Can't import %s: %s", path, message),
+			location: location,
+		},
+	}
+}
+
 //
 // Runtime error
 //
@@ -200,6 +261,107 @@ func NewNotBoolExpressionError(typ string,
 	}
 }
 
+type DivisionByZeroError struct {
+	baseError
+}
+
+func NewDivisionByZeroError(location *common.Location) *DivisionByZeroError {
+	return &DivisionByZeroError{
+		baseError: baseError{
+			message:  "Division by zero",
+			location: location,
+		},
+	}
+}
+
+type FieldNotFoundError struct {
+	baseError
+}
+
+func NewFieldNotFoundError(name, field string,
+	location *common.Location) *FieldNotFoundError {
+	return &FieldNotFoundError{
+		baseError: baseError{
+			message:  fmt.Sprintf("Undefined field %s of struct %s", field, name),
+			location: location,
+		},
+	}
+}
+
+type NativeFunctionError struct {
+	baseError
+}
+
+func NewNativeFunctionError(name, message string,
+	location *common.Location) *NativeFunctionError {
+	return &NativeFunctionError{
+		baseError: baseError{
+			message:  fmt.Sprintf("Error in native function %s: %s", name, message),
+			location: location,
+		},
+	}
+}
+
+// ExceptionError carries a value thrown by script, value is the thrown
+// script value, which can't be referred here.
+type ExceptionError struct {
+	baseError
+	value interface{}
+}
+
+func NewExceptionError(value interface{}, message string,
+	location *common.Location) *ExceptionError {
+	return &ExceptionError{
+		baseError: baseError{
+			message:  message,
+			location: location,
+		},
+		value: value,
+	}
+}
+
+func (error *ExceptionError) GetValue() interface{} {
+	return error.value
+}
+
+// LimitExceededError aborts a script which exceeds an execution limit or
+// whose context is done, it can't be caught by script.
+type LimitExceededError struct {
+	baseError
+	limit string
+}
+
+func NewLimitExceededError(limit, message string,
+	location *common.Location) *LimitExceededError {
+	return &LimitExceededError{
+		baseError: baseError{
+			message:  message,
+			location: location,
+		},
+		limit: limit,
+	}
+}
+
+// Name of the exceeded limit, like "statements" or "time".
+func (error *LimitExceededError) GetLimit() string {
+	return error.limit
+}
+
+// DeadlockError aborts the tasks blocked forever, since all tasks of a
+// script are blocked, it can't be caught by script.
+type DeadlockError struct {
+	baseError
+}
+
+func NewDeadlockError(message string, location *common.Location) *DeadlockError {
+	return &DeadlockError{
+		baseError: baseError{
+			message:  message,
+			location: location,
+		},
+	}
+}
+
 //
 // internal error
 //
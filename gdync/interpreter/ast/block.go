@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"github.com/mlmhl/compiler/common"
 	gerror "github.com/mlmhl/compiler/gdync/errors"
 )
 
@@ -18,12 +19,19 @@ func NewBlock(statements []Statement) *Block {
 	}
 }
 
+func (block *Block) GetLocation() *common.Location {
+	return nil
+}
+
 func (block *Block) Execute(env *Environment) (
 	*StatementResult, gerror.Error) {
 	var err gerror.Error
 	var result *StatementResult
 
 	for _, statement := range block.statements {
+		if err = env.Step(statement.GetLocation()); err != nil {
+			return nil, err
+		}
 		result, err = statement.Execute(env)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,229 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mlmhl/compiler/common"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Instruction set of the bytecode backend, each instruction has an opcode
+// and at most two integer operands, operands refer to constants, identifiers,
+// keywords, local variable slots or jump targets of the chunk.
+//
+
+type Opcode byte
+
+const (
+	OP_CONSTANT   Opcode = iota // push constants[a]
+	OP_POP                      // discard the top value
+	OP_GET_GLOBAL               // push global variable identifiers[a]
+	OP_SET_GLOBAL               // assign the top value to global variable identifiers[a]
+	OP_GET_LOCAL                // push local variable in slot a, identifiers[b] reports errors
+	OP_SET_LOCAL                // assign the top value to local variable in slot a named identifiers[b]
+	OP_GLOBAL                   // share global variable identifiers[b] with slot a
+
+	// binary operators pop the right operand, then the left one
+	OP_ADD
+	OP_SUBTRACT
+	OP_MULTIPLY
+	OP_DIVIDE
+	OP_MOD
+	OP_EQUAL
+	OP_NOT_EQUAL
+	OP_GT
+	OP_LT
+	OP_GTE
+	OP_LTE
+
+	OP_MINUS
+	OP_NOT
+	OP_INCREMENT // add 1 to the top value
+	OP_DECREMENT // subtract 1 from the top value
+
+	OP_JUMP          // jump to a
+	OP_JUMP_IF_FALSE // pop a condition of statement keywords[b], jump to a if it's false
+
+	// short circuit of logical operator keywords[b], the top value is kept
+	// as result if it's decisive, otherwise it's popped
+	OP_JUMP_IF_FALSE_OR_POP
+	OP_JUMP_IF_TRUE_OR_POP
+	OP_CHECK_BOOL // the top value must be a bool operand of keywords[b]
+	OP_CALL       // call function identifiers[a] with b arguments on the stack
+	OP_SPAWN      // spawn a task calling function identifiers[a] with b arguments, push the task
+	OP_RETURN     // return the top value to the caller
+
+	OP_ITERATE // replace the top collection with an iterator over it
+	OP_NEXT    // push the next element of the top iterator, jump to a if it's exhausted
+	OP_MATCH   // pop the top value and jump to a if it matches case constants[b]
+
+	OP_GET_FIELD // replace the top struct with its field identifiers[a]
+	OP_SET_FIELD // pop a value and a struct, assign the value to field identifiers[a] and push it
+
+	// install an exception handler at a, a catch handler if b is 0, which
+	// gets the caught value, otherwise a finally handler getting the error
+	OP_TRY
+	OP_END_TRY // remove the latest handler
+	OP_THROW   // throw the top value
+	OP_RETHROW // throw the error got by a finally handler again
+
+	OP_STEP // count a statement against the execution limits
+)
+
+var opcodeNames []string = []string{
+	OP_CONSTANT:             "CONSTANT",
+	OP_POP:                  "POP",
+	OP_GET_GLOBAL:           "GET_GLOBAL",
+	OP_SET_GLOBAL:           "SET_GLOBAL",
+	OP_GET_LOCAL:            "GET_LOCAL",
+	OP_SET_LOCAL:            "SET_LOCAL",
+	OP_GLOBAL:               "GLOBAL",
+	OP_ADD:                  "ADD",
+	OP_SUBTRACT:             "SUBTRACT",
+	OP_MULTIPLY:             "MULTIPLY",
+	OP_DIVIDE:               "DIVIDE",
+	OP_MOD:                  "MOD",
+	OP_EQUAL:                "EQUAL",
+	OP_NOT_EQUAL:            "NOT_EQUAL",
+	OP_GT:                   "GT",
+	OP_LT:                   "LT",
+	OP_GTE:                  "GTE",
+	OP_LTE:                  "LTE",
+	OP_MINUS:                "MINUS",
+	OP_NOT:                  "NOT",
+	OP_INCREMENT:            "INCREMENT",
+	OP_DECREMENT:            "DECREMENT",
+	OP_JUMP:                 "JUMP",
+	OP_JUMP_IF_FALSE:        "JUMP_IF_FALSE",
+	OP_JUMP_IF_FALSE_OR_POP: "JUMP_IF_FALSE_OR_POP",
+	OP_JUMP_IF_TRUE_OR_POP:  "JUMP_IF_TRUE_OR_POP",
+	OP_CHECK_BOOL:           "CHECK_BOOL",
+	OP_CALL:                 "CALL",
+	OP_SPAWN:                "SPAWN",
+	OP_RETURN:               "RETURN",
+	OP_ITERATE:              "ITERATE",
+	OP_NEXT:                 "NEXT",
+	OP_MATCH:                "MATCH",
+	OP_GET_FIELD:            "GET_FIELD",
+	OP_SET_FIELD:            "SET_FIELD",
+	OP_TRY:                  "TRY",
+	OP_END_TRY:              "END_TRY",
+	OP_THROW:                "THROW",
+	OP_RETHROW:              "RETHROW",
+	OP_STEP:                 "STEP",
+}
+
+func (op Opcode) String() string {
+	if int(op) < len(opcodeNames) {
+		return opcodeNames[op]
+	}
+	return fmt.Sprintf("Opcode(%d)", op)
+}
+
+// operator names of binary opcodes, used to invoke value operations
+var binaryOperators map[Opcode]string = map[Opcode]string{
+	OP_ADD:       types.ADD,
+	OP_SUBTRACT:  types.SUBTRACT,
+	OP_MULTIPLY:  types.MULTIPLY,
+	OP_DIVIDE:    types.DIVIDE,
+	OP_MOD:       types.MOD,
+	OP_EQUAL:     types.EQUAL,
+	OP_NOT_EQUAL: types.NOT_EQUAL,
+	OP_GT:        types.GT,
+	OP_LT:        types.LT,
+	OP_GTE:       types.GTE,
+	OP_LTE:       types.LTE,
+}
+
+type Instruction struct {
+	op Opcode
+	a  int
+	b  int
+}
+
+// Chunk is the compiled code of a function or a top level statement.
+type Chunk struct {
+	code []Instruction
+	// source location of each instruction, nil if it never fails
+	locations []*common.Location
+
+	constants   []types.Value
+	identifiers []*types.Identifier
+	keywords    []string
+}
+
+func newChunk() *Chunk {
+	return &Chunk{
+		code:      []Instruction{},
+		locations: []*common.Location{},
+
+		constants:   []types.Value{},
+		identifiers: []*types.Identifier{},
+		keywords:    []string{},
+	}
+}
+
+// Append an instruction, returns its index.
+func (chunk *Chunk) emit(op Opcode, a, b int, location *common.Location) int {
+	chunk.code = append(chunk.code, Instruction{op: op, a: a, b: b})
+	chunk.locations = append(chunk.locations, location)
+	return len(chunk.code) - 1
+}
+
+func (chunk *Chunk) addConstant(value types.Value) int {
+	chunk.constants = append(chunk.constants, value)
+	return len(chunk.constants) - 1
+}
+
+func (chunk *Chunk) addIdentifier(identifier *types.Identifier) int {
+	chunk.identifiers = append(chunk.identifiers, identifier)
+	return len(chunk.identifiers) - 1
+}
+
+func (chunk *Chunk) addKeyword(keyword string) int {
+	for i, k := range chunk.keywords {
+		if k == keyword {
+			return i
+		}
+	}
+	chunk.keywords = append(chunk.keywords, keyword)
+	return len(chunk.keywords) - 1
+}
+
+// String disassemble the chunk, one instruction per line.
+func (chunk *Chunk) String() string {
+	lines := []string{}
+	for pc, instruction := range chunk.code {
+		line := fmt.Sprintf("%04d %-13s", pc, instruction.op)
+		switch instruction.op {
+		case OP_CONSTANT:
+			line += fmt.Sprintf(" %v", chunk.constants[instruction.a])
+		case OP_GET_GLOBAL, OP_SET_GLOBAL, OP_GET_FIELD, OP_SET_FIELD:
+			line += " " + chunk.identifiers[instruction.a].GetName()
+		case OP_GET_LOCAL, OP_SET_LOCAL, OP_GLOBAL:
+			line += fmt.Sprintf(" %d(%s)", instruction.a,
+				chunk.identifiers[instruction.b].GetName())
+		case OP_JUMP, OP_NEXT:
+			line += fmt.Sprintf(" %d", instruction.a)
+		case OP_MATCH:
+			line += fmt.Sprintf(" %d %v", instruction.a, chunk.constants[instruction.b])
+		case OP_JUMP_IF_FALSE, OP_JUMP_IF_FALSE_OR_POP, OP_JUMP_IF_TRUE_OR_POP:
+			line += fmt.Sprintf(" %d(%s)", instruction.a, chunk.keywords[instruction.b])
+		case OP_CHECK_BOOL:
+			line += " " + chunk.keywords[instruction.b]
+		case OP_CALL, OP_SPAWN:
+			line += fmt.Sprintf(" %s %d", chunk.identifiers[instruction.a].GetName(),
+				instruction.b)
+		case OP_TRY:
+			kind := "catch"
+			if instruction.b != 0 {
+				kind = "finally"
+			}
+			line += fmt.Sprintf(" %d(%s)", instruction.a, kind)
+		}
+		lines = append(lines, strings.TrimRight(line, " "))
+	}
+	return strings.Join(lines, "\n")
+}
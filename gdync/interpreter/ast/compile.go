@@ -0,0 +1,569 @@
+package ast
+
+import (
+	"github.com/mlmhl/compiler/common"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Compile resolved and folded AST to bytecode. Each top level statement and
+// each function is compiled to a chunk, the value returned by a top level
+// chunk is the value of an expression statement.
+//
+
+// jump targets of the enclosing loop, or switch statement which is
+// only ended by break
+type loop struct {
+	breaks    []int // jumps to patch with the end of loop
+	continues []int // jumps to patch with the target of continue
+	isSwitch  bool
+
+	regions int // count of regions entered outside the loop
+	pending int // count of pending values outside the loop
+}
+
+// code protected by an exception handler, which must be removed when
+// jumping out of the region, the finally block is executed then.
+type region struct {
+	finally *Block
+}
+
+type Compiler struct {
+	chunk *Chunk
+	local bool // compiling a function body
+
+	loops   []*loop
+	regions []*region
+	// values kept on the stack while finally blocks are executed
+	pending int
+}
+
+func newCompiler(local bool) *Compiler {
+	return &Compiler{
+		chunk:   newChunk(),
+		local:   local,
+		loops:   []*loop{},
+		regions: []*region{},
+	}
+}
+
+// CompileStatement compile a top level statement.
+func CompileStatement(statement Statement) *Chunk {
+	compiler := newCompiler(false)
+	compiler.chunk.emit(OP_STEP, 0, 0, statement.GetLocation())
+	if expressionStatement, ok := statement.(*ExpressionStatement); ok {
+		compiler.compileExpression(expressionStatement.expression)
+		compiler.chunk.emit(OP_RETURN, 0, 0, nil)
+	} else {
+		compiler.compileStatement(statement)
+		compiler.returnNull()
+	}
+	return compiler.chunk
+}
+
+// CompileFunction compile the body of a custom function.
+func CompileFunction(function *CustomFunction) *Chunk {
+	compiler := newCompiler(true)
+	compiler.compileBlock(function.block)
+	// no return statement, return a null value
+	compiler.returnNull()
+	return compiler.chunk
+}
+
+func (compiler *Compiler) compileBlock(block *Block) {
+	for _, statement := range block.statements {
+		compiler.chunk.emit(OP_STEP, 0, 0, statement.GetLocation())
+		compiler.compileStatement(statement)
+	}
+}
+
+func (compiler *Compiler) compileStatement(statement Statement) {
+	chunk := compiler.chunk
+
+	switch statement := statement.(type) {
+	case *Block:
+		compiler.compileBlock(statement)
+	case *ExpressionStatement:
+		compiler.compileExpression(statement.expression)
+		chunk.emit(OP_POP, 0, 0, nil)
+	case *GlobalStatement:
+		for i, id := range statement.identifiers {
+			chunk.emit(OP_GLOBAL, statement.slots[i], chunk.addIdentifier(id), statement.location)
+		}
+	case *IfStatement:
+		compiler.compileIf(statement)
+	case *WhileStatement:
+		start := chunk.emit(OP_STEP, 0, 0, statement.location)
+		exit := compiler.compileCondition(statement.condition, "while", statement.location)
+		compiler.compileLoop(statement.block)
+		chunk.emit(OP_JUMP, start, 0, nil)
+		compiler.patch(exit)
+		compiler.endLoop(start)
+	case *ForStatement:
+		compiler.compileFor(statement)
+	case *ForeachStatement:
+		compiler.compileForeach(statement)
+	case *SwitchStatement:
+		compiler.compileSwitch(statement)
+	case *ReturnStatement:
+		if statement.returnValue == nil {
+			compiler.returnNull()
+		} else {
+			compiler.compileExpression(statement.returnValue)
+			compiler.emitReturn()
+		}
+	case *BreakStatement:
+		if len(compiler.loops) == 0 {
+			// break outside of loop ends the function or the top level statement
+			compiler.returnNull()
+			return
+		}
+		current := compiler.loops[len(compiler.loops)-1]
+		compiler.leaveLoop(current)
+		current.breaks = append(current.breaks, chunk.emit(OP_JUMP, -1, 0, nil))
+	case *ContinueStatement:
+		current := compiler.continuable()
+		if current == nil {
+			compiler.returnNull()
+			return
+		}
+		compiler.leaveLoop(current)
+		current.continues = append(current.continues, chunk.emit(OP_JUMP, -1, 0, nil))
+	case *ThrowStatement:
+		compiler.compileExpression(statement.value)
+		chunk.emit(OP_THROW, 0, 0, statement.location)
+	case *TryStatement:
+		compiler.compileTry(statement)
+	}
+}
+
+// The try block is handled by the catch block if it exists, which is
+// handled by the finally block if it exists. The finally block is compiled
+// once for normal completion, once for exceptions, and once more for each
+// jump out of the statement.
+func (compiler *Compiler) compileTry(statement *TryStatement) {
+	chunk := compiler.chunk
+	finally := statement.finallyBlock
+
+	// handlers of the finally block
+	handlers := []int{}
+
+	if statement.catchBlock == nil {
+		handlers = append(handlers, chunk.emit(OP_TRY, -1, 1, statement.location))
+		compiler.compileRegion(statement.tryBlock, finally)
+	} else {
+		handler := chunk.emit(OP_TRY, -1, 0, statement.location)
+		compiler.compileRegion(statement.tryBlock, finally)
+		normal := chunk.emit(OP_JUMP, -1, 0, nil)
+
+		// the caught value is on the stack
+		compiler.patch(handler)
+		if finally != nil {
+			handlers = append(handlers, chunk.emit(OP_TRY, -1, 1, statement.location))
+		}
+		compiler.set(statement.identifier, statement.slot)
+		chunk.emit(OP_POP, 0, 0, nil)
+		if finally != nil {
+			compiler.compileRegion(statement.catchBlock, finally)
+		} else {
+			compiler.compileBlock(statement.catchBlock)
+		}
+		compiler.patch(normal)
+	}
+
+	if finally == nil {
+		return
+	}
+	compiler.compileBlock(finally)
+	end := chunk.emit(OP_JUMP, -1, 0, nil)
+
+	// the error got by the handler is kept until the finally block ends
+	for _, handler := range handlers {
+		compiler.patch(handler)
+	}
+	compiler.pending++
+	compiler.compileBlock(finally)
+	compiler.pending--
+	chunk.emit(OP_RETHROW, 0, 0, nil)
+	compiler.patch(end)
+}
+
+// Compile a block protected by the handler just installed.
+func (compiler *Compiler) compileRegion(block *Block, finally *Block) {
+	compiler.regions = append(compiler.regions, &region{finally: finally})
+	compiler.compileBlock(block)
+	compiler.regions = compiler.regions[:len(compiler.regions)-1]
+	compiler.chunk.emit(OP_END_TRY, 0, 0, nil)
+}
+
+// Leave the regions inside a loop before jumping out of its block,
+// values pending inside the loop are dropped.
+func (compiler *Compiler) leaveLoop(current *loop) {
+	compiler.unwind(current.regions)
+	for i := current.pending; i < compiler.pending; i++ {
+		compiler.chunk.emit(OP_POP, 0, 0, nil)
+	}
+}
+
+// Leave regions from the innermost one to the one at depth, the finally
+// blocks are compiled outside of their own regions.
+func (compiler *Compiler) unwind(depth int) {
+	regions := compiler.regions
+	defer func() {
+		compiler.regions = regions
+	}()
+
+	for i := len(regions) - 1; i >= depth; i-- {
+		compiler.regions = regions[:i]
+		compiler.chunk.emit(OP_END_TRY, 0, 0, nil)
+		if regions[i].finally != nil {
+			compiler.compileBlock(regions[i].finally)
+		}
+	}
+}
+
+func (compiler *Compiler) compileIf(statement *IfStatement) {
+	chunk := compiler.chunk
+	ends := []int{}
+
+	next := compiler.compileCondition(statement.condition, "if", statement.location)
+	compiler.compileBlock(statement.ifBlock)
+	ends = append(ends, chunk.emit(OP_JUMP, -1, 0, nil))
+	compiler.patch(next)
+
+	for _, elif := range statement.elifBlocks {
+		next = compiler.compileCondition(elif.condition, "elif", elif.location)
+		compiler.compileBlock(elif.block)
+		ends = append(ends, chunk.emit(OP_JUMP, -1, 0, nil))
+		compiler.patch(next)
+	}
+
+	if statement.elseBlock.block != nil {
+		compiler.compileBlock(statement.elseBlock.block)
+	}
+	for _, end := range ends {
+		compiler.patch(end)
+	}
+}
+
+func (compiler *Compiler) compileFor(statement *ForStatement) {
+	chunk := compiler.chunk
+
+	if statement.init != nil {
+		compiler.compileExpression(statement.init)
+		chunk.emit(OP_POP, 0, 0, nil)
+	}
+
+	start := chunk.emit(OP_STEP, 0, 0, statement.location)
+	exit := -1
+	if statement.condition != nil {
+		exit = compiler.compileCondition(statement.condition, "for", statement.location)
+	}
+
+	// continue jumps to the post expression
+	compiler.compileLoop(statement.block)
+	post := len(chunk.code)
+	if statement.post != nil {
+		compiler.compileExpression(statement.post)
+		chunk.emit(OP_POP, 0, 0, nil)
+	}
+	chunk.emit(OP_JUMP, start, 0, nil)
+
+	if exit >= 0 {
+		compiler.patch(exit)
+	}
+	compiler.endLoop(post)
+}
+
+// The iterator is kept on the stack until the loop ends.
+func (compiler *Compiler) compileForeach(statement *ForeachStatement) {
+	chunk := compiler.chunk
+
+	compiler.compileExpression(statement.collection)
+	chunk.emit(OP_ITERATE, 0, 0, statement.location)
+	compiler.pending++
+
+	start := chunk.emit(OP_NEXT, -1, 0, nil)
+	chunk.emit(OP_STEP, 0, 0, statement.location)
+	compiler.set(statement.identifier, statement.slot)
+	chunk.emit(OP_POP, 0, 0, nil)
+	compiler.compileLoop(statement.block)
+	chunk.emit(OP_JUMP, start, 0, nil)
+
+	compiler.endLoop(start)
+	compiler.patch(start)
+	compiler.pending--
+	chunk.emit(OP_POP, 0, 0, nil)
+}
+
+// The value is compared with case constants, the block of the matched case
+// is jumped to, otherwise the default block is executed.
+func (compiler *Compiler) compileSwitch(statement *SwitchStatement) {
+	chunk := compiler.chunk
+
+	compiler.compileExpression(statement.value)
+	matches := make([][]int, len(statement.cases))
+	for i, c := range statement.cases {
+		for _, value := range c.values {
+			matches[i] = append(matches[i], chunk.emit(OP_MATCH, -1, chunk.addConstant(value), nil))
+		}
+	}
+	chunk.emit(OP_POP, 0, 0, nil)
+
+	compiler.enterLoop(true)
+	ends := []int{}
+	if statement.defaultBlock != nil {
+		compiler.compileBlock(statement.defaultBlock)
+	}
+	for i, c := range statement.cases {
+		ends = append(ends, chunk.emit(OP_JUMP, -1, 0, nil))
+		for _, match := range matches[i] {
+			compiler.patch(match)
+		}
+		compiler.compileBlock(c.block)
+	}
+	for _, end := range ends {
+		compiler.patch(end)
+	}
+	compiler.endLoop(-1)
+}
+
+// Compile the block of a loop, the loop is ended by endLoop.
+func (compiler *Compiler) compileLoop(block *Block) {
+	compiler.enterLoop(false)
+	compiler.compileBlock(block)
+}
+
+func (compiler *Compiler) enterLoop(isSwitch bool) {
+	compiler.loops = append(compiler.loops, &loop{
+		breaks:    []int{},
+		continues: []int{},
+		isSwitch:  isSwitch,
+		regions:   len(compiler.regions),
+		pending:   compiler.pending,
+	})
+}
+
+// Returns the innermost loop except switch statements, nil if there is none.
+func (compiler *Compiler) continuable() *loop {
+	for i := len(compiler.loops) - 1; i >= 0; i-- {
+		if !compiler.loops[i].isSwitch {
+			return compiler.loops[i]
+		}
+	}
+	return nil
+}
+
+// Patch breaks with current position and continues with target.
+func (compiler *Compiler) endLoop(target int) {
+	current := compiler.loops[len(compiler.loops)-1]
+	compiler.loops = compiler.loops[:len(compiler.loops)-1]
+
+	for _, jump := range current.breaks {
+		compiler.patch(jump)
+	}
+	for _, jump := range current.continues {
+		compiler.chunk.code[jump].a = target
+	}
+}
+
+// Compile a condition followed by a conditional jump,
+// returns the jump to patch with the target of false.
+func (compiler *Compiler) compileCondition(condition Expression,
+	keyword string, location *common.Location) int {
+	compiler.compileExpression(condition)
+	return compiler.chunk.emit(OP_JUMP_IF_FALSE, -1,
+		compiler.chunk.addKeyword(keyword), location)
+}
+
+// Set the target of jump to current position.
+func (compiler *Compiler) patch(jump int) {
+	compiler.chunk.code[jump].a = len(compiler.chunk.code)
+}
+
+func (compiler *Compiler) returnNull() {
+	compiler.chunk.emit(OP_CONSTANT,
+		compiler.chunk.addConstant(types.NewValue(types.NULL_TYPE, nil)), 0, nil)
+	compiler.emitReturn()
+}
+
+// Return the top value, which is kept while leaving all regions.
+func (compiler *Compiler) emitReturn() {
+	compiler.pending++
+	compiler.unwind(0)
+	compiler.pending--
+	compiler.chunk.emit(OP_RETURN, 0, 0, nil)
+}
+
+// Push the value of a variable, which is global if it has no slot.
+func (compiler *Compiler) get(identifier *types.Identifier, slot int) {
+	if compiler.local && slot >= 0 {
+		compiler.chunk.emit(OP_GET_LOCAL, slot, compiler.chunk.addIdentifier(identifier),
+			identifier.GetLocation())
+	} else {
+		compiler.chunk.emit(OP_GET_GLOBAL, compiler.chunk.addIdentifier(identifier), 0,
+			identifier.GetLocation())
+	}
+}
+
+// Assign the top value to a variable, the value is kept.
+func (compiler *Compiler) set(identifier *types.Identifier, slot int) {
+	if compiler.local && slot >= 0 {
+		compiler.chunk.emit(OP_SET_LOCAL, slot, compiler.chunk.addIdentifier(identifier), nil)
+	} else {
+		compiler.chunk.emit(OP_SET_GLOBAL, compiler.chunk.addIdentifier(identifier), 0, nil)
+	}
+}
+
+func (compiler *Compiler) compileExpression(expression Expression) {
+	chunk := compiler.chunk
+
+	switch e := expression.(type) {
+	case *StringExpression:
+		chunk.emit(OP_CONSTANT, chunk.addConstant(e.value), 0, nil)
+	case *IntegerExpression:
+		chunk.emit(OP_CONSTANT, chunk.addConstant(e.value), 0, nil)
+	case *FloatExpression:
+		chunk.emit(OP_CONSTANT, chunk.addConstant(e.value), 0, nil)
+	case *BoolExpression:
+		chunk.emit(OP_CONSTANT, chunk.addConstant(e.value), 0, nil)
+	case *NullExpression:
+		chunk.emit(OP_CONSTANT, chunk.addConstant(e.value), 0, nil)
+	case *IdentifierExpression:
+		compiler.get(e.identifier, e.slot)
+	case *AssignExpression:
+		compiler.compileExpression(e.operand)
+		compiler.set(e.identifier, e.slot)
+	case *FieldExpression:
+		compiler.compileExpression(e.object)
+		chunk.emit(OP_GET_FIELD, chunk.addIdentifier(e.field), 0, e.field.GetLocation())
+	case *FieldAssignExpression:
+		compiler.compileExpression(e.object)
+		compiler.compileExpression(e.operand)
+		chunk.emit(OP_SET_FIELD, chunk.addIdentifier(e.field), 0, e.field.GetLocation())
+	case *FunctionCallExpression:
+		for _, argument := range e.arguments {
+			compiler.compileExpression(argument.expression)
+		}
+		chunk.emit(OP_CALL, chunk.addIdentifier(e.identifier), len(e.arguments), e.location)
+	case *SpawnExpression:
+		for _, argument := range e.call.arguments {
+			compiler.compileExpression(argument.expression)
+		}
+		chunk.emit(OP_SPAWN, chunk.addIdentifier(e.call.identifier), len(e.call.arguments),
+			e.call.location)
+	case *IncrementExpression:
+		compiler.compileIncrement(e)
+	case *FieldIncrementExpression:
+		compiler.compileFieldIncrement(e)
+	case *ConditionalExpression:
+		exit := compiler.compileCondition(e.condition, "?:", e.location)
+		compiler.compileExpression(e.trueBranch)
+		end := chunk.emit(OP_JUMP, -1, 0, nil)
+		compiler.patch(exit)
+		compiler.compileExpression(e.falseBranch)
+		compiler.patch(end)
+	case *AndExpression:
+		compiler.compileLogical(OP_JUMP_IF_FALSE_OR_POP, "&&", &e.binaryExpression)
+	case *OrExpression:
+		compiler.compileLogical(OP_JUMP_IF_TRUE_OR_POP, "||", &e.binaryExpression)
+	case *MinusExpression:
+		compiler.compileExpression(e.expression)
+		chunk.emit(OP_MINUS, 0, 0, e.location)
+	case *NotExpression:
+		compiler.compileExpression(e.expression)
+		chunk.emit(OP_NOT, 0, 0, e.location)
+	case binaryOperation:
+		binary := e.getBinary()
+		compiler.compileExpression(binary.left)
+		compiler.compileExpression(binary.right)
+		chunk.emit(binaryOpcode(expression), 0, 0, binary.location)
+	}
+}
+
+// The right operand is skipped if the left one is decisive.
+func (compiler *Compiler) compileLogical(jump Opcode, keyword string,
+	expression *binaryExpression) {
+	chunk := compiler.chunk
+
+	compiler.compileExpression(expression.left)
+	end := chunk.emit(jump, -1, chunk.addKeyword(keyword), expression.location)
+	compiler.compileExpression(expression.right)
+	chunk.emit(OP_CHECK_BOOL, 0, chunk.addKeyword(keyword), expression.location)
+	compiler.patch(end)
+}
+
+// Postfix form keeps a copy of the old value under the new one.
+func (compiler *Compiler) compileIncrement(expression *IncrementExpression) {
+	chunk := compiler.chunk
+
+	compiler.get(expression.identifier, expression.slot)
+	if !expression.prefix {
+		compiler.get(expression.identifier, expression.slot)
+	}
+
+	if expression.op == types.INCREMENT {
+		chunk.emit(OP_INCREMENT, 0, 0, expression.location)
+	} else {
+		chunk.emit(OP_DECREMENT, 0, 0, expression.location)
+	}
+
+	compiler.set(expression.identifier, expression.slot)
+	if !expression.prefix {
+		chunk.emit(OP_POP, 0, 0, nil)
+	}
+}
+
+// The object is evaluated again to set the field, postfix form gets the
+// old value first and drops the new one at last.
+func (compiler *Compiler) compileFieldIncrement(expression *FieldIncrementExpression) {
+	chunk := compiler.chunk
+	field := chunk.addIdentifier(expression.field)
+
+	if !expression.prefix {
+		compiler.compileExpression(expression.object)
+		chunk.emit(OP_GET_FIELD, field, 0, expression.field.GetLocation())
+	}
+
+	compiler.compileExpression(expression.object)
+	compiler.compileExpression(expression.object)
+	chunk.emit(OP_GET_FIELD, field, 0, expression.field.GetLocation())
+	if expression.op == types.INCREMENT {
+		chunk.emit(OP_INCREMENT, 0, 0, expression.location)
+	} else {
+		chunk.emit(OP_DECREMENT, 0, 0, expression.location)
+	}
+	chunk.emit(OP_SET_FIELD, field, 0, expression.field.GetLocation())
+
+	if !expression.prefix {
+		chunk.emit(OP_POP, 0, 0, nil)
+	}
+}
+
+func binaryOpcode(expression Expression) Opcode {
+	switch expression.(type) {
+	case *AddExpression:
+		return OP_ADD
+	case *SubtractExpression:
+		return OP_SUBTRACT
+	case *MultiplyExpression:
+		return OP_MULTIPLY
+	case *DivideExpression:
+		return OP_DIVIDE
+	case *ModExpression:
+		return OP_MOD
+	case *EqualExpression:
+		return OP_EQUAL
+	case *NotEqualExpression:
+		return OP_NOT_EQUAL
+	case *GTExpression:
+		return OP_GT
+	case *LTExpression:
+		return OP_LT
+	case *GTEExpression:
+		return OP_GTE
+	case *LTEExpression:
+		return OP_LTE
+	}
+	panic("Unknown binary expression!")
+}
@@ -0,0 +1,199 @@
+package ast
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mlmhl/compiler/common"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+func TestCompileLoop(t *testing.T) {
+	t.Log("Test: compile loop ...")
+
+	location := common.NewLocation(1, 1, "test")
+	id := types.NewIdentifier("x", location)
+
+	// while (x < 3) { if (x == 1) { break } x = x + 1; continue }
+	ifStatement := NewIfStatement(location)
+	ifStatement.SetCondition(NewEqualExpression(NewIdentifierExpression(id),
+		NewIntegerExpression(1), location))
+	ifStatement.SetIfBlock(NewBlock([]Statement{NewBreakStatement(location)}))
+	ifStatement.SetElseBlock(nil, nil)
+	statement := NewWhileStatement(location, NewLTExpression(NewIdentifierExpression(id),
+		NewIntegerExpression(3), location), NewBlock([]Statement{
+		ifStatement,
+		NewExpressionStatement(NewAssignExpression(NewAddExpression(
+			NewIdentifierExpression(id), NewIntegerExpression(1), location), id)),
+		NewContinueStatement(location),
+	}))
+
+	target := strings.Join([]string{
+		"0000 STEP",
+		"0001 STEP",
+		"0002 GET_GLOBAL    x",
+		"0003 CONSTANT      3",
+		"0004 LT",
+		"0005 JUMP_IF_FALSE 23(while)",
+		"0006 STEP",
+		"0007 GET_GLOBAL    x",
+		"0008 CONSTANT      1",
+		"0009 EQUAL",
+		"0010 JUMP_IF_FALSE 14(if)",
+		"0011 STEP",
+		"0012 JUMP          23",
+		"0013 JUMP          14",
+		"0014 STEP",
+		"0015 GET_GLOBAL    x",
+		"0016 CONSTANT      1",
+		"0017 ADD",
+		"0018 SET_GLOBAL    x",
+		"0019 POP",
+		"0020 STEP",
+		"0021 JUMP          1",
+		"0022 JUMP          1",
+		"0023 CONSTANT      null",
+		"0024 RETURN",
+	}, "\n")
+	if chunk := CompileStatement(statement).String(); chunk != target {
+		t.Fatalf("Wrong chunk: Wanted\n%s\ngot\n%s", target, chunk)
+	}
+
+	t.Log("Passed")
+}
+
+func TestCompileTry(t *testing.T) {
+	t.Log("Test: compile try ...")
+
+	location := common.NewLocation(1, 1, "test")
+	x := types.NewIdentifier("x", location)
+	e := types.NewIdentifier("e", location)
+
+	// try { throw x } catch (e) { x = e } finally { x = 0 }
+	statement := NewTryStatement(NewBlock([]Statement{
+		NewThrowStatement(NewIdentifierExpression(x), location),
+	}), location)
+	statement.SetCatchBlock(e, NewBlock([]Statement{
+		NewExpressionStatement(NewAssignExpression(NewIdentifierExpression(e), x)),
+	}))
+	statement.SetFinallyBlock(NewBlock([]Statement{
+		NewExpressionStatement(NewAssignExpression(NewIntegerExpression(0), x)),
+	}))
+
+	target := strings.Join([]string{
+		"0000 STEP",
+		"0001 TRY           7(catch)",
+		"0002 STEP",
+		"0003 GET_GLOBAL    x",
+		"0004 THROW",
+		"0005 END_TRY",
+		"0006 JUMP          15",
+		"0007 TRY           20(finally)",
+		"0008 SET_GLOBAL    e",
+		"0009 POP",
+		"0010 STEP",
+		"0011 GET_GLOBAL    e",
+		"0012 SET_GLOBAL    x",
+		"0013 POP",
+		"0014 END_TRY",
+		"0015 STEP",
+		"0016 CONSTANT      0",
+		"0017 SET_GLOBAL    x",
+		"0018 POP",
+		"0019 JUMP          25",
+		"0020 STEP",
+		"0021 CONSTANT      0",
+		"0022 SET_GLOBAL    x",
+		"0023 POP",
+		"0024 RETHROW",
+		"0025 CONSTANT      null",
+		"0026 RETURN",
+	}, "\n")
+	if chunk := CompileStatement(statement).String(); chunk != target {
+		t.Fatalf("Wrong chunk: Wanted\n%s\ngot\n%s", target, chunk)
+	}
+
+	t.Log("Passed")
+}
+
+func TestCompileForeach(t *testing.T) {
+	t.Log("Test: compile foreach ...")
+
+	location := common.NewLocation(1, 1, "test")
+	c := types.NewIdentifier("c", location)
+	s := types.NewIdentifier("s", location)
+
+	// for (c in s) { break }
+	statement := NewForeachStatement(location, c, NewIdentifierExpression(s),
+		NewBlock([]Statement{NewBreakStatement(location)}))
+
+	target := strings.Join([]string{
+		"0000 STEP",
+		"0001 GET_GLOBAL    s",
+		"0002 ITERATE",
+		"0003 NEXT          10",
+		"0004 STEP",
+		"0005 SET_GLOBAL    c",
+		"0006 POP",
+		"0007 STEP",
+		"0008 JUMP          10",
+		"0009 JUMP          3",
+		"0010 POP",
+		"0011 CONSTANT      null",
+		"0012 RETURN",
+	}, "\n")
+	if chunk := CompileStatement(statement).String(); chunk != target {
+		t.Fatalf("Wrong chunk: Wanted\n%s\ngot\n%s", target, chunk)
+	}
+
+	t.Log("Passed")
+}
+
+func TestCompileSwitch(t *testing.T) {
+	t.Log("Test: compile switch ...")
+
+	location := common.NewLocation(1, 1, "test")
+	x := types.NewIdentifier("x", location)
+
+	// switch (x) { case 1, 2: x = 0 case "a": break default: x = 1 }
+	statement := NewSwitchStatement(NewIdentifierExpression(x), location)
+	statement.AddCase([]types.Value{
+		types.NewValue(types.INTEGER_TYPE, int64(1)),
+		types.NewValue(types.INTEGER_TYPE, int64(2)),
+	}, []*common.Location{location, location}, NewBlock([]Statement{
+		NewExpressionStatement(NewAssignExpression(NewIntegerExpression(0), x)),
+	}))
+	statement.AddCase([]types.Value{types.NewValue(types.STRING_TYPE, "a")},
+		[]*common.Location{location}, NewBlock([]Statement{NewBreakStatement(location)}))
+	statement.SetDefaultBlock(NewBlock([]Statement{
+		NewExpressionStatement(NewAssignExpression(NewIntegerExpression(1), x)),
+	}), location)
+
+	target := strings.Join([]string{
+		"0000 STEP",
+		"0001 GET_GLOBAL    x",
+		"0002 MATCH         11 1",
+		"0003 MATCH         11 2",
+		"0004 MATCH         16 a",
+		"0005 POP",
+		"0006 STEP",
+		"0007 CONSTANT      1",
+		"0008 SET_GLOBAL    x",
+		"0009 POP",
+		"0010 JUMP          18",
+		"0011 STEP",
+		"0012 CONSTANT      0",
+		"0013 SET_GLOBAL    x",
+		"0014 POP",
+		"0015 JUMP          18",
+		"0016 STEP",
+		"0017 JUMP          18",
+		"0018 CONSTANT      null",
+		"0019 RETURN",
+	}, "\n")
+	if chunk := CompileStatement(statement).String(); chunk != target {
+		t.Fatalf("Wrong chunk: Wanted\n%s\ngot\n%s", target, chunk)
+	}
+
+	t.Log("Passed")
+}
@@ -0,0 +1,245 @@
+package ast
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mlmhl/compiler/common"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// AST dump: the tree of nodes as indented text or JSON, for debugging
+// the parser.
+//
+
+// DumpNode is a node dumped, named by its type, like IfStatement.
+type DumpNode struct {
+	Kind       string            `json:"kind"`
+	Location   *DumpLocation     `json:"location,omitempty"` // nil for a literal or block
+	Attributes map[string]string `json:"attributes,omitempty"`
+	Children   []*DumpNode       `json:"children,omitempty"`
+}
+
+type DumpLocation struct {
+	Line     int `json:"line"`
+	Position int `json:"position"`
+}
+
+// dumpVisitor builds the children of parent.
+type dumpVisitor struct {
+	parent *DumpNode
+}
+
+func (visitor *dumpVisitor) Visit(node Node) Visitor {
+	if node == nil {
+		return nil
+	}
+	child := newDumpNode(node)
+	visitor.parent.Children = append(visitor.parent.Children, child)
+	return &dumpVisitor{parent: child}
+}
+
+// Dump returns the trees of nodes.
+func Dump(nodes ...Node) []*DumpNode {
+	root := &DumpNode{}
+	for _, node := range nodes {
+		Walk(&dumpVisitor{parent: root}, node)
+	}
+	return root.Children
+}
+
+func newDumpNode(node Node) *DumpNode {
+	dump := &DumpNode{
+		Kind:       reflect.TypeOf(node).Elem().Name(),
+		Attributes: attributes(node),
+	}
+	if location := nodeLocation(node); location != nil {
+		dump.Location = &DumpLocation{Line: location.GetLine(), Position: location.GetPosition()}
+	}
+	return dump
+}
+
+// Attributes of a node other than its children, which tell the missing
+// children of statements too.
+func attributes(node Node) map[string]string {
+	attributes := map[string]string{}
+	switch node := node.(type) {
+	case *CustomFunction:
+		attributes["name"] = node.GetName()
+		names := []string{}
+		for _, parameter := range node.parameters {
+			names = append(names, parameter.identifier.GetName())
+		}
+		if len(names) > 0 {
+			attributes["parameters"] = strings.Join(names, ", ")
+		}
+	case *StructDefinition:
+		attributes["name"] = node.GetName()
+		attributes["fields"] = strings.Join(node.typ.GetFields(), ", ")
+
+	case *ImportStatement:
+		attributes["path"] = strconv.Quote(node.path)
+		attributes["namespace"] = node.namespace
+	case *GlobalStatement:
+		names := []string{}
+		for _, identifier := range node.identifiers {
+			names = append(names, identifier.GetName())
+		}
+		attributes["names"] = strings.Join(names, ", ")
+	case *IfStatement:
+		if len(node.elifBlocks) > 0 {
+			attributes["elif"] = strconv.Itoa(len(node.elifBlocks))
+		}
+		if node.elseBlock != nil && node.elseBlock.block != nil {
+			attributes["else"] = "true"
+		}
+	case *ForeachStatement:
+		attributes["variable"] = node.identifier.GetName()
+	case *SwitchStatement:
+		cases := []string{}
+		for _, c := range node.cases {
+			values := []string{}
+			for _, value := range c.values {
+				values = append(values, dumpValue(value))
+			}
+			cases = append(cases, "["+strings.Join(values, ", ")+"]")
+		}
+		if len(cases) > 0 {
+			attributes["cases"] = strings.Join(cases, " ")
+		}
+		if node.defaultBlock != nil {
+			attributes["default"] = "true"
+		}
+	case *TryStatement:
+		if node.catchBlock != nil {
+			attributes["catch"] = node.identifier.GetName()
+		}
+		if node.finallyBlock != nil {
+			attributes["finally"] = "true"
+		}
+
+	case *StringExpression:
+		attributes["value"] = dumpValue(node.value)
+	case *IntegerExpression:
+		attributes["value"] = dumpValue(node.value)
+	case *FloatExpression:
+		attributes["value"] = dumpValue(node.value)
+	case *BoolExpression:
+		attributes["value"] = dumpValue(node.value)
+	case *IdentifierExpression:
+		attributes["name"] = node.identifier.GetName()
+	case *AssignExpression:
+		attributes["name"] = node.identifier.GetName()
+	case *IncrementExpression:
+		attributes["name"] = node.identifier.GetName()
+		attributes["op"] = node.op
+		attributes["prefix"] = strconv.FormatBool(node.prefix)
+	case *FieldExpression:
+		attributes["field"] = node.field.GetName()
+	case *FieldAssignExpression:
+		attributes["field"] = node.field.GetName()
+	case *FieldIncrementExpression:
+		attributes["field"] = node.field.GetName()
+		attributes["op"] = node.op
+		attributes["prefix"] = strconv.FormatBool(node.prefix)
+	case *FunctionCallExpression:
+		attributes["name"] = node.identifier.GetName()
+	}
+	if len(attributes) == 0 {
+		return nil
+	}
+	return attributes
+}
+
+// Location of a node, nil for a literal or block.
+func nodeLocation(node Node) *common.Location {
+	switch node := node.(type) {
+	case Statement:
+		return node.GetLocation()
+	case *CustomFunction:
+		return node.GetLocation()
+	case *StructDefinition:
+		return node.GetLocation()
+	case *IdentifierExpression:
+		return node.identifier.GetLocation()
+	case *AssignExpression:
+		return node.identifier.GetLocation()
+	case *IncrementExpression:
+		return node.location
+	case *ConditionalExpression:
+		return node.location
+	case *FieldExpression:
+		return node.field.GetLocation()
+	case *FieldAssignExpression:
+		return node.field.GetLocation()
+	case *FieldIncrementExpression:
+		return node.location
+	case *FunctionCallExpression:
+		return node.location
+	case *SpawnExpression:
+		return node.location
+	case binaryOperation:
+		return node.getBinary().location
+	case unaryOperation:
+		return node.getUnary().location
+	}
+	return nil
+}
+
+func dumpValue(value types.Value) string {
+	if value.GetType() == types.STRING_TYPE {
+		return strconv.Quote(value.GetValue().(string))
+	}
+	return value.String()
+}
+
+// WriteText writes a node per line, indented by its depth, like:
+//
+//	IfStatement 3:0 else=true
+//	  LTExpression 3:6
+func WriteText(writer io.Writer, nodes []*DumpNode) error {
+	for _, node := range nodes {
+		if err := writeText(writer, node, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeText(writer io.Writer, node *DumpNode, depth int) error {
+	line := strings.Repeat("  ", depth) + node.Kind
+	if node.Location != nil {
+		line += fmt.Sprintf(" %d:%d", node.Location.Line, node.Location.Position)
+	}
+	names := []string{}
+	for name := range node.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		line += fmt.Sprintf(" %s=%s", name, node.Attributes[name])
+	}
+	if _, err := io.WriteString(writer, line+"\n"); err != nil {
+		return err
+	}
+
+	for _, child := range node.Children {
+		if err := writeText(writer, child, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the nodes as an indented JSON array.
+func WriteJSON(writer io.Writer, nodes []*DumpNode) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(nodes)
+}
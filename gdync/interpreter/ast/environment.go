@@ -1,6 +1,10 @@
 package ast
 
 import (
+	"sync"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
 	"github.com/mlmhl/compiler/gdync/interpreter/types"
 )
 
@@ -8,21 +12,36 @@ type FunctionSet map[string]Function
 type VariableSet map[string]*types.Variable
 
 // Context for each process point.
-// Global variables in global statement will be put into localVariables.
+// Local variables are kept in slots bound by the resolver, global variables
+// in global statement share the variable with global scope.
 type Environment struct {
-	localVariables  VariableSet
+	localVariables  []*types.Variable
 	globalVariables VariableSet
 
 	functions FunctionSet
+
+	// guards global variables and functions, which are shared by tasks
+	lock *sync.RWMutex
+
+	limiter   *Limiter   // shared by the scopes of an execution, nil if no limit
+	observer  Observer   // shared like limiter, nil if nothing observes
+	tracer    Tracer     // observer if it's a tracer too
+	scheduler *Scheduler // shared like limiter, runs tasks spawned
+
+	// the call entering the local scope, unset in global scope
+	depth        int // number of nested function calls
+	caller       *Environment
+	function     string
+	callLocation *common.Location
 }
 
 func NewEnvironment(globals VariableSet,
 	functions FunctionSet, isGlobal bool) *Environment {
-	var localVariables VariableSet
+	var localVariables []*types.Variable
 	if isGlobal {
 		localVariables = nil
 	} else {
-		localVariables = VariableSet{}
+		localVariables = []*types.Variable{}
 	}
 
 	return &Environment{
@@ -30,6 +49,140 @@ func NewEnvironment(globals VariableSet,
 		globalVariables: globals,
 
 		functions: functions,
+
+		lock: &sync.RWMutex{},
+	}
+}
+
+// A local scope sharing the global variables and functions of env.
+func (env *Environment) localScope() *Environment {
+	localEnv := NewEnvironment(env.globalVariables, env.functions, false)
+	localEnv.lock = env.lock
+	return localEnv
+}
+
+// Local scope of function name called in env at location, which shares
+// the limiter and observer of env.
+func (env *Environment) EnterFunction(name string,
+	location *common.Location) (*Environment, gerror.Error) {
+	localEnv := env.localScope()
+	localEnv.limiter = env.limiter
+	localEnv.observer = env.observer
+	localEnv.tracer = env.tracer
+	localEnv.scheduler = env.scheduler
+	localEnv.depth = env.depth + 1
+	localEnv.caller = env
+	localEnv.function = name
+	localEnv.callLocation = location
+	if err := env.limiter.checkCallDepth(localEnv.depth); err != nil {
+		return nil, err
+	}
+	return localEnv, nil
+}
+
+// Share the execution state of env, whose call enters this scope too.
+func (env *Environment) inherit(other *Environment) {
+	env.limiter = other.limiter
+	env.observer = other.observer
+	env.tracer = other.tracer
+	env.scheduler = other.scheduler
+	env.depth = other.depth
+	env.caller = other.caller
+	env.function = other.function
+	env.callLocation = other.callLocation
+}
+
+// Returns the scope calling the function of env, nil in global scope
+// or the outermost call of a host.
+func (env *Environment) GetCaller() *Environment {
+	return env.caller
+}
+
+// Number of nested function calls entering env, 0 in global scope.
+func (env *Environment) GetDepth() int {
+	return env.depth
+}
+
+// Name of the function whose local scope is env, as it's called.
+func (env *Environment) GetFunctionName() string {
+	return env.function
+}
+
+func (env *Environment) GetCallLocation() *common.Location {
+	return env.callLocation
+}
+
+// SetObserver let observer watch the execution in env and the scopes
+// entered from it.
+func (env *Environment) SetObserver(observer Observer) {
+	env.observer = observer
+	env.tracer, _ = observer.(Tracer)
+}
+
+func (env *Environment) GetObserver() Observer {
+	return env.observer
+}
+
+// SetLimiter limit the execution in env and the scopes entered from it.
+func (env *Environment) SetLimiter(limiter *Limiter) {
+	env.limiter = limiter
+}
+
+// Returns nil if env is nil, like in constant folding.
+func (env *Environment) GetLimiter() *Limiter {
+	if env == nil {
+		return nil
+	}
+	return env.limiter
+}
+
+// SetScheduler let scheduler run the tasks spawned in env and the scopes
+// entered from it.
+func (env *Environment) SetScheduler(scheduler *Scheduler) {
+	env.scheduler = scheduler
+}
+
+func (env *Environment) GetScheduler() *Scheduler {
+	return env.scheduler
+}
+
+// Step counts a statement executed at location against the limits,
+// then notifies the observer.
+func (env *Environment) Step(location *common.Location) gerror.Error {
+	if err := env.limiter.Step(location); err != nil {
+		return err
+	}
+	if env.observer != nil {
+		env.scheduler.lockObserver()
+		defer env.scheduler.unlockObserver()
+		return env.observer.OnStep(location, env)
+	}
+	return nil
+}
+
+// The local scope env is entered with arguments.
+func (env *Environment) traceCall(arguments []types.Value) {
+	if env.tracer != nil {
+		env.scheduler.lockObserver()
+		defer env.scheduler.unlockObserver()
+		env.tracer.OnCall(arguments, env)
+	}
+}
+
+// The local scope env returns value.
+func (env *Environment) traceReturn(value types.Value) {
+	if env.tracer != nil {
+		env.scheduler.lockObserver()
+		defer env.scheduler.unlockObserver()
+		env.tracer.OnReturn(value, env)
+	}
+}
+
+func (env *Environment) traceAssign(identifier *types.Identifier, value types.Value) {
+	if env.tracer != nil {
+		env.scheduler.lockObserver()
+		defer env.scheduler.unlockObserver()
+		env.tracer.OnAssign(identifier, value, env)
 	}
 }
 
@@ -37,40 +190,94 @@ func (env *Environment) IsGlobal() bool {
 	return env.localVariables == nil
 }
 
+// Returns a copy of the global variables.
 func (env *Environment) GetGlobalVariables() VariableSet {
-	return env.globalVariables
+	env.lock.RLock()
+	defer env.lock.RUnlock()
+	variables := VariableSet{}
+	for name, variable := range env.globalVariables {
+		variables[name] = variable
+	}
+	return variables
 }
 
+// Returns a copy of the functions.
 func (env *Environment) GetFunctions() FunctionSet {
-	return env.functions
+	env.lock.RLock()
+	defer env.lock.RUnlock()
+	functions := FunctionSet{}
+	for name, function := range env.functions {
+		functions[name] = function
+	}
+	return functions
 }
 
 func (env *Environment) GetGlobalVariable(id *types.Identifier) *types.Variable {
+	env.lock.RLock()
+	defer env.lock.RUnlock()
 	return getVariable(env.globalVariables, id)
 }
 
-func (env *Environment) GetLocalVariable(id *types.Identifier) *types.Variable {
+// Allocate size empty slots for local variables.
+func (env *Environment) ReserveLocalVariables(size int) {
+	if env.IsGlobal() {
+		panic("Can't reserve local variables in global scope!")
+	}
+	env.localVariables = make([]*types.Variable, size)
+}
+
+// Variables of assigned slots, nil in global scope.
+func (env *Environment) GetLocalVariables() []*types.Variable {
+	if env.IsGlobal() {
+		return nil
+	}
+	variables := []*types.Variable{}
+	for _, variable := range env.localVariables {
+		if variable != nil {
+			variables = append(variables, variable)
+		}
+	}
+	return variables
+}
+
+// Returns nil if the slot isn't assigned yet.
+func (env *Environment) GetLocalVariable(slot int) *types.Variable {
 	if env.IsGlobal() {
 		panic("Can't get local variable in global scope!")
 	}
-	return getVariable(env.localVariables, id)
+	return env.localVariables[slot]
 }
 
 func (env *Environment) AddGlobalVariable(variable *types.Variable) {
 	if !env.IsGlobal() {
 		panic("Can't add global variable in local scope!")
 	}
+	env.lock.Lock()
+	defer env.lock.Unlock()
 	env.globalVariables[variable.GetName()] = variable
 }
 
-func (env *Environment) AddLocalVariable(variable *types.Variable) {
+// Bind variable to name in global scope, the name may differ from the
+// variable's own, like a variable exposed by an imported module.
+func (env *Environment) SetGlobalVariable(name string, variable *types.Variable) {
+	if !env.IsGlobal() {
+		panic("Can't set global variable in local scope!")
+	}
+	env.lock.Lock()
+	defer env.lock.Unlock()
+	env.globalVariables[name] = variable
+}
+
+func (env *Environment) SetLocalVariable(slot int, variable *types.Variable) {
 	if env.IsGlobal() {
-		panic("Can't add local variable in global scope!")
+		panic("Can't set local variable in global scope!")
 	}
-	env.localVariables[variable.GetName()] = variable
+	env.localVariables[slot] = variable
 }
 
 func (env *Environment) GetFunction(id *types.Identifier) Function {
+	env.lock.RLock()
+	defer env.lock.RUnlock()
 	if env.functions == nil {
 		// No function exist
 		return nil
@@ -87,6 +294,8 @@ func (env *Environment) AddFunction(function Function) {
 	if !env.IsGlobal() {
 		panic("Can't add function in local scope!")
 	}
+	env.lock.Lock()
+	defer env.lock.Unlock()
 
 	if env.functions == nil {
 		env.functions = map[string]Function{}
@@ -95,6 +304,22 @@ func (env *Environment) AddFunction(function Function) {
 	env.functions[function.GetName()] = function
 }
 
+// Bind function to name, the name may differ from the function's own,
+// like a struct exposed by an imported module.
+func (env *Environment) SetFunction(name string, function Function) {
+	if !env.IsGlobal() {
+		panic("Can't set function in local scope!")
+	}
+	env.lock.Lock()
+	defer env.lock.Unlock()
+
+	if env.functions == nil {
+		env.functions = map[string]Function{}
+	}
+
+	env.functions[name] = function
+}
+
 func getVariable(variables map[string]*types.Variable, id *types.Identifier) *types.Variable {
 	if v, ok := variables[id.GetName()]; ok {
 		return v
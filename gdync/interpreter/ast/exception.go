@@ -0,0 +1,51 @@
+package ast
+
+import (
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Exceptions: a thrown value is propagated as THROW_STATEMENT_RESULT inside
+// a function body, and as an ExceptionError across function calls. Runtime
+// errors except internal and limit ones can be caught too, as values of
+// Error type.
+//
+
+// NewException wrap a thrown value as error, an Error value keeps the
+// message and location of the runtime error it's created from.
+func NewException(value types.Value, location *common.Location) *gerror.ExceptionError {
+	if value.GetType() == types.ERROR_TYPE {
+		err := value.GetValue().(*types.RuntimeError)
+		if err.GetLocation() != nil {
+			location = err.GetLocation()
+		}
+		return gerror.NewExceptionError(value, err.GetMessage(), location)
+	}
+	return gerror.NewExceptionError(value, "Uncaught exception: "+value.String(), location)
+}
+
+// Returns the value bound by catch, ok is false if err can't be caught.
+func exceptionValue(err gerror.Error) (types.Value, bool) {
+	switch err := err.(type) {
+	case *gerror.InternalError, *gerror.LimitExceededError, *gerror.DeadlockError:
+		return nil, false
+	case *gerror.ExceptionError:
+		return err.GetValue().(types.Value), true
+	}
+	return types.NewValue(types.ERROR_TYPE,
+		types.NewRuntimeError(err.GetMessage(), err.GetLocation())), true
+}
+
+// Returns the value thrown by a block, ok is false if nothing is thrown
+// or err can't be caught.
+func thrownValue(result *StatementResult, err gerror.Error) (types.Value, bool) {
+	if err != nil {
+		return exceptionValue(err)
+	}
+	if result.GetType() == THROW_STATEMENT_RESULT {
+		return result.GetValue(), true
+	}
+	return nil, false
+}
@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"math/big"
 	"strings"
 
 	"github.com/mlmhl/compiler/common"
@@ -57,6 +58,11 @@ func NewIntegerExpression(value int64) *IntegerExpression {
 	return &IntegerExpression{types.NewValue(types.INTEGER_TYPE, value)}
 }
 
+// Literal too large for int64.
+func NewBigIntegerExpression(value *big.Int) *IntegerExpression {
+	return &IntegerExpression{types.NewBigInteger(value)}
+}
+
 func (expression *IntegerExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
 	return expression.value, nil
 }
@@ -100,20 +106,24 @@ func (expression *NullExpression) Evaluate(env *Environment) (types.Value, gerro
 type IdentifierExpression struct {
 	identifier *types.Identifier
 
+	// slot of local variable, bound by the resolver
+	slot int
+
 	// Using identifier's location as IdentifierExpression's location.
 }
 
 func NewIdentifierExpression(identifier *types.Identifier) *IdentifierExpression {
-	return &IdentifierExpression{identifier}
+	return &IdentifierExpression{identifier: identifier, slot: -1}
 }
 
 func (expression *IdentifierExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
 	var variable *types.Variable
 
-	if env.IsGlobal() {
+	// a member of imported module has no slot
+	if env.IsGlobal() || expression.slot < 0 {
 		variable = env.GetGlobalVariable(expression.identifier)
 	} else {
-		variable = env.GetLocalVariable(expression.identifier)
+		variable = env.GetLocalVariable(expression.slot)
 	}
 
 	if variable == nil {
@@ -126,43 +136,161 @@ func (expression *IdentifierExpression) Evaluate(env *Environment) (types.Value,
 type AssignExpression struct {
 	operand    Expression
 	identifier *types.Identifier
+
+	// slot of local variable, bound by the resolver
+	slot int
 }
 
 func NewAssignExpression(operand Expression, identifier *types.Identifier) *AssignExpression {
 	return &AssignExpression{
 		operand:    operand,
 		identifier: identifier,
+		slot:       -1,
 	}
 }
 
 func (expression *AssignExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
-	var err gerror.Error
-	var right types.Value
-	var left *types.Variable
-
-	if right, err = expression.operand.Evaluate(env); err != nil {
+	right, err := expression.operand.Evaluate(env)
+	if err != nil {
 		return nil, err
 	}
 
-	if env.IsGlobal() {
-		left = env.GetGlobalVariable(expression.identifier)
+	assign(env, expression.identifier, expression.slot, right)
+	return right, nil
+}
+
+// Set the variable identifier, slot is used in local scope unless it's
+// negative, the variable is created if it doesn't exist.
+func assign(env *Environment, identifier *types.Identifier, slot int, value types.Value) {
+	var left *types.Variable
+
+	if env.IsGlobal() || slot < 0 {
+		left = env.GetGlobalVariable(identifier)
 		if left == nil {
 			// Create a new global variable.
-			env.AddGlobalVariable(types.NewVariable(expression.identifier, right))
+			env.AddGlobalVariable(types.NewVariable(identifier, value))
 		} else {
-			left.SetValue(right)
+			left.SetValue(value)
 		}
 	} else {
-		left = env.GetLocalVariable(expression.identifier)
+		left = env.GetLocalVariable(slot)
 		if left == nil {
 			// Create a new local variable.
-			env.AddLocalVariable(types.NewVariable(expression.identifier, right))
+			env.SetLocalVariable(slot, types.NewVariable(identifier, value))
 		} else {
-			left.SetValue(right)
+			left.SetValue(value)
 		}
 	}
+	env.traceAssign(identifier, value)
+}
 
-	return right, nil
+// IncrementExpression add 1 to or subtract 1 from a numeric variable,
+// the value is the new one for prefix form, the old one for postfix form.
+type IncrementExpression struct {
+	identifier *types.Identifier
+	op         string // types.INCREMENT or types.DECREMENT
+	prefix     bool
+
+	// slot of local variable, bound by the resolver
+	slot int
+
+	location *common.Location // operation signal's location
+}
+
+func NewIncrementExpression(identifier *types.Identifier, op string, prefix bool,
+	location *common.Location) *IncrementExpression {
+	return &IncrementExpression{
+		identifier: identifier,
+		op:         op,
+		prefix:     prefix,
+		slot:       -1,
+		location:   location,
+	}
+}
+
+func (expression *IncrementExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
+	var variable *types.Variable
+
+	// a member of imported module has no slot
+	if env.IsGlobal() || expression.slot < 0 {
+		variable = env.GetGlobalVariable(expression.identifier)
+	} else {
+		variable = env.GetLocalVariable(expression.slot)
+	}
+
+	if variable == nil {
+		return nil, gerror.NewVariableNotFoundError(
+			expression.identifier.GetName(), expression.identifier.GetLocation())
+	}
+
+	old := variable.GetValue()
+	value, err := incrementOperation(expression.op, old, expression.location)
+	if err != nil {
+		return nil, err
+	}
+	variable.SetValue(value)
+	env.traceAssign(expression.identifier, value)
+
+	if expression.prefix {
+		return value, nil
+	}
+	return old, nil
+}
+
+var incrementOperators map[string]string = map[string]string{
+	types.INCREMENT: types.ADD,
+	types.DECREMENT: types.SUBTRACT,
+}
+
+func incrementOperation(op string, value types.Value,
+	location *common.Location) (types.Value, gerror.Error) {
+	if value.GetType() != types.INTEGER_TYPE && value.GetType() != types.BIG_INTEGER_TYPE &&
+		value.GetType() != types.FLOAT_TYPE {
+		return nil, gerror.NewInvalidOperationError(location, op, value.GetType().String())
+	}
+
+	result, err := types.ArithmeticOperation(incrementOperators[op], value,
+		types.NewValue(types.INTEGER_TYPE, int64(1)))
+	if err != nil {
+		err.SetLocation(location)
+		return nil, err
+	}
+	return result, nil
+}
+
+// ConditionalExpression evaluate only one of its branches.
+type ConditionalExpression struct {
+	condition   Expression
+	trueBranch  Expression
+	falseBranch Expression
+
+	location *common.Location // location of '?'
+}
+
+func NewConditionalExpression(condition, trueBranch, falseBranch Expression,
+	location *common.Location) *ConditionalExpression {
+	return &ConditionalExpression{
+		condition:   condition,
+		trueBranch:  trueBranch,
+		falseBranch: falseBranch,
+
+		location: location,
+	}
+}
+
+func (expression *ConditionalExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
+	condition, err := expression.condition.Evaluate(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkBool("?:", condition, expression.location); err != nil {
+		return nil, err
+	}
+	if condition.GetValue().(bool) {
+		return expression.trueBranch.Evaluate(env)
+	}
+	return expression.falseBranch.Evaluate(env)
 }
 
 type unaryExpression struct {
@@ -180,6 +308,23 @@ type binaryExpression struct {
 	location *common.Location
 }
 
+// Implemented by all unary and binary operators, used by passes over the AST.
+type unaryOperation interface {
+	getUnary() *unaryExpression
+}
+
+func (expression *unaryExpression) getUnary() *unaryExpression {
+	return expression
+}
+
+type binaryOperation interface {
+	getBinary() *binaryExpression
+}
+
+func (expression *binaryExpression) getBinary() *binaryExpression {
+	return expression
+}
+
 type AddExpression struct {
 	binaryExpression
 }
@@ -293,15 +438,18 @@ func (expression *MinusExpression) Evaluate(env *Environment) (types.Value, gerr
 	if err != nil {
 		return nil, err
 	}
+	return minusOperation(value, expression.location)
+}
 
-	if value.GetType() == types.INTEGER_TYPE {
-		return types.NewValue(types.INTEGER_TYPE, -value.GetValue().(int64)), nil
+func minusOperation(value types.Value, location *common.Location) (types.Value, gerror.Error) {
+	if value.GetType() == types.INTEGER_TYPE || value.GetType() == types.BIG_INTEGER_TYPE {
+		return types.NegateInteger(value), nil
 	}
 	if value.GetType() == types.FLOAT_TYPE {
-		return types.NewValue(types.FLOAT_TYPE, -value.GetValue().(int64)), nil
+		return types.NewValue(types.FLOAT_TYPE, -value.GetValue().(float64)), nil
 	}
 
-	return nil, gerror.NewInvalidOperationError(expression.location, types.MINUS,
+	return nil, gerror.NewInvalidOperationError(location, types.MINUS,
 		value.GetType().String())
 }
 
@@ -434,7 +582,7 @@ func NewAndExpression(left, right Expression, location *common.Location) *AndExp
 }
 
 func (expression *AndExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
-	return logicalOperation(types.AND, expression.left, expression.right, expression.location, env)
+	return logicalOperation("&&", false, &expression.binaryExpression, env)
 }
 
 type OrExpression struct {
@@ -452,7 +600,7 @@ func NewOrExpression(left, right Expression, location *common.Location) *OrExpre
 }
 
 func (expression *OrExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
-	return logicalOperation(types.Or, expression.left, expression.right, expression.location, env)
+	return logicalOperation("||", true, &expression.binaryExpression, env)
 }
 
 type NotExpression struct {
@@ -473,10 +621,12 @@ func (expression *NotExpression) Evaluate(env *Environment) (types.Value, gerror
 	if err != nil {
 		return nil, err
 	}
+	return notOperation(value, expression.location)
+}
 
-	if value.GetType() != types.BOOL_TYPE {
-		return nil, gerror.NewInvalidOperationError(expression.location, types.NOT,
-			value.GetType().String())
+func notOperation(value types.Value, location *common.Location) (types.Value, gerror.Error) {
+	if err := checkBool("!", value, location); err != nil {
+		return nil, err
 	}
 
 	return types.NewValue(types.BOOL_TYPE, !value.GetValue().(bool)), nil
@@ -500,28 +650,64 @@ func NewFunctionCallExpression(arguments []*Argument, identifier *types.Identifi
 }
 
 func (expression *FunctionCallExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
+	function, values, err := expression.prepare(env)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := expression.call(function, values, env)
+	if err != nil && err.GetLocation() == nil {
+		err.SetLocation(expression.location)
+	}
+	return value, err
+}
+
+// Returns the function called and the values of arguments.
+func (expression *FunctionCallExpression) prepare(env *Environment) (Function,
+	[]types.Value, gerror.Error) {
 	function := env.GetFunction(expression.identifier)
 	if function == nil {
-		return nil, gerror.NewFunctionNotFoundError(
+		return nil, nil, gerror.NewFunctionNotFoundError(
 			expression.identifier.GetName(), expression.location)
 	}
 
-	localEnv := NewEnvironment(env.GetGlobalVariables(), env.GetFunctions(), false)
-
 	values := []types.Value{}
 	for _, argument := range expression.arguments {
 		value, err := argument.expression.Evaluate(env)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		values = append(values, value)
 	}
+	return function, values, nil
+}
+
+func (expression *FunctionCallExpression) call(function Function,
+	values []types.Value, env *Environment) (types.Value, gerror.Error) {
+	localEnv, err := env.EnterFunction(expression.identifier.GetName(), expression.location)
+	if err != nil {
+		return nil, err
+	}
+	return expression.invoke(function, values, env, localEnv)
+}
 
+// Evaluate function in the scope entered from env already.
+func (expression *FunctionCallExpression) invoke(function Function,
+	values []types.Value, env, localEnv *Environment) (types.Value, gerror.Error) {
+	localEnv.traceCall(values)
 	value, err := function.Evaluate(values, localEnv)
-	if err != nil && err.GetLocation() == nil {
-		err.SetLocation(expression.location)
+	if err != nil {
+		return nil, err
 	}
-	return value, err
+	localEnv.traceReturn(value)
+	if module, ok := function.(*ModuleFunction); ok {
+		function = module.function
+	}
+	if _, ok := function.(*CustomFunction); ok {
+		// strings created by scripts are checked by operators
+		return value, nil
+	}
+	return value, env.limiter.checkValue(value, nil)
 }
 
 //
@@ -535,11 +721,14 @@ func arithmeticOperation(op string, leftExpression, rightExpression Expression,
 		return nil, err
 	}
 
+	if err := env.GetLimiter().checkOperation(op, left, right, location); err != nil {
+		return nil, err
+	}
 	if value, err := types.ArithmeticOperation(op, left, right); err != nil {
 		err.SetLocation(location)
 		return nil, err
 	} else {
-		return value, nil
+		return value, env.GetLimiter().checkValue(value, location)
 	}
 }
 
@@ -567,20 +756,39 @@ func relationalOperation(op string, leftExpression, rightExpression Expression,
 // logic operations
 //
 
-func logicalOperation(op string, leftExpression, rightExpression Expression,
-	location *common.Location, env *Environment) (types.Value, gerror.Error) {
-	left, right, err := evaluateOperand(leftExpression, rightExpression, env)
+// Operands must be bool values, the right operand is evaluated only if
+// the left one doesn't decide the result: false for &&, true for ||.
+func logicalOperation(keyword string, decisive bool, expression *binaryExpression,
+	env *Environment) (types.Value, gerror.Error) {
+	left, err := boolOperand(keyword, expression.left, expression.location, env)
 	if err != nil {
 		return nil, err
 	}
+	if left.GetValue().(bool) == decisive {
+		return left, nil
+	}
+	return boolOperand(keyword, expression.right, expression.location, env)
+}
 
-	value, err := types.LogicalOperation(op, left, right)
+func boolOperand(keyword string, operand Expression, location *common.Location,
+	env *Environment) (types.Value, gerror.Error) {
+	value, err := operand.Evaluate(env)
 	if err != nil {
-		err.SetLocation(location)
 		return nil, err
-	} else {
-		return value, nil
 	}
+	if err = checkBool(keyword, value, location); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// There is no implicit truthiness, conditions of statements and
+// operands of logical operators must be bool values.
+func checkBool(keyword string, value types.Value, location *common.Location) gerror.Error {
+	if value.GetType() != types.BOOL_TYPE {
+		return gerror.NewNotBoolExpressionError(keyword, location)
+	}
+	return nil
 }
 
 func evaluateOperand(leftExpression, rightExpression Expression,
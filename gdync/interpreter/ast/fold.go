@@ -0,0 +1,322 @@
+package ast
+
+import (
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Constant folding: operations over literals are evaluated before execution
+// with the same semantics as runtime, branches whose conditions are literals
+// are pruned, errors found by evaluation are reported as compile errors.
+// Pruned branches are never folded, they can't raise errors.
+//
+
+type Folder struct {
+	errors []gerror.Error
+
+	// depth of try blocks with catch, errors inside are left to runtime
+	guarded int
+
+	env *Environment // carries the limiter of the execution, nil if no limit
+}
+
+func NewFolder() *Folder {
+	return &Folder{
+		errors: []gerror.Error{},
+	}
+}
+
+// SetLimiter check strings created by folding against the limits, so a
+// huge constant string isn't created before execution.
+func (folder *Folder) SetLimiter(limiter *Limiter) {
+	folder.env = &Environment{limiter: limiter}
+}
+
+func (folder *Folder) GetErrors() []gerror.Error {
+	return folder.errors
+}
+
+func (folder *Folder) report(err gerror.Error) {
+	if folder.guarded == 0 {
+		folder.errors = append(folder.errors, err)
+	}
+}
+
+// FoldStatements fold each statement, statements never executed are removed.
+func (folder *Folder) FoldStatements(statements []Statement) []Statement {
+	result := []Statement{}
+	for _, statement := range statements {
+		if statement = folder.foldStatement(statement); statement != nil {
+			result = append(result, statement)
+		}
+	}
+	return result
+}
+
+func (folder *Folder) FoldFunction(function *CustomFunction) {
+	function.block = folder.foldBlock(function.block)
+}
+
+func (folder *Folder) foldBlock(block *Block) *Block {
+	block.statements = folder.FoldStatements(block.statements)
+	return block
+}
+
+// Returns the statement to execute instead, nil if it's never executed.
+func (folder *Folder) foldStatement(statement Statement) Statement {
+	switch statement := statement.(type) {
+	case *Block:
+		return folder.foldBlock(statement)
+	case *ExpressionStatement:
+		statement.expression = folder.foldExpression(statement.expression)
+	case *IfStatement:
+		return folder.foldIf(statement)
+	case *WhileStatement:
+		statement.condition = folder.foldExpression(statement.condition)
+		if value, ok := folder.literalCondition("while",
+			statement.condition, statement.location); ok && !value {
+			return nil
+		}
+		statement.block = folder.foldBlock(statement.block)
+	case *ForStatement:
+		statement.init = folder.foldExpression(statement.init)
+		statement.condition = folder.foldExpression(statement.condition)
+		if value, ok := folder.literalCondition("for",
+			statement.condition, statement.location); ok && !value {
+			// only the init expression is executed
+			if statement.init == nil {
+				return nil
+			}
+			init := NewExpressionStatement(statement.init)
+			init.SetLocation(statement.location)
+			return init
+		}
+		statement.post = folder.foldExpression(statement.post)
+		statement.block = folder.foldBlock(statement.block)
+	case *ForeachStatement:
+		statement.collection = folder.foldExpression(statement.collection)
+		statement.block = folder.foldBlock(statement.block)
+	case *SwitchStatement:
+		statement.value = folder.foldExpression(statement.value)
+		for _, c := range statement.cases {
+			c.block = folder.foldBlock(c.block)
+		}
+		if statement.defaultBlock != nil {
+			statement.defaultBlock = folder.foldBlock(statement.defaultBlock)
+		}
+	case *ReturnStatement:
+		statement.returnValue = folder.foldExpression(statement.returnValue)
+	case *ThrowStatement:
+		statement.value = folder.foldExpression(statement.value)
+	case *TryStatement:
+		folder.foldTry(statement)
+	}
+	return statement
+}
+
+func (folder *Folder) foldTry(statement *TryStatement) {
+	if statement.catchBlock != nil {
+		folder.guarded++
+	}
+	statement.tryBlock = folder.foldBlock(statement.tryBlock)
+	if statement.catchBlock != nil {
+		folder.guarded--
+		statement.catchBlock = folder.foldBlock(statement.catchBlock)
+	}
+	if statement.finallyBlock != nil {
+		statement.finallyBlock = folder.foldBlock(statement.finallyBlock)
+	}
+}
+
+func (folder *Folder) foldIf(statement *IfStatement) Statement {
+	// the if branch is treated as the first elif
+	branches := append([]*elifStatement{{
+		block:     statement.ifBlock,
+		condition: statement.condition,
+		location:  statement.location,
+	}}, statement.elifBlocks...)
+
+	kept := []*elifStatement{}
+	elseBlock := statement.elseBlock
+	matched := false
+	for i, branch := range branches {
+		keyword := "elif"
+		if i == 0 {
+			keyword = "if"
+		}
+
+		branch.condition = folder.foldExpression(branch.condition)
+		value, ok := folder.literalCondition(keyword, branch.condition, branch.location)
+		if !ok {
+			branch.block = folder.foldBlock(branch.block)
+			kept = append(kept, branch)
+			continue
+		}
+		if value {
+			// always matched, the following branches are unreachable
+			elseBlock = &elseStatement{block: folder.foldBlock(branch.block), location: branch.location}
+			matched = true
+			break
+		}
+		// never matched, just drop it
+	}
+	if !matched && elseBlock.block != nil {
+		elseBlock.block = folder.foldBlock(elseBlock.block)
+	}
+
+	if len(kept) == 0 {
+		if elseBlock.block == nil {
+			return nil
+		}
+		return elseBlock.block
+	}
+
+	statement.condition = kept[0].condition
+	statement.ifBlock = kept[0].block
+	statement.location = kept[0].location
+	statement.elifBlocks = kept[1:]
+	statement.elseBlock = elseBlock
+	return statement
+}
+
+// Returns the value of a literal condition, ok is false if the condition
+// isn't a bool literal, a literal of other types is reported as error.
+func (folder *Folder) literalCondition(keyword string, condition Expression,
+	location *common.Location) (value bool, ok bool) {
+	if !isLiteral(condition) {
+		return false, false
+	}
+
+	result, _ := condition.Evaluate(nil)
+	if result.GetType() != types.BOOL_TYPE {
+		folder.report(gerror.NewNotBoolExpressionError(keyword, location))
+		return false, false
+	}
+	return result.GetValue().(bool), true
+}
+
+func (folder *Folder) foldExpression(expression Expression) Expression {
+	switch e := expression.(type) {
+	case *AssignExpression:
+		e.operand = folder.foldExpression(e.operand)
+	case *FieldExpression:
+		e.object = folder.foldExpression(e.object)
+	case *FieldAssignExpression:
+		e.object = folder.foldExpression(e.object)
+		e.operand = folder.foldExpression(e.operand)
+	case *FieldIncrementExpression:
+		e.object = folder.foldExpression(e.object)
+	case *FunctionCallExpression:
+		for _, argument := range e.arguments {
+			argument.expression = folder.foldExpression(argument.expression)
+		}
+	case *SpawnExpression:
+		folder.foldExpression(e.call)
+	case *ConditionalExpression:
+		e.condition = folder.foldExpression(e.condition)
+		if value, ok := folder.literalCondition("?:", e.condition, e.location); ok {
+			if value {
+				return folder.foldExpression(e.trueBranch)
+			}
+			return folder.foldExpression(e.falseBranch)
+		}
+		e.trueBranch = folder.foldExpression(e.trueBranch)
+		e.falseBranch = folder.foldExpression(e.falseBranch)
+	case *AndExpression:
+		return folder.foldLogical("&&", false, &e.binaryExpression, expression)
+	case *OrExpression:
+		return folder.foldLogical("||", true, &e.binaryExpression, expression)
+	case binaryOperation:
+		binary := e.getBinary()
+		binary.left = folder.foldExpression(binary.left)
+		binary.right = folder.foldExpression(binary.right)
+
+		if isDivision(expression) && isZero(binary.right) {
+			folder.report(gerror.NewDivisionByZeroError(binary.location))
+			return expression
+		}
+		if isLiteral(binary.left) && isLiteral(binary.right) {
+			return folder.evaluate(expression)
+		}
+	case unaryOperation:
+		unary := e.getUnary()
+		unary.expression = folder.foldExpression(unary.expression)
+		if isLiteral(unary.expression) {
+			return folder.evaluate(expression)
+		}
+	}
+	return expression
+}
+
+// A logical expression is folded to its left operand if it's a decisive
+// literal, the right operand is never evaluated then.
+func (folder *Folder) foldLogical(keyword string, decisive bool,
+	binary *binaryExpression, expression Expression) Expression {
+	binary.left = folder.foldExpression(binary.left)
+	value, ok := folder.literalCondition(keyword, binary.left, binary.location)
+	if ok && value == decisive {
+		return folder.evaluate(expression)
+	}
+
+	binary.right = folder.foldExpression(binary.right)
+	if ok && isLiteral(binary.right) {
+		return folder.evaluate(expression)
+	}
+	return expression
+}
+
+// Evaluate an expression whose operands are all literals.
+func (folder *Folder) evaluate(expression Expression) Expression {
+	value, err := expression.Evaluate(folder.env)
+	if err != nil {
+		// an exceeded limit is reported by execution
+		if _, ok := err.(*gerror.LimitExceededError); !ok {
+			folder.report(err)
+		}
+		return expression
+	}
+
+	switch value.GetType() {
+	case types.INTEGER_TYPE, types.BIG_INTEGER_TYPE:
+		return &IntegerExpression{value}
+	case types.FLOAT_TYPE:
+		return &FloatExpression{value}
+	case types.STRING_TYPE:
+		return &StringExpression{value}
+	case types.BOOL_TYPE:
+		return &BoolExpression{value}
+	}
+	return expression
+}
+
+func isLiteral(expression Expression) bool {
+	switch expression.(type) {
+	case *IntegerExpression, *FloatExpression, *StringExpression, *BoolExpression:
+		return true
+	default:
+		return false
+	}
+}
+
+func isDivision(expression Expression) bool {
+	switch expression.(type) {
+	case *DivideExpression, *ModExpression:
+		return true
+	default:
+		return false
+	}
+}
+
+func isZero(expression Expression) bool {
+	switch e := expression.(type) {
+	case *IntegerExpression:
+		// big integers are never 0
+		return e.value.GetValue() == int64(0)
+	case *FloatExpression:
+		return e.value.GetValue().(float64) == 0
+	default:
+		return false
+	}
+}
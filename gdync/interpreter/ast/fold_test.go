@@ -0,0 +1,178 @@
+package ast
+
+import (
+	"testing"
+
+	"github.com/mlmhl/compiler/common"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+func foldExpressionTest(expression Expression, target types.Value, t *testing.T) {
+	t.Logf("Test: fold %T ...", expression)
+
+	folder := NewFolder()
+	result := folder.foldExpression(expression)
+	if len(folder.GetErrors()) != 0 {
+		t.Fatalf("Unexpected error: %s", folder.GetErrors()[0].GetMessage())
+	}
+	if !isLiteral(result) {
+		t.Fatalf("Expression isn't folded: %T", result)
+	}
+
+	value, _ := result.Evaluate(nil)
+	if value.GetType() != target.GetType() || value.GetValue() != target.GetValue() {
+		t.Fatalf("Wrong result: Wanted %v, got %v", target, value)
+	}
+
+	t.Log("Passed")
+}
+
+func TestFoldExpression(t *testing.T) {
+	location := common.NewLocation(1, 1, "test")
+
+	// (1 + 2) * 3
+	foldExpressionTest(NewMultiplyExpression(NewAddExpression(NewIntegerExpression(1),
+		NewIntegerExpression(2), location), NewIntegerExpression(3), location),
+		types.NewValue(types.INTEGER_TYPE, int64(9)), t)
+	// -2.5 + 1
+	foldExpressionTest(NewAddExpression(NewMinusExpression(NewFloatExpression(2.5), location),
+		NewIntegerExpression(1), location), types.NewValue(types.FLOAT_TYPE, float64(-1.5)), t)
+	// "a" + 1
+	str, _ := NewStringExpression("\"a\"")
+	foldExpressionTest(NewAddExpression(str, NewIntegerExpression(1), location),
+		types.NewValue(types.STRING_TYPE, "a1"), t)
+	// !(1 >= 2)
+	foldExpressionTest(NewNotExpression(NewGTEExpression(NewIntegerExpression(1),
+		NewIntegerExpression(2), location), location), types.NewValue(types.BOOL_TYPE, true), t)
+
+	t.Log("Test: fold with identifier ...")
+	x := NewIdentifierExpression(types.NewIdentifier("x", location))
+	add := NewAddExpression(x, NewMultiplyExpression(NewIntegerExpression(2),
+		NewIntegerExpression(3), location), location)
+	folder := NewFolder()
+	if folder.foldExpression(add) != add {
+		t.Fatalf("Expression with identifier shouldn't be folded")
+	}
+	if _, ok := add.right.(*IntegerExpression); !ok {
+		t.Fatalf("Literal operands should be folded: %T", add.right)
+	}
+	t.Log("Passed")
+
+	t.Log("Test: fold logical expression ...")
+	if !isLiteral(folder.foldExpression(NewOrExpression(NewBoolExpression(true), x, location))) ||
+		!isLiteral(folder.foldExpression(NewAndExpression(NewBoolExpression(false), x, location))) {
+		t.Fatalf("Logical expression with decisive literal should be folded")
+	}
+	and := NewAndExpression(NewBoolExpression(true), x, location)
+	if folder.foldExpression(and) != and {
+		t.Fatalf("Logical expression with non-decisive literal shouldn't be folded")
+	}
+	t.Log("Passed")
+
+	t.Log("Test: fold conditional expression ...")
+	conditional := NewConditionalExpression(NewLTExpression(NewIntegerExpression(1),
+		NewIntegerExpression(2), location), x, NewIntegerExpression(0), location)
+	if folder.foldExpression(conditional) != x {
+		t.Fatalf("Conditional expression with literal condition should be folded")
+	}
+	t.Log("Passed")
+}
+
+func TestFoldError(t *testing.T) {
+	location := common.NewLocation(3, 5, "test")
+	x := NewIdentifierExpression(types.NewIdentifier("x", location))
+	str, _ := NewStringExpression("\"a\"")
+
+	expressions := []Expression{
+		NewDivideExpression(NewIntegerExpression(1), NewIntegerExpression(0), location),
+		NewModExpression(x, NewIntegerExpression(0), location),
+		NewDivideExpression(x, NewFloatExpression(0), location),
+		NewSubtractExpression(str, NewIntegerExpression(1), location),
+		NewOrExpression(NewIntegerExpression(1), x, location),
+		NewNotExpression(str, location),
+	}
+	targets := []string{
+		"Division by zero",
+		"Division by zero",
+		"Division by zero",
+		"Can't invoke Subtract operation on [String Integer]",
+		"Condition expression for || must be a bool expression",
+		"Condition expression for ! must be a bool expression",
+	}
+
+	for i, expression := range expressions {
+		t.Logf("Test: fold error %d ...", i)
+		folder := NewFolder()
+		folder.foldExpression(expression)
+		if len(folder.GetErrors()) != 1 {
+			t.Fatalf("Wrong error count: Wanted 1, got %d", len(folder.GetErrors()))
+		}
+		err := folder.GetErrors()[0]
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error: Wanted (%s), got (%s)", targets[i], err.GetMessage())
+		}
+		if !err.GetLocation().Equal(location) {
+			t.Fatalf("Wrong error location")
+		}
+		t.Log("Passed")
+	}
+}
+
+func TestFoldStatement(t *testing.T) {
+	location := common.NewLocation(1, 1, "test")
+	x := NewIdentifierExpression(types.NewIdentifier("x", location))
+	block := func() *Block {
+		return NewBlock([]Statement{NewExpressionStatement(x)})
+	}
+
+	t.Log("Test: prune while(false) ...")
+	statements := NewFolder().FoldStatements([]Statement{
+		NewWhileStatement(location, NewNotExpression(NewBoolExpression(true), location), block()),
+	})
+	if len(statements) != 0 {
+		t.Fatalf("while(false) should be removed")
+	}
+	t.Log("Passed")
+
+	t.Log("Test: prune if(true) ...")
+	ifStatement := NewIfStatement(location)
+	ifStatement.SetCondition(NewBoolExpression(true))
+	ifBlock := block()
+	ifStatement.SetIfBlock(ifBlock)
+	ifStatement.SetElseBlock(block(), location)
+	statements = NewFolder().FoldStatements([]Statement{ifStatement})
+	if len(statements) != 1 || statements[0] != ifBlock {
+		t.Fatalf("if(true) should be replaced by its block")
+	}
+	t.Log("Passed")
+
+	t.Log("Test: prune if(false) and elif(true) ...")
+	ifStatement = NewIfStatement(location)
+	ifStatement.SetCondition(NewBoolExpression(false))
+	ifStatement.SetIfBlock(block())
+	elifBlock := block()
+	ifStatement.AddElifBlock(x, block(), location)
+	ifStatement.AddElifBlock(NewBoolExpression(true), elifBlock, location)
+	ifStatement.AddElifBlock(x, block(), location)
+	ifStatement.SetElseBlock(nil, nil)
+	statements = NewFolder().FoldStatements([]Statement{ifStatement})
+	if len(statements) != 1 || statements[0] != ifStatement {
+		t.Fatalf("if statement with non-literal condition should be kept")
+	}
+	if ifStatement.condition != x || len(ifStatement.elifBlocks) != 0 ||
+		ifStatement.elseBlock.block != elifBlock {
+		t.Fatalf("Wrong pruned if statement")
+	}
+	t.Log("Passed")
+
+	t.Log("Test: non-bool literal condition ...")
+	folder := NewFolder()
+	folder.FoldStatements([]Statement{
+		NewWhileStatement(location, NewIntegerExpression(1), block()),
+	})
+	if len(folder.GetErrors()) != 1 || folder.GetErrors()[0].GetMessage() !=
+		"Condition expression for while must be a bool expression" {
+		t.Fatalf("Non-bool literal condition should be reported")
+	}
+	t.Log("Passed")
+}
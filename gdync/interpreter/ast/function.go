@@ -2,6 +2,8 @@ package ast
 
 import (
 	"fmt"
+	"reflect"
+	"sync"
 
 	"github.com/mlmhl/compiler/common"
 	gerror "github.com/mlmhl/compiler/gdync/errors"
@@ -79,10 +81,118 @@ func (f *PrintfFunction) Evaluate(arguments []types.Value, env *Environment) (ty
 		for i := 1; i < len(arguments); i++ {
 			values = append(values, arguments[i].GetValue())
 		}
-		fmt.Printf(format.GetValue().(string), values...)
+
+		stdout, err := GetStandardFile(types.STDOUT, env)
+		if err != nil {
+			return nil, err
+		}
+		if err := stdout.Write(fmt.Sprintf(format.GetValue().(string), values...)); err != nil {
+			return nil, gerror.NewNativeFunctionError(f.GetName(), err.Error(), nil)
+		}
+	}
+
+	return types.NewValue(types.NULL_TYPE, nil), nil
+}
+
+// GetStandardFile return the file held by global variable stdin, stdout
+// or stderr, the file of the process is used if there is no environment.
+func GetStandardFile(name string, env *Environment) (*types.File, gerror.Error) {
+	if env == nil {
+		return types.NewStandardFile(name), nil
+	}
+
+	variable := env.GetGlobalVariable(types.NewIdentifier(name, nil))
+	if variable == nil {
+		return nil, gerror.NewVariableNotFoundError(name, nil)
+	}
+	value := variable.GetValue()
+	if value.GetType() != types.FILE_TYPE {
+		return nil, gerror.NewTypeMismatchError(types.FILE_TYPE.String(),
+			value.GetType().String(), value.GetValue(), nil)
+	}
+	return value.GetValue().(*types.File), nil
+}
+
+// NativeFunction wrap a Go function provided by the host program,
+// arguments and results are converted between Go values and script values.
+type NativeFunction struct {
+	name     string
+	function reflect.Value
+}
+
+var errorInterface = reflect.TypeOf((*error)(nil)).Elem()
+
+// The function may return nothing, a value, an error, or a value and an error.
+func NewNativeFunction(name string, function interface{}) (*NativeFunction, gerror.Error) {
+	typ := reflect.TypeOf(function)
+	if typ == nil || typ.Kind() != reflect.Func {
+		return nil, gerror.NewInternalError(
+			fmt.Sprintf("Native function %s must be a Go function", name))
+	}
+	if typ.NumOut() > 2 || (typ.NumOut() == 2 && typ.Out(1) != errorInterface) {
+		return nil, gerror.NewInternalError(fmt.Sprintf("Native function %s "+
+			"should return a value, an error, or a value and an error", name))
+	}
+
+	return &NativeFunction{
+		name:     name,
+		function: reflect.ValueOf(function),
+	}, nil
+}
+
+func (f *NativeFunction) GetName() string {
+	return f.name
+}
+
+func (f *NativeFunction) GetLocation() *common.Location {
+	return nil
+}
+
+func (f *NativeFunction) Evaluate(arguments []types.Value, env *Environment) (types.Value, gerror.Error) {
+	typ := f.function.Type()
+
+	size := typ.NumIn()
+	if typ.IsVariadic() {
+		size--
+	}
+	if len(arguments) < size {
+		return nil, gerror.NewArgumentTooFewError(f.name, size, len(arguments), nil)
+	}
+	if len(arguments) > size && !typ.IsVariadic() {
+		return nil, gerror.NewArgumentTooManyError(f.name, size, len(arguments), nil)
+	}
+
+	values := []reflect.Value{}
+	for i, argument := range arguments {
+		var parameterType reflect.Type
+		if i < size {
+			parameterType = typ.In(i)
+		} else {
+			parameterType = typ.In(size).Elem()
+		}
+
+		value, err := types.ToGoType(argument, parameterType)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	results := f.function.Call(values)
+
+	if len(results) > 0 && typ.Out(len(results)-1) == errorInterface {
+		last := results[len(results)-1]
+		if !last.IsNil() {
+			return nil, gerror.NewNativeFunctionError(
+				f.name, last.Interface().(error).Error(), nil)
+		}
+		results = results[:len(results)-1]
 	}
 
-	return nil, nil
+	if len(results) == 0 {
+		return types.NewValue(types.NULL_TYPE, nil), nil
+	}
+	return types.FromGo(results[0].Interface())
 }
 
 //
@@ -93,6 +203,12 @@ type CustomFunction struct {
 	block      *Block
 	parameters []*Parameter
 
+	// count of local variable slots, parameters take the first ones
+	size int
+	// bytecode of block, compiled when it's called by the vm first time
+	chunk   *Chunk
+	compile sync.Once // tasks may call it concurrently
+
 	// use identifier's location as function's location
 	identifier *types.Identifier
 }
@@ -102,6 +218,7 @@ func NewCustomFunction(identifier *types.Identifier,
 	return &CustomFunction{
 		block:      block,
 		parameters: parameters,
+		size:       len(parameters),
 
 		identifier: identifier,
 	}
@@ -117,19 +234,8 @@ func (function *CustomFunction) GetLocation() *common.Location {
 
 func (function *CustomFunction) Evaluate(arguments []types.Value,
 	env *Environment) (types.Value, gerror.Error) {
-	if len(arguments) < len(function.parameters) {
-		return nil, gerror.NewArgumentTooFewError(
-			function.GetName(), len(function.parameters), len(arguments), nil)
-	}
-
-	if len(arguments) > len(function.parameters) {
-		return nil, gerror.NewArgumentTooManyError(
-			function.GetName(), len(function.parameters), len(arguments), nil)
-	}
-
-	for i, parameter := range function.parameters {
-		argument := arguments[i]
-		env.AddLocalVariable(types.NewVariable(parameter.identifier, argument))
+	if err := function.bind(arguments, env); err != nil {
+		return nil, err
 	}
 
 	result, err := function.block.Execute(env)
@@ -139,8 +245,30 @@ func (function *CustomFunction) Evaluate(arguments []types.Value,
 
 	if result.GetType() == RETURN_STATEMENT_RESULT {
 		return result.GetValue(), nil
+	} else if result.GetType() == THROW_STATEMENT_RESULT {
+		return nil, NewException(result.GetValue(), result.GetLocation())
 	} else {
 		// no return statement, return a null value
 		return types.NewValue(types.NULL_TYPE, nil), nil
 	}
 }
+
+// Check the count of arguments and place them in the parameter slots of env.
+func (function *CustomFunction) bind(arguments []types.Value, env *Environment) gerror.Error {
+	if len(arguments) < len(function.parameters) {
+		return gerror.NewArgumentTooFewError(
+			function.GetName(), len(function.parameters), len(arguments), nil)
+	}
+
+	if len(arguments) > len(function.parameters) {
+		return gerror.NewArgumentTooManyError(
+			function.GetName(), len(function.parameters), len(arguments), nil)
+	}
+
+	env.ReserveLocalVariables(function.size)
+	for i, parameter := range function.parameters {
+		argument := arguments[i]
+		env.SetLocalVariable(i, types.NewVariable(parameter.identifier, argument))
+	}
+	return nil
+}
@@ -46,5 +46,5 @@ func TestPrintfFunction(t *testing.T) {
 	arguments = append(arguments, types.NewValue(types.STRING_TYPE, "My name is %s, I am %d years old"))
 	arguments = append(arguments, types.NewValue(types.STRING_TYPE, "gdync"))
 	arguments = append(arguments, types.NewValue(types.INTEGER_TYPE, 0))
-	functionTest(function, arguments, nil, nil, t)
+	functionTest(function, arguments, nil, types.NewValue(types.NULL_TYPE, nil), t)
 }
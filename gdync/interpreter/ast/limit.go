@@ -0,0 +1,179 @@
+package ast
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Execution limits: a script exceeding any of them, or whose context is
+// done, is aborted by a LimitExceededError, which can't be caught by script.
+//
+
+const (
+	STATEMENTS_LIMIT  = "statements"
+	CALL_DEPTH_LIMIT  = "call depth"
+	STRING_SIZE_LIMIT = "string size"
+	TIME_LIMIT        = "time"
+	CONTEXT_LIMIT     = "context"
+)
+
+// the context and clock are checked once per checkInterval statements
+const checkInterval = 256
+
+// Limits of an execution, a zero field means no limit.
+type Limits struct {
+	Statements int64         // executed statements and loop iterations
+	CallDepth  int           // nested function calls
+	StringSize int           // bytes of a string created by script
+	Time       time.Duration // wall-clock time
+}
+
+// Limiter checks the limits of an execution, a nil Limiter checks nothing.
+type Limiter struct {
+	limits   Limits
+	ctx      context.Context
+	deadline time.Time // zero if there is no time limit
+
+	statements int64 // counted by tasks atomically
+}
+
+func NewLimiter(ctx context.Context, limits Limits) *Limiter {
+	limiter := &Limiter{
+		limits: limits,
+		ctx:    ctx,
+	}
+	if limits.Time > 0 {
+		limiter.deadline = time.Now().Add(limits.Time)
+	}
+	return limiter
+}
+
+// Step counts a statement executed at location.
+func (limiter *Limiter) Step(location *common.Location) gerror.Error {
+	if limiter == nil {
+		return nil
+	}
+
+	statements := atomic.AddInt64(&limiter.statements, 1)
+	if limiter.limits.Statements > 0 && statements > limiter.limits.Statements {
+		return gerror.NewLimitExceededError(STATEMENTS_LIMIT, fmt.Sprintf(
+			"Execution exceeds the limit of %d statements", limiter.limits.Statements), location)
+	}
+	if statements%checkInterval == 0 {
+		return limiter.checkTime(location)
+	}
+	return nil
+}
+
+// CheckContext checks the context at once, instead of once per
+// checkInterval statements.
+func (limiter *Limiter) CheckContext(location *common.Location) gerror.Error {
+	if limiter == nil || limiter.ctx == nil {
+		return nil
+	}
+	if err := limiter.ctx.Err(); err != nil {
+		return gerror.NewLimitExceededError(CONTEXT_LIMIT,
+			"Execution is canceled: "+err.Error(), location)
+	}
+	return nil
+}
+
+// Returns a channel closed once the context is done, nil if there is
+// no context.
+func (limiter *Limiter) done() <-chan struct{} {
+	if limiter == nil || limiter.ctx == nil {
+		return nil
+	}
+	return limiter.ctx.Done()
+}
+
+func (limiter *Limiter) checkTime(location *common.Location) gerror.Error {
+	if err := limiter.CheckContext(location); err != nil {
+		return err
+	}
+	if !limiter.deadline.IsZero() && time.Now().After(limiter.deadline) {
+		return gerror.NewLimitExceededError(TIME_LIMIT, fmt.Sprintf(
+			"Execution exceeds the time limit of %s", limiter.limits.Time), location)
+	}
+	return nil
+}
+
+// Check the depth of a function call.
+func (limiter *Limiter) checkCallDepth(depth int) gerror.Error {
+	if limiter == nil || limiter.limits.CallDepth <= 0 || depth <= limiter.limits.CallDepth {
+		return nil
+	}
+	return gerror.NewLimitExceededError(CALL_DEPTH_LIMIT, fmt.Sprintf(
+		"Call depth exceeds the limit of %d", limiter.limits.CallDepth), nil)
+}
+
+// Check the size of a string before it's created.
+func (limiter *Limiter) checkStringSize(size int64, location *common.Location) gerror.Error {
+	if limiter == nil || limiter.limits.StringSize <= 0 || size <= int64(limiter.limits.StringSize) {
+		return nil
+	}
+	return gerror.NewLimitExceededError(STRING_SIZE_LIMIT, fmt.Sprintf(
+		"String size %d exceeds the limit of %d", size, limiter.limits.StringSize), location)
+}
+
+// Check the string created by a binary operation before it's evaluated,
+// concatenation and repetition are the only operators creating strings.
+func (limiter *Limiter) checkOperation(op string, left, right types.Value,
+	location *common.Location) gerror.Error {
+	if limiter == nil || limiter.limits.StringSize <= 0 {
+		return nil
+	}
+
+	switch op {
+	case types.ADD:
+		// other operands aren't printed here, the result is checked after
+		// concatenation
+		if isString(left) || isString(right) {
+			size := stringSize(left) + stringSize(right)
+			return limiter.checkStringSize(size, location)
+		}
+	case types.MULTIPLY:
+		if isString(right) {
+			left, right = right, left
+		}
+		if isString(left) {
+			if count, ok := right.GetValue().(int64); ok && count > 0 {
+				size := int64(len(left.GetValue().(string)))
+				if size > 0 && count > math.MaxInt64/size {
+					// the product overflows
+					return limiter.checkStringSize(math.MaxInt64, location)
+				}
+				return limiter.checkStringSize(size*count, location)
+			}
+		}
+	}
+	return nil
+}
+
+// Check a value returned by a function or an operation, native functions
+// may create strings.
+func (limiter *Limiter) checkValue(value types.Value, location *common.Location) gerror.Error {
+	if limiter == nil || value == nil || !isString(value) {
+		return nil
+	}
+	return limiter.checkStringSize(int64(len(value.GetValue().(string))), location)
+}
+
+func isString(value types.Value) bool {
+	return value.GetType() == types.STRING_TYPE
+}
+
+func stringSize(value types.Value) int64 {
+	if isString(value) {
+		return int64(len(value.GetValue().(string)))
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package ast
+
+import (
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+// ModuleFunction is a custom function imported from another module under
+// a namespace, it's always executed in the global scope of its module.
+type ModuleFunction struct {
+	name     string // qualified by the namespace
+	function *CustomFunction
+	env      *Environment // global scope of the module
+}
+
+func NewModuleFunction(namespace string, function *CustomFunction,
+	env *Environment) *ModuleFunction {
+	return &ModuleFunction{
+		name:     namespace + "." + function.GetName(),
+		function: function,
+		env:      env,
+	}
+}
+
+func (function *ModuleFunction) GetName() string {
+	return function.name
+}
+
+func (function *ModuleFunction) GetLocation() *common.Location {
+	return function.function.GetLocation()
+}
+
+// The local scope of the caller is replaced by one of the module.
+func (function *ModuleFunction) Evaluate(arguments []types.Value,
+	env *Environment) (types.Value, gerror.Error) {
+	return function.function.Evaluate(arguments, function.localEnvironment(env))
+}
+
+func (function *ModuleFunction) localEnvironment(env *Environment) *Environment {
+	localEnv := function.env.localScope()
+	localEnv.inherit(env)
+	return localEnv
+}
+
+// ImportStatement executes an imported module by load, which exposes its
+// members under namespace. A module is executed by its first import only,
+// imports can only be top level statements.
+type ImportStatement struct {
+	path      string
+	namespace string
+	location  *common.Location // location of keyword 'import'
+
+	load func(env *Environment) gerror.Error
+}
+
+func NewImportStatement(path, namespace string, location *common.Location,
+	load func(env *Environment) gerror.Error) *ImportStatement {
+	return &ImportStatement{
+		path:      path,
+		namespace: namespace,
+		location:  location,
+		load:      load,
+	}
+}
+
+func (statement *ImportStatement) GetPath() string {
+	return statement.path
+}
+
+func (statement *ImportStatement) GetNamespace() string {
+	return statement.namespace
+}
+
+func (statement *ImportStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
+func (statement *ImportStatement) Execute(env *Environment) (*StatementResult, gerror.Error) {
+	if err := statement.load(env); err != nil {
+		return nil, err
+	}
+	return NewStatementResult(NORMAL_STATEMENT_RESULT, nil), nil
+}
@@ -0,0 +1,23 @@
+package ast
+
+import (
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+// Observer watches an execution, like a debugger. OnStep is invoked before
+// each statement and each iteration of a loop at location, by both of the
+// tree walker and the vm. Execution is aborted if it returns an error.
+type Observer interface {
+	OnStep(location *common.Location, env *Environment) gerror.Error
+}
+
+// Tracer is an observer which watches function calls and assignments too,
+// env is the local scope of the function called or returning.
+type Tracer interface {
+	Observer
+	OnCall(arguments []types.Value, env *Environment)
+	OnReturn(value types.Value, env *Environment)
+	OnAssign(identifier *types.Identifier, value types.Value, env *Environment)
+}
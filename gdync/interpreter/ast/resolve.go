@@ -0,0 +1,323 @@
+package ast
+
+import (
+	"strings"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Static resolution: local variables are bound to slots of the function
+// frame, undefined variables and functions and duplicate definitions are
+// reported before execution. Global variables and functions are still
+// looked up by name, the host program and the following inputs of an
+// interactive session can change them.
+//
+
+// local variables of a function
+type scope struct {
+	size      int
+	slots     map[string]int
+	locations map[string]*common.Location // where the variable is defined
+}
+
+func newScope() *scope {
+	return &scope{
+		size:      0,
+		slots:     map[string]int{},
+		locations: map[string]*common.Location{},
+	}
+}
+
+func (scope *scope) define(identifier *types.Identifier) int {
+	slot := scope.size
+	scope.size++
+	scope.slots[identifier.GetName()] = slot
+	scope.locations[identifier.GetName()] = identifier.GetLocation()
+	return slot
+}
+
+type Resolver struct {
+	env *Environment
+
+	// global variables assigned by the statements resolved so far
+	defined map[string]bool
+	// functions and structs defined by the source, not added to env yet
+	functions map[string]Function
+
+	scope *scope // nil in global scope
+
+	errors []gerror.Error
+}
+
+func NewResolver(env *Environment) *Resolver {
+	return &Resolver{
+		env:       env,
+		defined:   map[string]bool{},
+		functions: map[string]Function{},
+		errors:    []gerror.Error{},
+	}
+}
+
+func (resolver *Resolver) GetErrors() []gerror.Error {
+	return resolver.errors
+}
+
+// DefineStructs register structs defined by a source, which must be
+// called before Resolve, a duplicated struct is reported.
+func (resolver *Resolver) DefineStructs(structs []*StructDefinition) {
+	for _, definition := range structs {
+		if first, ok := resolver.functions[definition.GetName()]; ok {
+			resolver.errors = append(resolver.errors, gerror.NewStructDuplicateDefinitionError(
+				definition.GetName(), first.GetLocation(), definition.GetLocation()))
+			continue
+		}
+		resolver.functions[definition.GetName()] = definition
+	}
+}
+
+// DefineImports register members of the modules imported by a source by
+// their qualified names, which must be called before Resolve. They are
+// exposed to env when the imports are executed.
+func (resolver *Resolver) DefineImports(variables []string, functions map[string]Function) {
+	for _, name := range variables {
+		resolver.defined[name] = true
+	}
+	for name, function := range functions {
+		resolver.functions[name] = function
+	}
+}
+
+// GetGlobals returns the global variables assigned by the statements
+// resolved, members of imported modules excluded.
+func (resolver *Resolver) GetGlobals() []string {
+	names := []string{}
+	for name := range resolver.defined {
+		if !strings.Contains(name, ".") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Resolve the top level statements and functions defined by a source,
+// functions are resolved after statements so that global statements
+// can see all global variables assigned in top level.
+func (resolver *Resolver) Resolve(statements []Statement, functions []*CustomFunction) {
+	resolved := []*CustomFunction{}
+	for _, function := range functions {
+		if first, ok := resolver.functions[function.GetName()]; ok {
+			resolver.errors = append(resolver.errors, gerror.NewFunctionDuplicateDefinitionError(
+				function.GetName(), first.GetLocation(), function.GetLocation()))
+			continue
+		}
+		resolver.functions[function.GetName()] = function
+		resolved = append(resolved, function)
+	}
+
+	for _, statement := range statements {
+		resolver.resolveStatement(statement)
+	}
+	for _, function := range resolved {
+		resolver.resolveFunction(function)
+	}
+}
+
+func (resolver *Resolver) resolveFunction(function *CustomFunction) {
+	resolver.scope = newScope()
+	defer func() {
+		resolver.scope = nil
+	}()
+
+	for _, parameter := range function.parameters {
+		resolver.defineLocal(parameter.identifier)
+	}
+	resolver.resolveBlock(function.block)
+
+	function.size = resolver.scope.size
+}
+
+func (resolver *Resolver) resolveBlock(block *Block) {
+	for _, statement := range block.statements {
+		resolver.resolveStatement(statement)
+	}
+}
+
+func (resolver *Resolver) resolveStatement(statement Statement) {
+	switch statement := statement.(type) {
+	case *Block:
+		resolver.resolveBlock(statement)
+	case *ExpressionStatement:
+		resolver.resolveExpression(statement.expression)
+	case *GlobalStatement:
+		resolver.resolveGlobal(statement)
+	case *IfStatement:
+		resolver.resolveExpression(statement.condition)
+		resolver.resolveBlock(statement.ifBlock)
+		for _, elif := range statement.elifBlocks {
+			resolver.resolveExpression(elif.condition)
+			resolver.resolveBlock(elif.block)
+		}
+		if statement.elseBlock.block != nil {
+			resolver.resolveBlock(statement.elseBlock.block)
+		}
+	case *WhileStatement:
+		resolver.resolveExpression(statement.condition)
+		resolver.resolveBlock(statement.block)
+	case *ForStatement:
+		resolver.resolveExpression(statement.init)
+		resolver.resolveExpression(statement.condition)
+		resolver.resolveExpression(statement.post)
+		resolver.resolveBlock(statement.block)
+	case *ForeachStatement:
+		resolver.resolveExpression(statement.collection)
+		statement.slot = resolver.assignVariable(statement.identifier)
+		resolver.resolveBlock(statement.block)
+	case *SwitchStatement:
+		resolver.resolveExpression(statement.value)
+		for _, c := range statement.cases {
+			resolver.resolveBlock(c.block)
+		}
+		if statement.defaultBlock != nil {
+			resolver.resolveBlock(statement.defaultBlock)
+		}
+	case *ReturnStatement:
+		resolver.resolveExpression(statement.returnValue)
+	case *ThrowStatement:
+		resolver.resolveExpression(statement.value)
+	case *TryStatement:
+		resolver.resolveBlock(statement.tryBlock)
+		if statement.catchBlock != nil {
+			statement.slot = resolver.assignVariable(statement.identifier)
+			resolver.resolveBlock(statement.catchBlock)
+		}
+		if statement.finallyBlock != nil {
+			resolver.resolveBlock(statement.finallyBlock)
+		}
+	}
+}
+
+func (resolver *Resolver) resolveGlobal(statement *GlobalStatement) {
+	if resolver.scope == nil {
+		resolver.errors = append(resolver.errors,
+			gerror.NewGlobalStatementInTopLevelError(statement.location))
+		return
+	}
+
+	statement.slots = []int{}
+	for _, id := range statement.identifiers {
+		if location, ok := resolver.scope.locations[id.GetName()]; ok {
+			resolver.errors = append(resolver.errors, gerror.NewVariableDuplicateDefinitionError(
+				id.GetName(), location, id.GetLocation()))
+			statement.slots = append(statement.slots, resolver.scope.slots[id.GetName()])
+			continue
+		}
+		if !resolver.isGlobal(id) {
+			resolver.errors = append(resolver.errors,
+				gerror.NewVariableNotFoundError(id.GetName(), id.GetLocation()))
+		}
+		statement.slots = append(statement.slots, resolver.scope.define(id))
+	}
+}
+
+func (resolver *Resolver) resolveExpression(expression Expression) {
+	switch e := expression.(type) {
+	case *IdentifierExpression:
+		e.slot = resolver.resolveVariable(e.identifier)
+	case *IncrementExpression:
+		e.slot = resolver.resolveVariable(e.identifier)
+	case *ConditionalExpression:
+		resolver.resolveExpression(e.condition)
+		resolver.resolveExpression(e.trueBranch)
+		resolver.resolveExpression(e.falseBranch)
+	case *AssignExpression:
+		resolver.resolveExpression(e.operand)
+		e.slot = resolver.assignVariable(e.identifier)
+	case *FieldExpression:
+		resolver.resolveExpression(e.object)
+	case *FieldAssignExpression:
+		resolver.resolveExpression(e.object)
+		resolver.resolveExpression(e.operand)
+	case *FieldIncrementExpression:
+		resolver.resolveExpression(e.object)
+	case *FunctionCallExpression:
+		if _, ok := resolver.functions[e.identifier.GetName()]; !ok &&
+			resolver.env.GetFunction(e.identifier) == nil {
+			resolver.errors = append(resolver.errors,
+				gerror.NewFunctionNotFoundError(e.identifier.GetName(), e.location))
+		}
+		for _, argument := range e.arguments {
+			resolver.resolveExpression(argument.expression)
+		}
+	case *SpawnExpression:
+		resolver.resolveExpression(e.call)
+	case binaryOperation:
+		binary := e.getBinary()
+		resolver.resolveExpression(binary.left)
+		resolver.resolveExpression(binary.right)
+	case unaryOperation:
+		resolver.resolveExpression(e.getUnary().expression)
+	}
+}
+
+// Returns the slot of a variable to read, -1 in global scope
+// or for a member of imported module.
+func (resolver *Resolver) resolveVariable(identifier *types.Identifier) int {
+	if resolver.scope == nil || isQualified(identifier) {
+		if !resolver.isGlobal(identifier) {
+			resolver.errors = append(resolver.errors, gerror.NewVariableNotFoundError(
+				identifier.GetName(), identifier.GetLocation()))
+		}
+		return -1
+	}
+	slot, ok := resolver.scope.slots[identifier.GetName()]
+	if !ok {
+		resolver.errors = append(resolver.errors, gerror.NewVariableNotFoundError(
+			identifier.GetName(), identifier.GetLocation()))
+		return -1
+	}
+	return slot
+}
+
+// Returns the slot of a variable to assign, which is defined if it
+// doesn't exist, -1 in global scope.
+func (resolver *Resolver) assignVariable(identifier *types.Identifier) int {
+	if isQualified(identifier) {
+		// members of imported module can't be created by the importer
+		if !resolver.isGlobal(identifier) {
+			resolver.errors = append(resolver.errors, gerror.NewVariableNotFoundError(
+				identifier.GetName(), identifier.GetLocation()))
+		}
+		return -1
+	}
+	if resolver.scope == nil {
+		resolver.defined[identifier.GetName()] = true
+		return -1
+	}
+	slot, ok := resolver.scope.slots[identifier.GetName()]
+	if !ok {
+		slot = resolver.scope.define(identifier)
+	}
+	return slot
+}
+
+func (resolver *Resolver) defineLocal(identifier *types.Identifier) {
+	if location, ok := resolver.scope.locations[identifier.GetName()]; ok {
+		resolver.errors = append(resolver.errors, gerror.NewVariableDuplicateDefinitionError(
+			identifier.GetName(), location, identifier.GetLocation()))
+	}
+	resolver.scope.define(identifier)
+}
+
+// A global variable exists if it's assigned before or by the host program.
+func (resolver *Resolver) isGlobal(identifier *types.Identifier) bool {
+	return resolver.defined[identifier.GetName()] ||
+		resolver.env.GetGlobalVariable(identifier) != nil
+}
+
+// A qualified name like ns.x refers to a member of imported module.
+func isQualified(identifier *types.Identifier) bool {
+	return strings.Contains(identifier.GetName(), ".")
+}
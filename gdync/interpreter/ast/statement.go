@@ -8,6 +8,8 @@ import (
 
 type Statement interface {
 	Execute(env *Environment) (*StatementResult, gerror.Error)
+	// location where the statement starts, nil for a block
+	GetLocation() *common.Location
 }
 
 //
@@ -16,6 +18,8 @@ type Statement interface {
 
 type ExpressionStatement struct {
 	expression Expression
+
+	location *common.Location // location of the first token
 }
 
 func NewExpressionStatement(expression Expression) *ExpressionStatement {
@@ -24,6 +28,18 @@ func NewExpressionStatement(expression Expression) *ExpressionStatement {
 	}
 }
 
+func (statement *ExpressionStatement) GetExpression() Expression {
+	return statement.expression
+}
+
+func (statement *ExpressionStatement) SetLocation(location *common.Location) {
+	statement.location = location
+}
+
+func (statement *ExpressionStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
 func (statement *ExpressionStatement) Execute(
 	env *Environment) (*StatementResult, gerror.Error) {
 	value, err := statement.expression.Evaluate(env)
@@ -40,6 +56,9 @@ func (statement *ExpressionStatement) Execute(
 type GlobalStatement struct {
 	identifiers []*types.Identifier
 	location    *common.Location // location of keyword 'global'
+
+	// slots of local variables sharing the global ones, bound by the resolver
+	slots []int
 }
 
 func NewGlobalStatement(location *common.Location) *GlobalStatement {
@@ -53,6 +72,10 @@ func (statement *GlobalStatement) SetIdentifiers(identifiers []*types.Identifier
 	statement.identifiers = identifiers
 }
 
+func (statement *GlobalStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
 func (statement *GlobalStatement) Execute(
 	env *Environment) (*StatementResult, gerror.Error) {
 	if env.IsGlobal() {
@@ -72,18 +95,11 @@ func (statement *GlobalStatement) Execute(
 			return nil, gerror.NewVariableNotFoundError(
 				id.GetName(), statement.location)
 		}
-
-		localVariable := env.GetLocalVariable(id)
-		if localVariable != nil {
-			return nil, gerror.NewVariableDuplicateDefinitionError(id.GetName(),
-				localVariable.GetLocation(), variable.GetLocation())
-		}
-
 		variables = append(variables, variable)
 	}
 
-	for _, variable := range variables {
-		env.AddLocalVariable(variable)
+	for i, variable := range variables {
+		env.SetLocalVariable(statement.slots[i], variable)
 	}
 
 	return result, nil
@@ -147,6 +163,10 @@ func (statement *IfStatement) AddElifBlock(
 	})
 }
 
+func (statement *IfStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
 func (statement *IfStatement) Execute(env *Environment) (
 	*StatementResult, gerror.Error) {
 	var goon types.Value
@@ -208,12 +228,19 @@ func NewWhileStatement(location *common.Location,
 	}
 }
 
+func (statement *WhileStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
 func (statement *WhileStatement) Execute(
 	env *Environment) (*StatementResult, gerror.Error) {
 	var goon types.Value
 	var err gerror.Error
 	var result *StatementResult
 	for {
+		if err = env.Step(statement.location); err != nil {
+			break
+		}
 		goon, err = statement.condition.Evaluate(env)
 		if err != nil {
 			break
@@ -230,7 +257,8 @@ func (statement *WhileStatement) Execute(
 			if err != nil {
 				break
 			}
-			if result.GetType() == RETURN_STATEMENT_RESULT {
+			if result.GetType() == RETURN_STATEMENT_RESULT ||
+				result.GetType() == THROW_STATEMENT_RESULT {
 				break
 			}
 			if result.GetType() == BREAK_STATEMENT_RESULT {
@@ -275,6 +303,10 @@ func (statement *ForStatement) SetBlock(block *Block) {
 	statement.block = block
 }
 
+func (statement *ForStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
 func (statement *ForStatement) Execute(
 	env *Environment) (*StatementResult, gerror.Error) {
 	if statement.init != nil {
@@ -288,6 +320,9 @@ func (statement *ForStatement) Execute(
 	var result *StatementResult
 
 	for {
+		if err = env.Step(statement.location); err != nil {
+			return nil, err
+		}
 		if statement.condition != nil {
 			goon, err = statement.condition.Evaluate(env)
 			if err != nil {
@@ -306,7 +341,8 @@ func (statement *ForStatement) Execute(
 		if err != nil {
 			return nil, err
 		}
-		if result.GetType() == RETURN_STATEMENT_RESULT {
+		if result.GetType() == RETURN_STATEMENT_RESULT ||
+			result.GetType() == THROW_STATEMENT_RESULT {
 			break
 		}
 		if result.GetType() == BREAK_STATEMENT_RESULT {
@@ -324,6 +360,194 @@ func (statement *ForStatement) Execute(
 	return result, err
 }
 
+// ForeachStatement assign each element of a collection to the variable
+// identifier, then execute the block.
+type ForeachStatement struct {
+	identifier *types.Identifier
+	slot       int // slot of the variable in local scope
+	collection Expression
+	block      *Block
+
+	location *common.Location // location for 'for' keyword
+}
+
+func NewForeachStatement(location *common.Location, identifier *types.Identifier,
+	collection Expression, block *Block) *ForeachStatement {
+	return &ForeachStatement{
+		identifier: identifier,
+		slot:       -1,
+		collection: collection,
+		block:      block,
+
+		location: location,
+	}
+}
+
+func (statement *ForeachStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
+func (statement *ForeachStatement) Execute(
+	env *Environment) (*StatementResult, gerror.Error) {
+	collection, err := statement.collection.Evaluate(env)
+	if err != nil {
+		return nil, err
+	}
+	iterator, err := iterate(collection, statement.location)
+	if err != nil {
+		return nil, err
+	}
+
+	result := NewStatementResult(NORMAL_STATEMENT_RESULT, nil)
+	for element, ok := iterator.Next(); ok; element, ok = iterator.Next() {
+		if err = env.Step(statement.location); err != nil {
+			return nil, err
+		}
+		assign(env, statement.identifier, statement.slot, element)
+
+		result, err = statement.block.Execute(env)
+		if err != nil {
+			return nil, err
+		}
+		if result.GetType() == RETURN_STATEMENT_RESULT ||
+			result.GetType() == THROW_STATEMENT_RESULT {
+			break
+		}
+		if result.GetType() == BREAK_STATEMENT_RESULT {
+			result.SetType(NORMAL_STATEMENT_RESULT)
+			break
+		}
+	}
+	if result.GetType() == CONTINUE_STATEMENT_RESULT {
+		result.SetType(NORMAL_STATEMENT_RESULT)
+	}
+
+	return result, nil
+}
+
+// Returns an iterator over collection, the error is located at location.
+func iterate(collection types.Value, location *common.Location) (types.Iterator, gerror.Error) {
+	iterator, err := types.Iterate(collection)
+	if err != nil {
+		err.SetLocation(location)
+	}
+	return iterator, err
+}
+
+// SwitchStatement execute the block of the case matching value, or the
+// default block if no case matches. Break ends the switch statement.
+type SwitchStatement struct {
+	value        Expression
+	cases        []*switchCase
+	defaultBlock *Block
+
+	location        *common.Location // location for 'switch' keyword
+	defaultLocation *common.Location
+}
+
+type switchCase struct {
+	values    []types.Value
+	locations []*common.Location
+	block     *Block
+}
+
+func NewSwitchStatement(value Expression, location *common.Location) *SwitchStatement {
+	return &SwitchStatement{
+		value: value,
+		cases: []*switchCase{},
+
+		location: location,
+	}
+}
+
+// The caller must check if any value is duplicated by GetCaseLocation.
+func (statement *SwitchStatement) AddCase(values []types.Value,
+	locations []*common.Location, block *Block) {
+	statement.cases = append(statement.cases, &switchCase{
+		values:    values,
+		locations: locations,
+		block:     block,
+	})
+}
+
+// Returns the location of the case value matching value, nil if not found.
+func (statement *SwitchStatement) GetCaseLocation(value types.Value) *common.Location {
+	for _, c := range statement.cases {
+		for i, v := range c.values {
+			if MatchCase(value, v) {
+				return c.locations[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (statement *SwitchStatement) SetDefaultBlock(block *Block, location *common.Location) {
+	statement.defaultBlock = block
+	statement.defaultLocation = location
+}
+
+// Returns the location of default, nil if there is no default block.
+func (statement *SwitchStatement) GetDefaultLocation() *common.Location {
+	return statement.defaultLocation
+}
+
+func (statement *SwitchStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
+func (statement *SwitchStatement) Execute(
+	env *Environment) (*StatementResult, gerror.Error) {
+	value, err := statement.value.Evaluate(env)
+	if err != nil {
+		return nil, err
+	}
+
+	block := statement.defaultBlock
+	for _, c := range statement.cases {
+		if c.match(value) {
+			block = c.block
+			break
+		}
+	}
+	if block == nil {
+		return NewStatementResult(NORMAL_STATEMENT_RESULT, nil), nil
+	}
+
+	result, err := block.Execute(env)
+	if err != nil {
+		return nil, err
+	}
+	if result.GetType() == BREAK_STATEMENT_RESULT {
+		result.SetType(NORMAL_STATEMENT_RESULT)
+	}
+	// continue is left to the enclosing loop
+	return result, nil
+}
+
+func (c *switchCase) match(value types.Value) bool {
+	for _, v := range c.values {
+		if MatchCase(value, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchCase reports whether value matches a case constant, which means
+// they have the same type and are equal, so 1 doesn't match 1.0.
+func MatchCase(value, constant types.Value) bool {
+	if value.GetType() != constant.GetType() {
+		return false
+	}
+	if value.GetType() == types.BIG_INTEGER_TYPE {
+		equal, _ := types.RelationalOperation(types.EQUAL, value, constant)
+		return equal.GetValue().(bool)
+	}
+	// constants are never collections, values can be compared directly
+	return value.GetValue() == constant.GetValue()
+}
+
 type ReturnStatement struct {
 	returnValue Expression
 	location    *common.Location // location for 'return' keyword
@@ -337,6 +561,10 @@ func NewReturnStatement(value Expression,
 	}
 }
 
+func (statement *ReturnStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
 func (statement *ReturnStatement) Execute(
 	env *Environment) (*StatementResult, gerror.Error) {
 	if statement.returnValue == nil {
@@ -363,6 +591,10 @@ func NewBreakStatement(location *common.Location) *BreakStatement {
 	}
 }
 
+func (statement *BreakStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
 func (statement *BreakStatement) Execute(
 	env *Environment) (*StatementResult, gerror.Error) {
 	return NewStatementResult(BREAK_STATEMENT_RESULT, nil), nil
@@ -378,7 +610,101 @@ func NewContinueStatement(location *common.Location) *ContinueStatement {
 	}
 }
 
+func (statement *ContinueStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
 func (statement *ContinueStatement) Execute(
 	env *Environment) (*StatementResult, gerror.Error) {
 	return NewStatementResult(CONTINUE_STATEMENT_RESULT, nil), nil
 }
+
+type ThrowStatement struct {
+	value    Expression
+	location *common.Location // location for 'throw' keyword
+}
+
+func NewThrowStatement(value Expression,
+	location *common.Location) *ThrowStatement {
+	return &ThrowStatement{
+		value:    value,
+		location: location,
+	}
+}
+
+func (statement *ThrowStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
+func (statement *ThrowStatement) Execute(
+	env *Environment) (*StatementResult, gerror.Error) {
+	value, err := statement.value.Evaluate(env)
+	if err != nil {
+		return nil, err
+	}
+	return NewThrowStatementResult(value, statement.location), nil
+}
+
+// TryStatement has a catch block, a finally block or both of them.
+type TryStatement struct {
+	tryBlock *Block
+	location *common.Location // location for 'try' keyword
+
+	// variable holding the caught value, nil if there is no catch block
+	identifier *types.Identifier
+	catchBlock *Block
+	// slot of the variable in local scope, bound by the resolver
+	slot int
+
+	finallyBlock *Block
+}
+
+func NewTryStatement(block *Block, location *common.Location) *TryStatement {
+	return &TryStatement{
+		tryBlock: block,
+		location: location,
+		slot:     -1,
+	}
+}
+
+func (statement *TryStatement) SetCatchBlock(identifier *types.Identifier, block *Block) {
+	statement.identifier = identifier
+	statement.catchBlock = block
+}
+
+func (statement *TryStatement) SetFinallyBlock(block *Block) {
+	statement.finallyBlock = block
+}
+
+func (statement *TryStatement) GetLocation() *common.Location {
+	return statement.location
+}
+
+// The finally block is executed however the try and catch blocks end, except
+// an error which can't be caught, its result replaces theirs if it doesn't
+// end normally.
+func (statement *TryStatement) Execute(
+	env *Environment) (*StatementResult, gerror.Error) {
+	result, err := statement.tryBlock.Execute(env)
+
+	if statement.catchBlock != nil {
+		if value, ok := thrownValue(result, err); ok {
+			assign(env, statement.identifier, statement.slot, value)
+			result, err = statement.catchBlock.Execute(env)
+		}
+	}
+
+	if statement.finallyBlock == nil {
+		return result, err
+	}
+	if err != nil {
+		if _, ok := exceptionValue(err); !ok {
+			return nil, err
+		}
+	}
+	finallyResult, finallyErr := statement.finallyBlock.Execute(env)
+	if finallyErr != nil || finallyResult.GetType() != NORMAL_STATEMENT_RESULT {
+		return finallyResult, finallyErr
+	}
+	return result, err
+}
@@ -1,6 +1,7 @@
 package ast
 
 import (
+	"github.com/mlmhl/compiler/common"
 	"github.com/mlmhl/compiler/gdync/interpreter/types"
 )
 
@@ -9,11 +10,14 @@ const (
 	RETURN_STATEMENT_RESULT
 	BREAK_STATEMENT_RESULT
 	CONTINUE_STATEMENT_RESULT
+	THROW_STATEMENT_RESULT // value is the thrown value
 )
 
 type StatementResult struct {
 	typ int
 	value types.Value
+
+	location *common.Location // location of the throw statement
 }
 
 func NewStatementResult(typ int, value types.Value) *StatementResult {
@@ -23,6 +27,15 @@ func NewStatementResult(typ int, value types.Value) *StatementResult {
 	}
 }
 
+func NewThrowStatementResult(value types.Value,
+	location *common.Location) *StatementResult {
+	return &StatementResult{
+		typ: THROW_STATEMENT_RESULT,
+		value: value,
+		location: location,
+	}
+}
+
 func (result *StatementResult) GetType() int {
 	return result.typ
 }
@@ -34,3 +47,7 @@ func (result *StatementResult) GetValue() types.Value {
 func (result *StatementResult) SetType(typ int) {
 	result.typ = typ
 }
+
+func (result *StatementResult) GetLocation() *common.Location {
+	return result.location
+}
@@ -0,0 +1,123 @@
+package ast
+
+import (
+	"path/filepath"
+
+	"github.com/mlmhl/compiler/common"
+)
+
+//
+// Stepper decides where an observer like a debugger stops the execution:
+// at breakpoints, or after stepping in, over or out of a function.
+//
+
+type StepMode int
+
+const (
+	STEP_CONTINUE StepMode = iota // stop at breakpoints only
+	STEP_IN                       // stop at any statement
+	STEP_OVER                     // stop at a statement of the frame or its callers
+	STEP_OUT                      // stop at a statement of the callers
+)
+
+type Stepper struct {
+	breakpoints map[string]map[int]bool // lines of each file
+
+	mode  StepMode
+	depth int // depth of the frame resumed
+
+	// where the execution stopped last, the statement of a loop is seen
+	// again as its first iteration, which shouldn't stop twice
+	location *common.Location
+	env      *Environment
+}
+
+func NewStepper(mode StepMode) *Stepper {
+	return &Stepper{
+		breakpoints: map[string]map[int]bool{},
+		mode:        mode,
+	}
+}
+
+// Breakpoint files are matched by path, absolute path or base name.
+func (stepper *Stepper) AddBreakpoint(file string, line int) {
+	if stepper.breakpoints[file] == nil {
+		stepper.breakpoints[file] = map[int]bool{}
+	}
+	stepper.breakpoints[file][line] = true
+}
+
+// Returns false if there is no such breakpoint.
+func (stepper *Stepper) RemoveBreakpoint(file string, line int) bool {
+	if !stepper.breakpoints[file][line] {
+		return false
+	}
+	delete(stepper.breakpoints[file], line)
+	return true
+}
+
+// Replace all breakpoints of file with lines.
+func (stepper *Stepper) SetBreakpoints(file string, lines []int) {
+	delete(stepper.breakpoints, file)
+	for _, line := range lines {
+		stepper.AddBreakpoint(file, line)
+	}
+}
+
+func (stepper *Stepper) IsBreakpoint(location *common.Location) bool {
+	for file, lines := range stepper.breakpoints {
+		if lines[location.GetLine()] && matchFile(file, location.GetFileName()) {
+			return true
+		}
+	}
+	return false
+}
+
+// file may be a base name, or a path relative to the working directory.
+func matchFile(file, fileName string) bool {
+	if file == fileName || file == filepath.Base(fileName) {
+		return true
+	}
+	abs, err := filepath.Abs(fileName)
+	if err != nil {
+		return false
+	}
+	target, err := filepath.Abs(file)
+	return err == nil && target == abs
+}
+
+// Stop returns true if the execution should stop at location in env,
+// which is remembered as the latest stop then.
+func (stepper *Stepper) Stop(location *common.Location, env *Environment) bool {
+	if location == nil {
+		return false
+	}
+	if env == stepper.env && stepper.location != nil && location.Equal(stepper.location) {
+		return false
+	}
+
+	stop := stepper.IsBreakpoint(location)
+	switch stepper.mode {
+	case STEP_IN:
+		stop = true
+	case STEP_OVER:
+		stop = stop || env.GetDepth() <= stepper.depth
+	case STEP_OUT:
+		stop = stop || env.GetDepth() < stepper.depth
+	}
+	if stop {
+		stepper.location, stepper.env = location, env
+	}
+	return stop
+}
+
+// Pause stops the running execution at the next statement.
+func (stepper *Stepper) Pause() {
+	stepper.mode = STEP_IN
+}
+
+// Resume the execution stopped in env by mode.
+func (stepper *Stepper) Resume(mode StepMode, env *Environment) {
+	stepper.mode = mode
+	stepper.depth = env.GetDepth()
+}
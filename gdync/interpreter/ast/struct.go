@@ -0,0 +1,178 @@
+package ast
+
+import (
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Structs: a struct definition is the constructor of its structs, which
+// is called like a function with field values in the order of definition.
+//
+
+type StructDefinition struct {
+	typ      *types.StructType
+	location *common.Location
+}
+
+// The caller must check if any field is duplicated.
+func NewStructDefinition(identifier *types.Identifier, fields []*types.Identifier) *StructDefinition {
+	names := []string{}
+	for _, field := range fields {
+		names = append(names, field.GetName())
+	}
+	return &StructDefinition{
+		typ:      types.NewStructType(identifier.GetName(), names),
+		location: identifier.GetLocation(),
+	}
+}
+
+func (definition *StructDefinition) GetName() string {
+	return definition.typ.GetName()
+}
+
+func (definition *StructDefinition) GetLocation() *common.Location {
+	return definition.location
+}
+
+// Fields without argument are null.
+func (definition *StructDefinition) Evaluate(arguments []types.Value,
+	env *Environment) (types.Value, gerror.Error) {
+	if len(arguments) > len(definition.typ.GetFields()) {
+		return nil, gerror.NewArgumentTooManyError(definition.GetName(),
+			len(definition.typ.GetFields()), len(arguments), nil)
+	}
+	return types.NewValue(types.STRUCT_TYPE, definition.typ.New(arguments)), nil
+}
+
+// FieldExpression get the field of a struct.
+type FieldExpression struct {
+	object Expression
+	field  *types.Identifier
+}
+
+func NewFieldExpression(object Expression, field *types.Identifier) *FieldExpression {
+	return &FieldExpression{
+		object: object,
+		field:  field,
+	}
+}
+
+func (expression *FieldExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
+	object, err := expression.object.Evaluate(env)
+	if err != nil {
+		return nil, err
+	}
+	return getField(object, expression.field)
+}
+
+// FieldAssignExpression set the field of a struct, the struct is
+// evaluated before the operand.
+type FieldAssignExpression struct {
+	object  Expression
+	field   *types.Identifier
+	operand Expression
+}
+
+func NewFieldAssignExpression(object Expression, field *types.Identifier,
+	operand Expression) *FieldAssignExpression {
+	return &FieldAssignExpression{
+		object:  object,
+		field:   field,
+		operand: operand,
+	}
+}
+
+func (expression *FieldAssignExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
+	object, err := expression.object.Evaluate(env)
+	if err != nil {
+		return nil, err
+	}
+	value, err := expression.operand.Evaluate(env)
+	if err != nil {
+		return nil, err
+	}
+	if err = setField(object, expression.field, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// FieldIncrementExpression add 1 to or subtract 1 from a numeric field,
+// the value is the new one for prefix form, the old one for postfix form.
+type FieldIncrementExpression struct {
+	object Expression
+	field  *types.Identifier
+	op     string // types.INCREMENT or types.DECREMENT
+	prefix bool
+
+	location *common.Location // operation signal's location
+}
+
+func NewFieldIncrementExpression(object Expression, field *types.Identifier, op string,
+	prefix bool, location *common.Location) *FieldIncrementExpression {
+	return &FieldIncrementExpression{
+		object:   object,
+		field:    field,
+		op:       op,
+		prefix:   prefix,
+		location: location,
+	}
+}
+
+func (expression *FieldIncrementExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
+	object, err := expression.object.Evaluate(env)
+	if err != nil {
+		return nil, err
+	}
+	old, err := getField(object, expression.field)
+	if err != nil {
+		return nil, err
+	}
+	value, err := incrementOperation(expression.op, old, expression.location)
+	if err != nil {
+		return nil, err
+	}
+	if err = setField(object, expression.field, value); err != nil {
+		return nil, err
+	}
+
+	if expression.prefix {
+		return value, nil
+	}
+	return old, nil
+}
+
+func toStruct(object types.Value, field *types.Identifier) (*types.Struct, gerror.Error) {
+	if object.GetType() != types.STRUCT_TYPE {
+		return nil, gerror.NewTypeMismatchError(types.STRUCT_TYPE.String(),
+			object.GetType().String(), object.GetValue(), field.GetLocation())
+	}
+	return object.GetValue().(*types.Struct), nil
+}
+
+func getField(object types.Value, field *types.Identifier) (types.Value, gerror.Error) {
+	s, err := toStruct(object, field)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := s.GetField(field.GetName())
+	if !ok {
+		return nil, gerror.NewFieldNotFoundError(s.GetType().GetName(),
+			field.GetName(), field.GetLocation())
+	}
+	return value, nil
+}
+
+func setField(object types.Value, field *types.Identifier, value types.Value) gerror.Error {
+	s, err := toStruct(object, field)
+	if err != nil {
+		return err
+	}
+	if !s.SetField(field.GetName(), value) {
+		return gerror.NewFieldNotFoundError(s.GetType().GetName(),
+			field.GetName(), field.GetLocation())
+	}
+	return nil
+}
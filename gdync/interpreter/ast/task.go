@@ -0,0 +1,431 @@
+package ast
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Tasks: spawn runs a function call on a goroutine, tasks communicate by
+// channels. The scheduler of an execution counts the tasks not blocked,
+// once all of them are blocked, they're aborted by a DeadlockError.
+//
+
+const deadlockMessage = "All tasks are blocked, deadlock"
+
+// waiter is a task blocked until it's woken by another one.
+type waiter struct {
+	ready chan struct{} // closed once woken
+
+	value types.Value // sent to or received by the task
+	ok    bool        // false if the channel is closed
+	err   gerror.Error
+}
+
+type Scheduler struct {
+	lock    sync.Mutex
+	running int              // tasks not blocked, the main one included
+	live    int              // tasks spawned and not finished
+	blocked map[*waiter]bool // tasks blocked
+	idle    []*waiter        // waiting for all tasks to finish
+	spawned map[*Task]bool   // tasks not waited by script yet
+	group   sync.WaitGroup   // goroutines of tasks
+	nextID  int
+
+	observer sync.Mutex // observers are notified by a task at a time
+}
+
+func NewScheduler() *Scheduler {
+	return &Scheduler{
+		running: 1,
+		blocked: map[*waiter]bool{},
+		spawned: map[*Task]bool{},
+	}
+}
+
+// Block the task of w until it's woken or the context of limiter is done,
+// the scheduler must be locked, which is unlocked then.
+func (scheduler *Scheduler) block(w *waiter, limiter *Limiter) gerror.Error {
+	w.ready = make(chan struct{})
+	scheduler.blocked[w] = true
+	scheduler.running--
+	scheduler.checkDeadlock()
+	scheduler.lock.Unlock()
+
+	select {
+	case <-w.ready:
+		return w.err
+	case <-limiter.done():
+	}
+
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	if !scheduler.blocked[w] {
+		// woken meanwhile
+		return w.err
+	}
+	delete(scheduler.blocked, w)
+	scheduler.running++
+	return limiter.CheckContext(nil)
+}
+
+// Returns false if w isn't blocked anymore, like aborted by the context,
+// the scheduler must be locked.
+func (scheduler *Scheduler) wake(w *waiter) bool {
+	if !scheduler.blocked[w] {
+		return false
+	}
+	delete(scheduler.blocked, w)
+	scheduler.running++
+	close(w.ready)
+	return true
+}
+
+// Abort all blocked tasks if none is running, the scheduler must be locked.
+func (scheduler *Scheduler) checkDeadlock() {
+	if scheduler.running > 0 || len(scheduler.blocked) == 0 {
+		return
+	}
+	for w := range scheduler.blocked {
+		w.err = gerror.NewDeadlockError(deadlockMessage, nil)
+		scheduler.wake(w)
+	}
+}
+
+// Spawn runs a task named name on a goroutine.
+func (scheduler *Scheduler) Spawn(name string, run func() (types.Value, gerror.Error)) *Task {
+	scheduler.lock.Lock()
+	scheduler.nextID++
+	task := &Task{
+		id:        scheduler.nextID,
+		name:      name,
+		scheduler: scheduler,
+	}
+	scheduler.running++
+	scheduler.live++
+	scheduler.spawned[task] = true
+	scheduler.group.Add(1)
+	scheduler.lock.Unlock()
+
+	go func() {
+		defer scheduler.group.Done()
+		value, err := run()
+		scheduler.finish(task, value, err)
+	}()
+	return task
+}
+
+func (scheduler *Scheduler) finish(task *Task, value types.Value, err gerror.Error) {
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+
+	task.done, task.value, task.err = true, value, err
+	for _, w := range task.waiters {
+		scheduler.wake(w)
+	}
+	task.waiters = nil
+
+	scheduler.running--
+	scheduler.live--
+	if scheduler.live == 0 {
+		for _, w := range scheduler.idle {
+			scheduler.wake(w)
+		}
+		scheduler.idle = nil
+	}
+	scheduler.checkDeadlock()
+}
+
+// WaitAll blocks until all tasks spawned finish, returns errors of the
+// tasks not waited by script, or the error of waiting if there is none.
+func (scheduler *Scheduler) WaitAll(limiter *Limiter) []gerror.Error {
+	var waitErr gerror.Error
+	scheduler.lock.Lock()
+	if scheduler.live > 0 {
+		w := &waiter{}
+		scheduler.idle = append(scheduler.idle, w)
+		waitErr = scheduler.block(w, limiter)
+	} else {
+		scheduler.lock.Unlock()
+	}
+	// tasks aborted are finishing
+	scheduler.group.Wait()
+
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	tasks := []*Task{}
+	for task := range scheduler.spawned {
+		if task.err != nil {
+			tasks = append(tasks, task)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].id < tasks[j].id
+	})
+	errs := []gerror.Error{}
+	for _, task := range tasks {
+		errs = append(errs, task.err)
+	}
+	scheduler.spawned = map[*Task]bool{}
+	if len(errs) == 0 && waitErr != nil {
+		errs = append(errs, waitErr)
+	}
+	return errs
+}
+
+// Observers are notified under the lock, a nil scheduler locks nothing.
+func (scheduler *Scheduler) lockObserver() {
+	if scheduler != nil {
+		scheduler.observer.Lock()
+	}
+}
+
+func (scheduler *Scheduler) unlockObserver() {
+	if scheduler != nil {
+		scheduler.observer.Unlock()
+	}
+}
+
+//
+// Task, the value of Task type
+//
+
+type Task struct {
+	id        int
+	name      string // of the function called
+	scheduler *Scheduler
+
+	done    bool
+	value   types.Value
+	err     gerror.Error
+	waiters []*waiter
+}
+
+// Wait blocks until the task finishes, returns its value or error, which
+// isn't reported at the end of execution then.
+func (task *Task) Wait(env *Environment) (types.Value, gerror.Error) {
+	scheduler := task.scheduler
+	scheduler.lock.Lock()
+	delete(scheduler.spawned, task)
+	if task.done {
+		scheduler.lock.Unlock()
+		return task.value, task.err
+	}
+
+	w := &waiter{}
+	task.waiters = append(task.waiters, w)
+	if err := scheduler.block(w, env.GetLimiter()); err != nil {
+		return nil, err
+	}
+	return task.value, task.err
+}
+
+func (task *Task) String() string {
+	return fmt.Sprintf("<Task %d %s>", task.id, task.name)
+}
+
+//
+// Channel, the value of Channel type. A value sent is received by one task
+// in order, a sender is blocked until its value is received or buffered.
+//
+
+type Channel struct {
+	id        int
+	capacity  int
+	scheduler *Scheduler
+
+	buffer    []types.Value
+	closed    bool
+	senders   []*waiter // blocked, with the value to send
+	receivers []*waiter // blocked
+}
+
+// NewChannel creates a channel buffering capacity values.
+func (scheduler *Scheduler) NewChannel(capacity int) *Channel {
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	scheduler.nextID++
+	return &Channel{
+		id:        scheduler.nextID,
+		capacity:  capacity,
+		scheduler: scheduler,
+	}
+}
+
+// Send blocks until value is received or buffered, returns false if the
+// channel is closed.
+func (channel *Channel) Send(value types.Value, env *Environment) (bool, gerror.Error) {
+	scheduler := channel.scheduler
+	scheduler.lock.Lock()
+	if channel.closed {
+		scheduler.lock.Unlock()
+		return false, nil
+	}
+
+	for len(channel.receivers) > 0 {
+		w := channel.receivers[0]
+		channel.receivers = channel.receivers[1:]
+		w.value, w.ok = value, true
+		if scheduler.wake(w) {
+			scheduler.lock.Unlock()
+			return true, nil
+		}
+	}
+	if len(channel.buffer) < channel.capacity {
+		channel.buffer = append(channel.buffer, value)
+		scheduler.lock.Unlock()
+		return true, nil
+	}
+
+	w := &waiter{value: value, ok: true}
+	channel.senders = append(channel.senders, w)
+	if err := scheduler.block(w, env.GetLimiter()); err != nil {
+		return false, err
+	}
+	// a sender woken by close fails
+	return w.ok, nil
+}
+
+// Receive blocks until a value is sent, ok is false if the channel is
+// closed and there is no value anymore.
+func (channel *Channel) Receive(env *Environment) (value types.Value, ok bool, err gerror.Error) {
+	scheduler := channel.scheduler
+	scheduler.lock.Lock()
+
+	if len(channel.buffer) > 0 {
+		value = channel.buffer[0]
+		channel.buffer = channel.buffer[1:]
+		if w := channel.nextSender(); w != nil {
+			channel.buffer = append(channel.buffer, w.value)
+		}
+		scheduler.lock.Unlock()
+		return value, true, nil
+	}
+	if w := channel.nextSender(); w != nil {
+		scheduler.lock.Unlock()
+		return w.value, true, nil
+	}
+	if channel.closed {
+		scheduler.lock.Unlock()
+		return nil, false, nil
+	}
+
+	w := &waiter{}
+	channel.receivers = append(channel.receivers, w)
+	if err := scheduler.block(w, env.GetLimiter()); err != nil {
+		return nil, false, err
+	}
+	return w.value, w.ok, nil
+}
+
+// Wake the first blocked sender, the scheduler must be locked.
+func (channel *Channel) nextSender() *waiter {
+	for len(channel.senders) > 0 {
+		w := channel.senders[0]
+		channel.senders = channel.senders[1:]
+		if channel.scheduler.wake(w) {
+			return w
+		}
+	}
+	return nil
+}
+
+// Close wakes all blocked tasks, receivers get no value and senders
+// fail. Returns false if the channel is closed already.
+func (channel *Channel) Close() bool {
+	scheduler := channel.scheduler
+	scheduler.lock.Lock()
+	defer scheduler.lock.Unlock()
+	if channel.closed {
+		return false
+	}
+
+	channel.closed = true
+	for _, w := range channel.receivers {
+		w.value, w.ok = nil, false
+		scheduler.wake(w)
+	}
+	for _, w := range channel.senders {
+		w.ok = false
+		scheduler.wake(w)
+	}
+	channel.receivers, channel.senders = nil, nil
+	return true
+}
+
+func (channel *Channel) String() string {
+	return fmt.Sprintf("<Channel %d>", channel.id)
+}
+
+//
+// spawn expression
+//
+
+// SpawnExpression runs a function call on a new task, whose value is the
+// task. The function and arguments are evaluated by the spawning task.
+type SpawnExpression struct {
+	call *FunctionCallExpression
+
+	location *common.Location // spawn keyword's location
+}
+
+func NewSpawnExpression(call *FunctionCallExpression, location *common.Location) *SpawnExpression {
+	return &SpawnExpression{
+		call:     call,
+		location: location,
+	}
+}
+
+func (expression *SpawnExpression) Evaluate(env *Environment) (types.Value, gerror.Error) {
+	call := expression.call
+	function, values, err := call.prepare(env)
+	if err != nil {
+		return nil, err
+	}
+	return spawn(env, call.identifier.GetName(), call.location,
+		func(localEnv *Environment) (types.Value, gerror.Error) {
+			return call.invoke(function, values, env, localEnv)
+		})
+}
+
+// Start a task running run in the scope of function name called at
+// location, the scope is entered by the spawning task, whose limiter may be
+// reset once it finishes.
+func spawn(env *Environment, name string, location *common.Location,
+	run func(localEnv *Environment) (types.Value, gerror.Error)) (types.Value, gerror.Error) {
+	if env.scheduler == nil {
+		return nil, gerror.NewInternalError("No scheduler to spawn " + name)
+	}
+	localEnv, err := env.EnterFunction(name, location)
+	if err != nil {
+		err.SetLocation(location)
+		return nil, err
+	}
+	task := env.scheduler.Spawn(name, func() (types.Value, gerror.Error) {
+		value, err := run(localEnv)
+		if err != nil && err.GetLocation() == nil {
+			err.SetLocation(location)
+		}
+		return value, err
+	})
+	return types.NewValue(types.TASK_TYPE, task), nil
+}
+
+// Spawn a task calling function by a new vm.
+func (vm *VM) spawn(function Function, id *types.Identifier, arguments []types.Value,
+	env *Environment, location *common.Location) (types.Value, gerror.Error) {
+	return spawn(env, id.GetName(), location, func(localEnv *Environment) (types.Value, gerror.Error) {
+		localEnv.traceCall(arguments)
+		value, err := NewVM().Call(function, arguments, localEnv)
+		if err != nil {
+			return nil, err
+		}
+		localEnv.traceReturn(value)
+		return value, nil
+	})
+}
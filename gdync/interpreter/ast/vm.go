@@ -0,0 +1,473 @@
+package ast
+
+import (
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Stack based virtual machine executing bytecode with the same semantics
+// as the tree walker. Local variables are kept in the slots of environment,
+// the value stack only holds operands and arguments.
+//
+
+type frame struct {
+	chunk *Chunk
+	pc    int
+	env   *Environment
+	base  int // size of the value stack when the frame is entered
+
+	handlers []*handler
+}
+
+// exception handler installed by a try statement
+type handler struct {
+	target  int
+	stack   int  // size of the value stack when the handler is installed
+	finally bool // gets the error instead of the caught value
+}
+
+// error got by a finally handler, kept on the stack until it's thrown again
+type pendingError struct {
+	err gerror.Error
+}
+
+func (pending *pendingError) GetType() types.ValueType {
+	return nil
+}
+
+func (pending *pendingError) GetValue() interface{} {
+	return pending.err
+}
+
+func (pending *pendingError) SetValue(value interface{}) {
+	pending.err = value.(gerror.Error)
+}
+
+func (pending *pendingError) String() string {
+	return "<pending " + pending.err.GetMessage() + ">"
+}
+
+// iterator of a foreach loop, kept on the stack until the loop ends
+type iteratorValue struct {
+	iterator types.Iterator
+}
+
+func (value *iteratorValue) GetType() types.ValueType {
+	return nil
+}
+
+func (value *iteratorValue) GetValue() interface{} {
+	return value.iterator
+}
+
+func (value *iteratorValue) SetValue(v interface{}) {
+	value.iterator = v.(types.Iterator)
+}
+
+func (value *iteratorValue) String() string {
+	return "<iterator>"
+}
+
+type VM struct {
+	stack  []types.Value
+	frames []*frame
+}
+
+func NewVM() *VM {
+	return &VM{
+		stack:  []types.Value{},
+		frames: []*frame{},
+	}
+}
+
+// Execute compile and run a top level statement in the global scope env,
+// returns the value of an expression statement, null for other statements.
+func (vm *VM) Execute(statement Statement, env *Environment) (types.Value, gerror.Error) {
+	depth := len(vm.frames)
+	vm.frames = append(vm.frames, &frame{
+		chunk: CompileStatement(statement),
+		pc:    0,
+		env:   env,
+		base:  len(vm.stack),
+	})
+	return vm.run(depth)
+}
+
+// Call invoke function with arguments, env is the local scope of the call.
+func (vm *VM) Call(function Function, arguments []types.Value,
+	env *Environment) (types.Value, gerror.Error) {
+	depth := len(vm.frames)
+	value, entered, err := vm.call(function, arguments, env)
+	if err != nil || !entered {
+		return value, err
+	}
+	return vm.run(depth)
+}
+
+// Call a function, a custom function enters a new frame and returns true,
+// a native function is evaluated immediately.
+func (vm *VM) call(function Function, arguments []types.Value,
+	env *Environment) (types.Value, bool, gerror.Error) {
+	if module, ok := function.(*ModuleFunction); ok {
+		function, env = module.function, module.localEnvironment(env)
+	}
+	custom, ok := function.(*CustomFunction)
+	if !ok {
+		value, err := function.Evaluate(arguments, env)
+		if err == nil {
+			err = env.limiter.checkValue(value, nil)
+		}
+		return value, false, err
+	}
+
+	if err := custom.bind(arguments, env); err != nil {
+		return nil, false, err
+	}
+	custom.compile.Do(func() {
+		custom.chunk = CompileFunction(custom)
+	})
+	vm.frames = append(vm.frames, &frame{
+		chunk: custom.chunk,
+		pc:    0,
+		env:   env,
+		base:  len(vm.stack),
+	})
+	return nil, true, nil
+}
+
+func (vm *VM) push(value types.Value) {
+	vm.stack = append(vm.stack, value)
+}
+
+func (vm *VM) pop() types.Value {
+	value := vm.stack[len(vm.stack)-1]
+	vm.stack = vm.stack[:len(vm.stack)-1]
+	return value
+}
+
+// Run frames until the frame at depth returns, all frames
+// above depth are dropped if there is any error not handled.
+func (vm *VM) run(depth int) (types.Value, gerror.Error) {
+	base := vm.frames[depth].base
+	for {
+		value, err := vm.loop(depth)
+		if err == nil {
+			return value, nil
+		}
+		if !vm.handle(err, depth) {
+			vm.locate(err, depth)
+			vm.frames = vm.frames[:depth]
+			vm.stack = vm.stack[:base]
+			return nil, err
+		}
+	}
+}
+
+// Locate an error without location at the innermost call above depth,
+// like the tree walker does.
+func (vm *VM) locate(err gerror.Error, depth int) {
+	if _, ok := err.(*gerror.InternalError); ok || err.GetLocation() != nil {
+		return
+	}
+	if len(vm.frames) > depth+1 {
+		caller := vm.frames[len(vm.frames)-2]
+		err.SetLocation(caller.chunk.locations[caller.pc-1])
+	}
+}
+
+// Transfer control to the latest handler of frames above depth,
+// returns false if there is no handler or err can't be caught.
+func (vm *VM) handle(err gerror.Error, depth int) bool {
+	value, ok := exceptionValue(err)
+	if !ok {
+		return false
+	}
+
+	for i := len(vm.frames) - 1; i >= depth; i-- {
+		frame := vm.frames[i]
+		if len(frame.handlers) == 0 {
+			continue
+		}
+		handler := frame.handlers[len(frame.handlers)-1]
+		frame.handlers = frame.handlers[:len(frame.handlers)-1]
+
+		vm.frames = vm.frames[:i+1]
+		vm.stack = vm.stack[:handler.stack]
+		if handler.finally {
+			vm.push(&pendingError{err: err})
+		} else {
+			vm.push(value)
+		}
+		frame.pc = handler.target
+		return true
+	}
+	return false
+}
+
+func (vm *VM) loop(depth int) (types.Value, gerror.Error) {
+	frame := vm.frames[len(vm.frames)-1]
+
+	for {
+		chunk := frame.chunk
+		pc := frame.pc
+		instruction := chunk.code[pc]
+		frame.pc++
+
+		switch instruction.op {
+		case OP_CONSTANT:
+			vm.push(chunk.constants[instruction.a])
+
+		case OP_POP:
+			vm.pop()
+
+		case OP_GET_GLOBAL:
+			id := chunk.identifiers[instruction.a]
+			variable := frame.env.GetGlobalVariable(id)
+			if variable == nil {
+				return nil, gerror.NewVariableNotFoundError(id.GetName(), id.GetLocation())
+			}
+			vm.push(variable.GetValue())
+
+		case OP_SET_GLOBAL:
+			id := chunk.identifiers[instruction.a]
+			value := vm.stack[len(vm.stack)-1]
+			if variable := frame.env.GetGlobalVariable(id); variable == nil {
+				frame.env.AddGlobalVariable(types.NewVariable(id, value))
+			} else {
+				variable.SetValue(value)
+			}
+			frame.env.traceAssign(id, value)
+
+		case OP_GET_LOCAL:
+			variable := frame.env.GetLocalVariable(instruction.a)
+			if variable == nil {
+				id := chunk.identifiers[instruction.b]
+				return nil, gerror.NewVariableNotFoundError(id.GetName(), id.GetLocation())
+			}
+			vm.push(variable.GetValue())
+
+		case OP_SET_LOCAL:
+			value := vm.stack[len(vm.stack)-1]
+			if variable := frame.env.GetLocalVariable(instruction.a); variable == nil {
+				frame.env.SetLocalVariable(instruction.a,
+					types.NewVariable(chunk.identifiers[instruction.b], value))
+			} else {
+				variable.SetValue(value)
+			}
+			frame.env.traceAssign(chunk.identifiers[instruction.b], value)
+
+		case OP_GLOBAL:
+			id := chunk.identifiers[instruction.b]
+			variable := frame.env.GetGlobalVariable(id)
+			if variable == nil {
+				return nil, gerror.NewVariableNotFoundError(id.GetName(), chunk.locations[pc])
+			}
+			frame.env.SetLocalVariable(instruction.a, variable)
+
+		case OP_ADD, OP_SUBTRACT, OP_MULTIPLY, OP_DIVIDE, OP_MOD:
+			right := vm.pop()
+			left := vm.pop()
+			err := frame.env.limiter.checkOperation(binaryOperators[instruction.op],
+				left, right, chunk.locations[pc])
+			if err != nil {
+				return nil, err
+			}
+			value, err := types.ArithmeticOperation(binaryOperators[instruction.op], left, right)
+			if err != nil {
+				err.SetLocation(chunk.locations[pc])
+				return nil, err
+			}
+			if err := frame.env.limiter.checkValue(value, chunk.locations[pc]); err != nil {
+				return nil, err
+			}
+			vm.push(value)
+
+		case OP_EQUAL, OP_NOT_EQUAL, OP_GT, OP_LT, OP_GTE, OP_LTE:
+			right := vm.pop()
+			left := vm.pop()
+			value, err := types.RelationalOperation(binaryOperators[instruction.op], left, right)
+			if err != nil {
+				err.SetLocation(chunk.locations[pc])
+				return nil, err
+			}
+			vm.push(value)
+
+		case OP_MINUS:
+			value, err := minusOperation(vm.pop(), chunk.locations[pc])
+			if err != nil {
+				return nil, err
+			}
+			vm.push(value)
+
+		case OP_NOT:
+			value, err := notOperation(vm.pop(), chunk.locations[pc])
+			if err != nil {
+				return nil, err
+			}
+			vm.push(value)
+
+		case OP_INCREMENT, OP_DECREMENT:
+			op := types.INCREMENT
+			if instruction.op == OP_DECREMENT {
+				op = types.DECREMENT
+			}
+			value, err := incrementOperation(op, vm.pop(), chunk.locations[pc])
+			if err != nil {
+				return nil, err
+			}
+			vm.push(value)
+
+		case OP_JUMP:
+			frame.pc = instruction.a
+
+		case OP_JUMP_IF_FALSE:
+			condition := vm.pop()
+			if err := checkBool(chunk.keywords[instruction.b], condition,
+				chunk.locations[pc]); err != nil {
+				return nil, err
+			}
+			if !condition.GetValue().(bool) {
+				frame.pc = instruction.a
+			}
+
+		case OP_JUMP_IF_FALSE_OR_POP, OP_JUMP_IF_TRUE_OR_POP:
+			operand := vm.stack[len(vm.stack)-1]
+			err := checkBool(chunk.keywords[instruction.b], operand, chunk.locations[pc])
+			if err != nil {
+				return nil, err
+			}
+			if operand.GetValue().(bool) == (instruction.op == OP_JUMP_IF_TRUE_OR_POP) {
+				frame.pc = instruction.a
+			} else {
+				vm.pop()
+			}
+
+		case OP_CHECK_BOOL:
+			err := checkBool(chunk.keywords[instruction.b], vm.stack[len(vm.stack)-1],
+				chunk.locations[pc])
+			if err != nil {
+				return nil, err
+			}
+
+		case OP_CALL:
+			id := chunk.identifiers[instruction.a]
+			function := frame.env.GetFunction(id)
+			if function == nil {
+				return nil, gerror.NewFunctionNotFoundError(id.GetName(), chunk.locations[pc])
+			}
+
+			// arguments are copied, the stack is reused by the callee
+			arguments := make([]types.Value, instruction.b)
+			copy(arguments, vm.stack[len(vm.stack)-instruction.b:])
+			vm.stack = vm.stack[:len(vm.stack)-instruction.b]
+
+			localEnv, err := frame.env.EnterFunction(id.GetName(), chunk.locations[pc])
+			if err != nil {
+				err.SetLocation(chunk.locations[pc])
+				return nil, err
+			}
+			localEnv.traceCall(arguments)
+			value, entered, err := vm.call(function, arguments, localEnv)
+			if err != nil {
+				if err.GetLocation() == nil {
+					err.SetLocation(chunk.locations[pc])
+				}
+				return nil, err
+			}
+			if entered {
+				frame = vm.frames[len(vm.frames)-1]
+			} else {
+				localEnv.traceReturn(value)
+				vm.push(value)
+			}
+
+		case OP_SPAWN:
+			id := chunk.identifiers[instruction.a]
+			function := frame.env.GetFunction(id)
+			if function == nil {
+				return nil, gerror.NewFunctionNotFoundError(id.GetName(), chunk.locations[pc])
+			}
+
+			arguments := make([]types.Value, instruction.b)
+			copy(arguments, vm.stack[len(vm.stack)-instruction.b:])
+			vm.stack = vm.stack[:len(vm.stack)-instruction.b]
+			task, err := vm.spawn(function, id, arguments, frame.env, chunk.locations[pc])
+			if err != nil {
+				return nil, err
+			}
+			vm.push(task)
+
+		case OP_RETURN:
+			value := vm.pop()
+			vm.stack = vm.stack[:frame.base]
+			vm.frames = vm.frames[:len(vm.frames)-1]
+			if len(vm.frames) == depth {
+				return value, nil
+			}
+			// a frame above depth is entered by OP_CALL
+			frame.env.traceReturn(value)
+			frame = vm.frames[len(vm.frames)-1]
+			vm.push(value)
+
+		case OP_ITERATE:
+			iterator, err := iterate(vm.pop(), chunk.locations[pc])
+			if err != nil {
+				return nil, err
+			}
+			vm.push(&iteratorValue{iterator: iterator})
+
+		case OP_NEXT:
+			iterator := vm.stack[len(vm.stack)-1].(*iteratorValue).iterator
+			if element, ok := iterator.Next(); ok {
+				vm.push(element)
+			} else {
+				frame.pc = instruction.a
+			}
+
+		case OP_MATCH:
+			if MatchCase(vm.stack[len(vm.stack)-1], chunk.constants[instruction.b]) {
+				vm.pop()
+				frame.pc = instruction.a
+			}
+
+		case OP_GET_FIELD:
+			value, err := getField(vm.pop(), chunk.identifiers[instruction.a])
+			if err != nil {
+				return nil, err
+			}
+			vm.push(value)
+
+		case OP_SET_FIELD:
+			value := vm.pop()
+			if err := setField(vm.pop(), chunk.identifiers[instruction.a], value); err != nil {
+				return nil, err
+			}
+			vm.push(value)
+
+		case OP_TRY:
+			frame.handlers = append(frame.handlers, &handler{
+				target:  instruction.a,
+				stack:   len(vm.stack),
+				finally: instruction.b != 0,
+			})
+
+		case OP_END_TRY:
+			frame.handlers = frame.handlers[:len(frame.handlers)-1]
+
+		case OP_THROW:
+			return nil, NewException(vm.pop(), chunk.locations[pc])
+
+		case OP_RETHROW:
+			return nil, vm.pop().(*pendingError).err
+
+		case OP_STEP:
+			if err := frame.env.Step(chunk.locations[pc]); err != nil {
+				return nil, err
+			}
+
+		default:
+			return nil, gerror.NewInternalError("Unknown opcode " + instruction.op.String())
+		}
+	}
+}
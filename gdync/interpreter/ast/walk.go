@@ -0,0 +1,136 @@
+package ast
+
+//
+// Walking the tree: nodes are visited in depth-first order, children in
+// the order they appear in the source.
+//
+
+// Node is a Statement, an Expression, a *CustomFunction or a
+// *StructDefinition.
+type Node interface{}
+
+// Visitor visits the nodes walked. The children of a node are visited by
+// the visitor returned by Visit, which is called with nil after them, they
+// are skipped if it's nil.
+type Visitor interface {
+	Visit(node Node) Visitor
+}
+
+// Walk visits node and its descendants by visitor.
+func Walk(visitor Visitor, node Node) {
+	if visitor = visitor.Visit(node); visitor == nil {
+		return
+	}
+	for _, child := range Children(node) {
+		Walk(visitor, child)
+	}
+	visitor.Visit(nil)
+}
+
+type inspector func(node Node) bool
+
+func (inspect inspector) Visit(node Node) Visitor {
+	if node != nil && inspect(node) {
+		return inspect
+	}
+	return nil
+}
+
+// Inspect calls f for node and its descendants, the children of a node are
+// skipped if f returns false.
+func Inspect(node Node, f func(node Node) bool) {
+	Walk(inspector(f), node)
+}
+
+// Children returns the children of node:
+//
+//	IfStatement: condition, if block, conditions and blocks of elifs, else block
+//	ForStatement: init, condition, post, block
+//	SwitchStatement: value, blocks of cases, default block
+//	TryStatement: try block, catch block, finally block
+//
+// the missing ones are skipped. Other nodes have their expressions followed
+// by their blocks.
+func Children(node Node) []Node {
+	children := []Node{}
+	add := func(nodes ...Node) {
+		for _, node := range nodes {
+			if !isNil(node) {
+				children = append(children, node)
+			}
+		}
+	}
+
+	switch node := node.(type) {
+	case *CustomFunction:
+		add(node.block)
+	case *Block:
+		for _, statement := range node.statements {
+			add(statement)
+		}
+	case *ExpressionStatement:
+		add(node.expression)
+	case *IfStatement:
+		add(node.condition, node.ifBlock)
+		for _, elif := range node.elifBlocks {
+			add(elif.condition, elif.block)
+		}
+		if node.elseBlock != nil {
+			add(node.elseBlock.block)
+		}
+	case *WhileStatement:
+		add(node.condition, node.block)
+	case *ForStatement:
+		add(node.init, node.condition, node.post, node.block)
+	case *ForeachStatement:
+		add(node.collection, node.block)
+	case *SwitchStatement:
+		add(node.value)
+		for _, c := range node.cases {
+			add(c.block)
+		}
+		add(node.defaultBlock)
+	case *ReturnStatement:
+		add(node.returnValue)
+	case *ThrowStatement:
+		add(node.value)
+	case *TryStatement:
+		add(node.tryBlock, node.catchBlock, node.finallyBlock)
+
+	case *AssignExpression:
+		add(node.operand)
+	case *ConditionalExpression:
+		add(node.condition, node.trueBranch, node.falseBranch)
+	case *FieldExpression:
+		add(node.object)
+	case *FieldAssignExpression:
+		add(node.object, node.operand)
+	case *FieldIncrementExpression:
+		add(node.object)
+	case *FunctionCallExpression:
+		for _, argument := range node.arguments {
+			add(argument.expression)
+		}
+	case *SpawnExpression:
+		add(node.call)
+	case binaryOperation:
+		binary := node.getBinary()
+		add(binary.left, binary.right)
+	case unaryOperation:
+		add(node.getUnary().expression)
+	}
+	return children
+}
+
+// Optional children are typed nil pointers, like a missing block.
+func isNil(node Node) bool {
+	switch node := node.(type) {
+	case nil:
+		return true
+	case *Block:
+		return node == nil
+	case *FunctionCallExpression:
+		return node == nil
+	}
+	return false
+}
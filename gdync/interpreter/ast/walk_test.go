@@ -0,0 +1,79 @@
+package ast
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mlmhl/compiler/common"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+// recorder records nodes visited, and the end of their children.
+type recorder struct {
+	events *[]string
+}
+
+func (visitor *recorder) Visit(node Node) Visitor {
+	if node == nil {
+		*visitor.events = append(*visitor.events, "end")
+		return nil
+	}
+	*visitor.events = append(*visitor.events, fmt.Sprintf("%T", node))
+	return visitor
+}
+
+func TestWalk(t *testing.T) {
+	t.Log("Test: walk ...")
+
+	location := common.NewLocation(1, 1, "test")
+	// x = -(1 + y)
+	statement := NewExpressionStatement(NewAssignExpression(NewMinusExpression(
+		NewAddExpression(NewIntegerExpression(1), NewIdentifierExpression(
+			types.NewIdentifier("y", location)), location), location),
+		types.NewIdentifier("x", location)))
+
+	events := []string{}
+	Walk(&recorder{events: &events}, statement)
+	target := []string{
+		"*ast.ExpressionStatement",
+		"*ast.AssignExpression",
+		"*ast.MinusExpression",
+		"*ast.AddExpression",
+		"*ast.IntegerExpression", "end",
+		"*ast.IdentifierExpression", "end",
+		"end", "end", "end", "end",
+	}
+	if strings.Join(events, ",") != strings.Join(target, ",") {
+		t.Fatalf("Wrong events: Wanted %v, got %v", target, events)
+	}
+
+	t.Log("Passed")
+}
+
+func TestInspect(t *testing.T) {
+	t.Log("Test: inspect ...")
+
+	location := common.NewLocation(1, 1, "test")
+	// if (x) { f(1, 2) } without the else block
+	call := NewFunctionCallExpression([]*Argument{
+		NewArgument(NewIntegerExpression(1)), NewArgument(NewIntegerExpression(2)),
+	}, types.NewIdentifier("f", location), location)
+	statement := NewIfStatement(location)
+	statement.SetCondition(NewIdentifierExpression(types.NewIdentifier("x", location)))
+	statement.SetIfBlock(NewBlock([]Statement{NewExpressionStatement(call)}))
+	statement.SetElseBlock(nil, nil)
+
+	count := 0
+	Inspect(statement, func(node Node) bool {
+		count++
+		// arguments of calls are skipped
+		_, ok := node.(*FunctionCallExpression)
+		return !ok
+	})
+	if count != 5 {
+		t.Fatalf("Wrong count: Wanted 5, got %d", count)
+	}
+
+	t.Log("Passed")
+}
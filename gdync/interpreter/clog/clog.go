@@ -30,13 +30,28 @@ func NewLogger(outputFile string) (*Logger, error) {
 		}
 	}
 
-	return &Logger{output}, nil
+	return NewWriterLogger(output), nil
+}
+
+// NewWriterLogger create a Logger which write errors to output
+func NewWriterLogger(output io.Writer) *Logger {
+	return &Logger{output}
+}
+
+// log all errors in a diagnostics list
+func (logger *Logger) Errors(errs []gerror.Error) {
+	for _, err := range errs {
+		if _, ok := err.(*gerror.InternalError); ok {
+			logger.InternalError(err)
+		} else {
+			logger.logError(err)
+		}
+	}
 }
 
 // log a internal error
 func (logger *Logger) InternalError(err gerror.Error) {
 	logger.output.Write([]byte(err.GetMessage() + "\n"))
-	os.Exit(1)
 }
 
 // log a compile error
@@ -51,7 +66,10 @@ func (logger *Logger) RuntimeError(err gerror.Error) {
 
 func (logger *Logger) logError(err gerror.Error) {
 	location := err.GetLocation()
+	if location == nil {
+		logger.output.Write([]byte(err.GetMessage() + "\n"))
+		return
+	}
 	logger.output.Write([]byte(fmt.Sprintf("%s,%d,%d: %s\n", location.GetFileName(),
 		location.GetLine(), location.GetPosition(), err.GetMessage())))
-	os.Exit(1)
 }
\ No newline at end of file
@@ -0,0 +1,279 @@
+package interpreter
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Debugger: an observer which stops the execution at breakpoints or after
+// stepping, then reads commands until the execution is resumed.
+//
+
+const debugPrompt = "(debug) "
+
+const debugHelp = `break [file:]line    set a breakpoint
+clear [file:]line    delete a breakpoint
+continue             run until a breakpoint
+step                 run until the next statement
+next                 run until the next statement of this function
+finish               run until this function returns
+locals               print local variables
+globals              print global variables
+print name           print a variable
+backtrace            print the call stack
+quit                 abort the execution
+`
+
+type debugger struct {
+	scanner *bufio.Scanner
+	output  io.Writer
+	cancel  func() // cancel the execution on quit
+
+	fileName string
+	stepper  *ast.Stepper
+	sources  map[string][]string // lines of the files shown
+
+	detached bool // no more input, run to the end
+}
+
+func newDebugger(fileName string, input io.Reader, output io.Writer, cancel func()) *debugger {
+	return &debugger{
+		scanner: bufio.NewScanner(input),
+		output:  output,
+		cancel:  cancel,
+
+		fileName: fileName,
+		// stop at the first statement, so that breakpoints can be set
+		stepper: ast.NewStepper(ast.STEP_IN),
+		sources: map[string][]string{},
+	}
+}
+
+// Debug execute the file under a command-line debugger, which reads
+// commands from input and writes to output.
+func (interpreter *Interpreter) Debug(file string, input io.Reader, output io.Writer) []gerror.Error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	previous := interpreter.env.GetObserver()
+	interpreter.SetObserver(newDebugger(file, input, output, cancel))
+	defer interpreter.SetObserver(previous)
+
+	return interpreter.InterpretContext(ctx, file)
+}
+
+func (debugger *debugger) OnStep(location *common.Location, env *ast.Environment) gerror.Error {
+	if debugger.detached || !debugger.stepper.Stop(location, env) {
+		return nil
+	}
+
+	debugger.showLocation(location)
+	if !debugger.prompt(location, env) {
+		debugger.cancel()
+		return env.GetLimiter().CheckContext(location)
+	}
+	return nil
+}
+
+// Read commands until the execution is resumed, returns false on quit.
+func (debugger *debugger) prompt(location *common.Location, env *ast.Environment) bool {
+	for {
+		io.WriteString(debugger.output, debugPrompt)
+		if !debugger.scanner.Scan() {
+			io.WriteString(debugger.output, "\n")
+			debugger.detached = true
+			return true
+		}
+
+		fields := strings.Fields(debugger.scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		command, arguments := fields[0], fields[1:]
+
+		switch command {
+		case "break", "b":
+			debugger.setBreakpoint(arguments)
+		case "clear":
+			debugger.clearBreakpoint(arguments)
+		case "continue", "c":
+			debugger.stepper.Resume(ast.STEP_CONTINUE, env)
+			return true
+		case "step", "s":
+			debugger.stepper.Resume(ast.STEP_IN, env)
+			return true
+		case "next", "n":
+			debugger.stepper.Resume(ast.STEP_OVER, env)
+			return true
+		case "finish", "f":
+			debugger.stepper.Resume(ast.STEP_OUT, env)
+			return true
+		case "locals":
+			debugger.printVariables(env.GetLocalVariables())
+		case "globals":
+			debugger.printVariables(GlobalVariables(env))
+		case "print", "p":
+			debugger.printVariable(arguments, env)
+		case "backtrace", "bt":
+			debugger.backtrace(location, env)
+		case "quit", "q":
+			return false
+		case "help", "h":
+			io.WriteString(debugger.output, debugHelp)
+		default:
+			fmt.Fprintf(debugger.output, "Unknown command %s, try help\n", command)
+		}
+	}
+}
+
+// Returns the file and line of a breakpoint, the file is the debugged
+// one if it's omitted.
+func (debugger *debugger) parseBreakpoint(arguments []string) (string, int, bool) {
+	if len(arguments) != 1 {
+		io.WriteString(debugger.output, "Breakpoint should be [file:]line\n")
+		return "", 0, false
+	}
+
+	file, line := debugger.fileName, arguments[0]
+	if i := strings.LastIndex(line, ":"); i >= 0 {
+		file, line = line[:i], line[i+1:]
+	}
+	n, err := strconv.Atoi(line)
+	if err != nil || n <= 0 {
+		fmt.Fprintf(debugger.output, "Invalid line %s\n", line)
+		return "", 0, false
+	}
+	return file, n, true
+}
+
+func (debugger *debugger) setBreakpoint(arguments []string) {
+	if file, line, ok := debugger.parseBreakpoint(arguments); ok {
+		debugger.stepper.AddBreakpoint(file, line)
+		fmt.Fprintf(debugger.output, "Breakpoint at %s:%d\n", file, line)
+	}
+}
+
+func (debugger *debugger) clearBreakpoint(arguments []string) {
+	file, line, ok := debugger.parseBreakpoint(arguments)
+	if !ok {
+		return
+	}
+	if debugger.stepper.RemoveBreakpoint(file, line) {
+		fmt.Fprintf(debugger.output, "Deleted breakpoint at %s:%d\n", file, line)
+	} else {
+		fmt.Fprintf(debugger.output, "No breakpoint at %s:%d\n", file, line)
+	}
+}
+
+func (debugger *debugger) printVariables(variables []*types.Variable) {
+	if len(variables) == 0 {
+		io.WriteString(debugger.output, "No variables\n")
+		return
+	}
+	for _, variable := range variables {
+		fmt.Fprintf(debugger.output, "%s = %s\n", variable.GetName(), FormatValue(variable.GetValue()))
+	}
+}
+
+// GlobalVariables returns global variables of env sorted by name,
+// the standard files are omitted.
+func GlobalVariables(env *ast.Environment) []*types.Variable {
+	names := []string{}
+	for name := range env.GetGlobalVariables() {
+		if name != types.STDIN && name != types.STDOUT && name != types.STDERR {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	variables := []*types.Variable{}
+	for _, name := range names {
+		variables = append(variables, env.GetGlobalVariables()[name])
+	}
+	return variables
+}
+
+// A local variable hides the global one with the same name.
+func (debugger *debugger) printVariable(arguments []string, env *ast.Environment) {
+	if len(arguments) != 1 {
+		io.WriteString(debugger.output, "print should be followed by a variable name\n")
+		return
+	}
+
+	name := arguments[0]
+	for _, variable := range env.GetLocalVariables() {
+		if variable.GetName() == name {
+			fmt.Fprintf(debugger.output, "%s = %s\n", name, FormatValue(variable.GetValue()))
+			return
+		}
+	}
+	if variable, ok := env.GetGlobalVariables()[name]; ok {
+		fmt.Fprintf(debugger.output, "%s = %s\n", name, FormatValue(variable.GetValue()))
+		return
+	}
+	fmt.Fprintf(debugger.output, "Undefined variable %s\n", name)
+}
+
+// Each frame is shown at its current location, which is the call location
+// of the frame above it.
+func (debugger *debugger) backtrace(location *common.Location, env *ast.Environment) {
+	for i := 0; env != nil; i++ {
+		name := env.GetFunctionName()
+		if name == "" {
+			name = "<global>"
+		}
+		fmt.Fprintf(debugger.output, "#%d %s at %s\n", i, name, formatLocation(location))
+		location, env = env.GetCallLocation(), env.GetCaller()
+	}
+}
+
+func (debugger *debugger) showLocation(location *common.Location) {
+	fmt.Fprintf(debugger.output, "Stopped at %s\n", formatLocation(location))
+	if line, ok := debugger.sourceLine(location); ok {
+		fmt.Fprintf(debugger.output, "%4d  %s\n", location.GetLine(), line)
+	}
+}
+
+// Returns the source line at location, ok is false if it can't be read.
+func (debugger *debugger) sourceLine(location *common.Location) (string, bool) {
+	lines, ok := debugger.sources[location.GetFileName()]
+	if !ok {
+		if content, err := os.ReadFile(location.GetFileName()); err == nil {
+			lines = strings.Split(string(content), "\n")
+		}
+		debugger.sources[location.GetFileName()] = lines
+	}
+
+	if location.GetLine() < 1 || location.GetLine() > len(lines) {
+		return "", false
+	}
+	return strings.TrimSpace(lines[location.GetLine()-1]), true
+}
+
+func formatLocation(location *common.Location) string {
+	if location == nil {
+		return "<host>"
+	}
+	return fmt.Sprintf("%s:%d", location.GetFileName(), location.GetLine())
+}
+
+// FormatValue returns the text of value shown to users, strings are
+// quoted, so that they can be told from other values.
+func FormatValue(value types.Value) string {
+	if value.GetType() == types.STRING_TYPE {
+		return strconv.Quote(value.GetValue().(string))
+	}
+	return value.String()
+}
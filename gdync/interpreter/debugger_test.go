@@ -0,0 +1,173 @@
+package interpreter
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+)
+
+var debuggedScript = []string{
+	"def fib(n) {",
+	"    if (n < 2) {",
+	"        return n",
+	"    }",
+	"    return fib(n - 1) + fib(n - 2)",
+	"}",
+	"total = 0",
+	"for (i = 0; i < 3; i++) {",
+	"    total = total + fib(i)",
+	"}",
+	"Printf(\"%d\\n\", total)",
+}
+
+func TestDebugger(t *testing.T) {
+	fileName := writeScript(strings.Join(debuggedScript, "\n"), t)
+	input := strings.Join([]string{
+		"break 3",
+		"continue",
+		"backtrace",
+		"locals",
+		"finish",
+		"next",
+		"globals",
+		"print total",
+		"clear 3",
+		"step",
+		"undefined",
+		"continue",
+	}, "\n")
+	target := strings.Join([]string{
+		"Stopped at FILE:7",
+		"   7  total = 0",
+		"(debug) Breakpoint at FILE:3",
+		"(debug) Stopped at FILE:3",
+		"   3  return n",
+		"(debug) #0 fib at FILE:3",
+		"#1 <global> at FILE:9",
+		"(debug) n = 0",
+		"(debug) Stopped at FILE:8",
+		"   8  for (i = 0; i < 3; i++) {",
+		"(debug) Stopped at FILE:9",
+		"   9  total = total + fib(i)",
+		"(debug) i = 1",
+		"total = 0",
+		"(debug) total = 0",
+		"(debug) Deleted breakpoint at FILE:3",
+		"(debug) Stopped at FILE:2",
+		"   2  if (n < 2) {",
+		"(debug) Unknown command undefined, try help",
+		"(debug) 2",
+		"",
+	}, "\n")
+	target = strings.Replace(target, "FILE", fileName, -1)
+
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: debugger, vm %v ...", vm)
+
+		output := &bytes.Buffer{}
+		inter := NewInterpreter()
+		inter.UseVM(vm)
+		inter.SetStdout(output)
+		if errs := inter.Debug(fileName, strings.NewReader(input), output); len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+		if output.String() != target {
+			t.Fatalf("Wrong output: Wanted\n%s\ngot\n%s", target, output.String())
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestDebuggerQuit(t *testing.T) {
+	t.Log("Test: debugger quit ...")
+
+	fileName := writeScript(strings.Join(debuggedScript, "\n"), t)
+	output := &bytes.Buffer{}
+	inter := NewInterpreter()
+	inter.SetStdout(output)
+	errs := inter.Debug(fileName, strings.NewReader("step\nquit\n"), output)
+	if len(errs) != 1 {
+		t.Fatalf("Wrong error count: Wanted 1, got %d", len(errs))
+	}
+	if _, ok := errs[0].(*gerror.LimitExceededError); !ok {
+		t.Fatalf("Wrong error: %s", errs[0].GetMessage())
+	}
+	if strings.Contains(output.String(), "2\n") {
+		t.Fatalf("Script isn't aborted: %s", output.String())
+	}
+
+	// the debugger is removed once the debugging ends
+	if errs = inter.Interpret(fileName); len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+
+	t.Log("Passed")
+}
+
+func TestDebuggerModuleBreakpoint(t *testing.T) {
+	dir := writeModules(map[string][]string{
+		"lib/m.gd": {
+			"def add(a, b) {",
+			"    c = a + b",
+			"    return c",
+			"}",
+		},
+		"main.gd": {
+			"import \"m.gd\"",
+			"x = m.add(1, 2)",
+			"Printf(\"%d\\n\", x)",
+		},
+	}, t)
+	// breakpoints are given relative to the working directory
+	t.Chdir(dir)
+	input := strings.Join([]string{
+		"break lib/m.gd:2",
+		"break ./lib/../lib/m.gd:3",
+		"continue",
+		"locals",
+		"continue",
+		"locals",
+		"continue",
+	}, "\n")
+	target := strings.Join([]string{
+		"Stopped at DIR/main.gd:1",
+		"   1  import \"m.gd\"",
+		"(debug) Breakpoint at lib/m.gd:2",
+		"(debug) Breakpoint at ./lib/../lib/m.gd:3",
+		"(debug) Stopped at DIR/lib/m.gd:2",
+		"   2  c = a + b",
+		"(debug) a = 1",
+		"b = 2",
+		"(debug) Stopped at DIR/lib/m.gd:3",
+		"   3  return c",
+		"(debug) a = 1",
+		"b = 2",
+		"c = 3",
+		"(debug) 3",
+		"",
+	}, "\n")
+	target = strings.Replace(target, "DIR", dir, -1)
+
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: breakpoint in module, vm %v ...", vm)
+
+		output := &bytes.Buffer{}
+		inter := NewInterpreter()
+		inter.UseVM(vm)
+		inter.SetStdout(output)
+		inter.SetSearchPath([]string{filepath.Join(dir, "lib")})
+		errs := inter.Debug(filepath.Join(dir, "main.gd"), strings.NewReader(input), output)
+		if len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+		if output.String() != target {
+			t.Fatalf("Wrong output: Wanted\n%s\ngot\n%s", target, output.String())
+		}
+
+		t.Log("Passed")
+	}
+}
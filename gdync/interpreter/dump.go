@@ -0,0 +1,31 @@
+package interpreter
+
+import (
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+)
+
+// Parse parse the file without executing it, returns the structs,
+// functions and statements defined in top level, in this order. Modules
+// imported are compiled but never executed, an import is an
+// ImportStatement among the statements.
+func (interpreter *Interpreter) Parse(file string) ([]ast.Node, []gerror.Error) {
+	if err := interpreter.parser.Parse(file); err != nil {
+		return nil, []gerror.Error{err}
+	}
+	interpreter.fileName = file
+	defer interpreter.enterFile()()
+
+	interpreter.create()
+	nodes := []ast.Node{}
+	for _, definition := range interpreter.structs {
+		nodes = append(nodes, definition)
+	}
+	for _, function := range interpreter.functions {
+		nodes = append(nodes, function)
+	}
+	for _, statement := range interpreter.statements {
+		nodes = append(nodes, statement)
+	}
+	return nodes, interpreter.errors
+}
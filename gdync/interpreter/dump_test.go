@@ -0,0 +1,190 @@
+package interpreter
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+)
+
+var dumpedScript = []string{
+	"struct Point { x, y }",
+	"def move(p, dx) {",
+	"    global count",
+	"    p.x = p.x + dx",
+	"    return p",
+	"}",
+	"count = 0",
+	"if (count > 1) {",
+	"    count++",
+	"} elif (count == 0) {",
+	"    count = 1",
+	"} else {",
+	"    throw \"never\"",
+	"}",
+	"for (v in \"ab\") {",
+	"    t = spawn move(Point(1, 2), 1)",
+	"}",
+}
+
+func TestDumpText(t *testing.T) {
+	t.Log("Test: dump ast as text ...")
+
+	fileName := writeScript(strings.Join(dumpedScript, "\n"), t)
+	nodes, errs := NewInterpreter().Parse(fileName)
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+	output := &bytes.Buffer{}
+	if err := ast.WriteText(output, ast.Dump(nodes...)); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	target := strings.Join([]string{
+		"StructDefinition 1:7 fields=x, y name=Point",
+		"CustomFunction 2:4 name=move parameters=p, dx",
+		"  Block",
+		"    GlobalStatement 3:4 names=count",
+		"    ExpressionStatement 4:4",
+		"      FieldAssignExpression 4:6 field=x",
+		"        IdentifierExpression 4:4 name=p",
+		"        AddExpression 4:14",
+		"          FieldExpression 4:12 field=x",
+		"            IdentifierExpression 4:10 name=p",
+		"          IdentifierExpression 4:16 name=dx",
+		"    ReturnStatement 5:4",
+		"      IdentifierExpression 5:11 name=p",
+		"ExpressionStatement 7:0",
+		"  AssignExpression 7:0 name=count",
+		"    IntegerExpression value=0",
+		"IfStatement 8:0 elif=1 else=true",
+		"  GTExpression 8:10",
+		"    IdentifierExpression 8:4 name=count",
+		"    IntegerExpression value=1",
+		"  Block",
+		"    ExpressionStatement 9:4",
+		"      IncrementExpression 9:9 name=count op=Increment prefix=false",
+		"  EqualExpression 10:14",
+		"    IdentifierExpression 10:8 name=count",
+		"    IntegerExpression value=0",
+		"  Block",
+		"    ExpressionStatement 11:4",
+		"      AssignExpression 11:4 name=count",
+		"        IntegerExpression value=1",
+		"  Block",
+		"    ThrowStatement 13:4",
+		"      StringExpression value=\"never\"",
+		"ForeachStatement 15:0 variable=v",
+		"  StringExpression value=\"ab\"",
+		"  Block",
+		"    ExpressionStatement 16:4",
+		"      AssignExpression 16:4 name=t",
+		"        SpawnExpression 16:8",
+		"          FunctionCallExpression 16:14 name=move",
+		"            FunctionCallExpression 16:19 name=Point",
+		"              IntegerExpression value=1",
+		"              IntegerExpression value=2",
+		"            IntegerExpression value=1",
+		"",
+	}, "\n")
+	if output.String() != target {
+		t.Fatalf("Wrong dump: Wanted\n%s\ngot\n%s", target, output.String())
+	}
+
+	t.Log("Passed")
+}
+
+func TestDumpJSON(t *testing.T) {
+	t.Log("Test: dump ast as json ...")
+
+	fileName := writeScript(strings.Join(dumpedScript, "\n"), t)
+	nodes, errs := NewInterpreter().Parse(fileName)
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+	output := &bytes.Buffer{}
+	if err := ast.WriteJSON(output, ast.Dump(nodes...)); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	dumped := []*ast.DumpNode{}
+	if err := json.Unmarshal(output.Bytes(), &dumped); err != nil {
+		t.Fatalf("Invalid json: %s", err.Error())
+	}
+	if len(dumped) != 5 {
+		t.Fatalf("Wrong node count: Wanted 5, got %d", len(dumped))
+	}
+	function := dumped[1]
+	if function.Kind != "CustomFunction" || function.Attributes["name"] != "move" ||
+		function.Location.Line != 2 || len(function.Children) != 1 {
+		t.Fatalf("Wrong function: %v", function)
+	}
+	literal := dumped[2].Children[0].Children[0]
+	if literal.Kind != "IntegerExpression" || literal.Attributes["value"] != "0" ||
+		literal.Location != nil {
+		t.Fatalf("Wrong literal: %v", literal)
+	}
+
+	t.Log("Passed")
+}
+
+func TestParseError(t *testing.T) {
+	t.Log("Test: parse error ...")
+
+	fileName := writeScript("x = (1 + 2\ny = 3", t)
+	_, errs := NewInterpreter().Parse(fileName)
+	if len(errs) != 1 {
+		t.Fatalf("Wrong error count: Wanted 1, got %d", len(errs))
+	}
+	// the missing parenthesis is found at the next line
+	if errs[0].GetLocation().GetLine() != 2 {
+		t.Fatalf("Wrong error line: Wanted 2, got %d", errs[0].GetLocation().GetLine())
+	}
+
+	t.Log("Passed")
+}
+
+func TestDumpImport(t *testing.T) {
+	t.Log("Test: dump imports without executing modules ...")
+
+	dir := writeModules(map[string][]string{
+		"util.gd": {
+			"Printf(\"util\\n\")",
+			"x = 1",
+		},
+		"main.gd": {
+			"import \"util.gd\" as u",
+			"y = u.x",
+		},
+	}, t)
+	stdout := &strings.Builder{}
+	inter := NewInterpreter()
+	inter.SetStdout(stdout)
+	nodes, errs := inter.Parse(filepath.Join(dir, "main.gd"))
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("Module shouldn't be executed: %s", stdout.String())
+	}
+
+	output := &bytes.Buffer{}
+	if err := ast.WriteText(output, ast.Dump(nodes...)); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+	target := strings.Join([]string{
+		"ImportStatement 1:0 namespace=u path=\"util.gd\"",
+		"ExpressionStatement 2:0",
+		"  AssignExpression 2:0 name=y",
+		"    IdentifierExpression 2:4 name=u.x",
+		"",
+	}, "\n")
+	if output.String() != target {
+		t.Fatalf("Wrong dump: Wanted\n%s\ngot\n%s", target, output.String())
+	}
+
+	t.Log("Passed")
+}
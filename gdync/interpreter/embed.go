@@ -0,0 +1,94 @@
+package interpreter
+
+import (
+	"context"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Embedding API: a host program can expose Go functions to scripts,
+// exchange global variables with them and call script functions.
+//
+
+const hostFileName = "<host>"
+
+// RegisterFunction expose a Go function to scripts under name. Arguments
+// are converted to the parameter types of function, which may return
+// nothing, a value, or a value followed by an error.
+func (interpreter *Interpreter) RegisterFunction(name string, function interface{}) gerror.Error {
+	native, err := ast.NewNativeFunction(name, function)
+	if err != nil {
+		return err
+	}
+	interpreter.env.AddFunction(native)
+	return nil
+}
+
+// SetGlobal assign value to the global variable name, the variable is
+// created if it doesn't exist.
+func (interpreter *Interpreter) SetGlobal(name string, value interface{}) gerror.Error {
+	v, err := types.FromGo(value)
+	if err != nil {
+		return err
+	}
+
+	id := types.NewIdentifier(name, common.NewLocation(0, 0, hostFileName))
+	if variable := interpreter.env.GetGlobalVariable(id); variable != nil {
+		variable.SetValue(v)
+	} else {
+		interpreter.env.AddGlobalVariable(types.NewVariable(id, v))
+	}
+	return nil
+}
+
+// GetGlobal return the Go value of the global variable name, the second
+// result reports whether the variable exists.
+func (interpreter *Interpreter) GetGlobal(name string) (interface{}, bool) {
+	variable := interpreter.env.GetGlobalVariable(types.NewIdentifier(name, nil))
+	if variable == nil {
+		return nil, false
+	}
+	return types.ToGo(variable.GetValue()), true
+}
+
+// Call invoke the function name, which is either defined by scripts
+// or registered by host, and return the Go value of its result.
+func (interpreter *Interpreter) Call(name string, arguments ...interface{}) (interface{}, gerror.Error) {
+	function := interpreter.env.GetFunction(types.NewIdentifier(name, nil))
+	if function == nil {
+		return nil, gerror.NewFunctionNotFoundError(name, nil)
+	}
+
+	values := []types.Value{}
+	for _, argument := range arguments {
+		value, err := types.FromGo(argument)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	if interpreter.env.GetLimiter() == nil {
+		// not called back during an interpretation
+		interpreter.env.SetLimiter(ast.NewLimiter(context.Background(), interpreter.limits))
+		defer interpreter.env.SetLimiter(nil)
+	}
+	env, err := interpreter.env.EnterFunction(name, nil)
+	if err != nil {
+		return nil, err
+	}
+	var result types.Value
+	if interpreter.vm != nil {
+		result, err = interpreter.vm.Call(function, values, env)
+	} else {
+		result, err = function.Evaluate(values, env)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return types.ToGo(result), nil
+}
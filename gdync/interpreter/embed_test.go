@@ -0,0 +1,184 @@
+package interpreter
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+)
+
+func TestRegisterFunction(t *testing.T) {
+	t.Log("Test: register function ...")
+
+	inter := NewInterpreter()
+	if err := inter.RegisterFunction("add", func(a, b int) int { return a + b }); err != nil {
+		t.Fatalf("Can't register function: %s", err.GetMessage())
+	}
+	if err := inter.RegisterFunction("fail", func(message string) (int, error) {
+		return 0, errors.New(message)
+	}); err != nil {
+		t.Fatalf("Can't register function: %s", err.GetMessage())
+	}
+	if err := inter.RegisterFunction("bad", 1); err == nil {
+		t.Fatalf("Register a non-function should fail")
+	}
+
+	errs := inter.InterpretReader("script", strings.NewReader("x = add(1, 2)\n"))
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+	if x, _ := inter.GetGlobal("x"); x != int64(3) {
+		t.Fatalf("Wrong result: Wanted 3, got %v", x)
+	}
+
+	errs = inter.InterpretReader("script", strings.NewReader("add(1, \"a\")\n"))
+	if len(errs) != 1 {
+		t.Fatalf("Wrong error count: Wanted 1, got %d", len(errs))
+	}
+	if _, ok := errs[0].(*gerror.TypeMismatchError); !ok {
+		t.Fatalf("Wrong error: %s", errs[0].GetMessage())
+	}
+
+	errs = inter.InterpretReader("script", strings.NewReader("fail(\"boom\")\n"))
+	if len(errs) != 1 {
+		t.Fatalf("Wrong error count: Wanted 1, got %d", len(errs))
+	}
+	if errs[0].GetMessage() != "Error in native function fail: boom" {
+		t.Fatalf("Wrong error: %s", errs[0].GetMessage())
+	}
+	if errs[0].GetLocation() == nil || errs[0].GetLocation().GetLine() != 1 {
+		t.Fatalf("Native function error should be located at the call")
+	}
+
+	t.Log("Passed")
+}
+
+func TestGlobals(t *testing.T) {
+	t.Log("Test: set and get globals ...")
+
+	inter := NewInterpreter()
+	if err := inter.SetGlobal("limit", 10); err != nil {
+		t.Fatalf("Can't set global: %s", err.GetMessage())
+	}
+	if err := inter.SetGlobal("bad", map[string]int{}); err == nil {
+		t.Fatalf("Set an unsupported value should fail")
+	}
+
+	errs := inter.InterpretReader("script", strings.NewReader("limit = limit * 2.5\n"))
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+	if limit, ok := inter.GetGlobal("limit"); !ok || limit != 25.0 {
+		t.Fatalf("Wrong global: Wanted 25, got %v", limit)
+	}
+	if _, ok := inter.GetGlobal("missing"); ok {
+		t.Fatalf("Undefined global should not exist")
+	}
+
+	t.Log("Passed")
+}
+
+func TestCall(t *testing.T) {
+	t.Log("Test: call script function ...")
+
+	inter := NewInterpreter()
+	errs := inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"def offset(x) {",
+		"    return 100 + x",
+		"}",
+	}, "\n")))
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+
+	result, err := inter.Call("offset", 5)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.GetMessage())
+	}
+	if result != int64(105) {
+		t.Fatalf("Wrong result: Wanted 105, got %v", result)
+	}
+
+	if _, err = inter.Call("offset"); err == nil {
+		t.Fatalf("Call with too few arguments should fail")
+	}
+	if _, err = inter.Call("missing"); err == nil {
+		t.Fatalf("Call an undefined function should fail")
+	}
+
+	t.Log("Passed")
+}
+
+func TestConvert(t *testing.T) {
+	t.Log("Test: convert arguments out of range ...")
+
+	inter := NewInterpreter()
+	inter.RegisterFunction("small", func(x int8) int8 { return x })
+	inter.RegisterFunction("unsigned", func(x uint) uint { return x })
+	inter.RegisterFunction("large", func(x uint64) uint64 { return x })
+	inter.RegisterFunction("single", func(x float32) float32 { return x })
+
+	for _, source := range []string{
+		"small(300)",
+		"small(-129)",
+		"unsigned(-1)",
+		"large(toInt(\"18446744073709551616\"))",
+		"single(toFloat(\"1e300\"))",
+	} {
+		errs := inter.InterpretReader("script", strings.NewReader(source))
+		if len(errs) != 1 {
+			t.Fatalf("Wrong error count of %s: Wanted 1, got %d", source, len(errs))
+		}
+		if _, ok := errs[0].(*gerror.TypeMismatchError); !ok {
+			t.Fatalf("Wrong error of %s: %s", source, errs[0].GetMessage())
+		}
+	}
+
+	errs := inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"a = small(-128)",
+		"b = large(toInt(\"18446744073709551615\"))",
+		"c = single(2)",
+	}, "\n")))
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+	for name, target := range map[string]string{
+		"a": "-128",
+		"b": "18446744073709551615",
+		"c": "2",
+	} {
+		value, _ := inter.GetGlobal(name)
+		if result := fmt.Sprint(value); result != target {
+			t.Fatalf("Wrong %s: Wanted %s, got %s", name, target, result)
+		}
+	}
+
+	t.Log("Passed")
+
+	t.Log("Test: convert slices ...")
+
+	inter.RegisterFunction("fields", func(s string) []string { return strings.Fields(s) })
+	inter.RegisterFunction("pair", func() [2]int { return [2]int{1, 2} })
+	errs = inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"words = fields(\" a b  c \")",
+		"n = len(words)",
+		"p = pair()",
+	}, "\n")))
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+	if n, _ := inter.GetGlobal("n"); n != int64(3) {
+		t.Fatalf("Wrong length: Wanted 3, got %v", n)
+	}
+	if words, _ := inter.GetGlobal("words"); !reflect.DeepEqual(words, []interface{}{"a", "b", "c"}) {
+		t.Fatalf("Wrong words: Wanted [a b c], got %v", words)
+	}
+	if p, _ := inter.GetGlobal("p"); !reflect.DeepEqual(p, []interface{}{int64(1), int64(2)}) {
+		t.Fatalf("Wrong pair: Wanted [1 2], got %v", p)
+	}
+
+	t.Log("Passed")
+}
@@ -2,6 +2,9 @@ package interpreter
 
 import (
 	"fmt"
+	"math/big"
+
+	"github.com/mlmhl/compiler/common"
 	gerror "github.com/mlmhl/compiler/gdync/errors"
 	"github.com/mlmhl/compiler/gdync/interpreter/ast"
 	"github.com/mlmhl/compiler/gdync/interpreter/types"
@@ -12,35 +15,43 @@ import (
 // Construction expression based on Operator-Precedence Parsing
 //
 // Operator precedence as follows:
-// -(minus) !
+// ++ --(postfix)
+// ++ --(prefix) -(minus) !
 // * / %
 // + -
 // > >= < <=
 // == !=
 // &&
 // ||
-// =
+// ?:
+// = += -= *= /= %=
 //
 
 func (interpreter *Interpreter) expression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
+
+	expression := interpreter.assignExpression()
+
+	// Skip semicolon if exist
+	tok, err := parser.Next()
+	if err != nil {
+		interpreter.compileError(err)
+	}
+	if tok.GetType() != token.SEMICOLON_ID {
+		parser.RollBack(tok)
+	}
+
+	return expression
+}
+
+func (interpreter *Interpreter) assignExpression() ast.Expression {
+	parser := interpreter.parser
 
 	var tok *token.Token
 	var err gerror.Error
 
-	defer func() {
-		// Skip semicolon if exist
-		if tok, err = parser.Next(); err != nil {
-			logger.CompileError(err)
-		}
-		if tok.GetType() != token.SEMICOLON_ID {
-			parser.RollBack(tok)
-		}
-	}()
-
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 
 	if tok.GetType() == token.FINISHED_ID {
@@ -49,53 +60,119 @@ func (interpreter *Interpreter) expression() ast.Expression {
 	}
 
 	if tok.GetType() == token.IDENTIFIER_ID {
+		identifier, consumed := interpreter.qualifiedIdentifier(tok)
+		fields, dots := interpreter.fieldChain()
+		consumed = append(consumed, dots...)
 		var nToken *token.Token
 		if nToken, err = parser.Next(); err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if nToken.GetType() == token.ASSIGN_ID {
+			if len(fields) > 0 {
+				// create a field assign expression
+				object := interpreter.fieldAccess(ast.NewIdentifierExpression(identifier), fields[:len(fields)-1])
+				return ast.NewFieldAssignExpression(object, fields[len(fields)-1], interpreter.expression())
+			}
 			// create a assign expression
-			return ast.NewAssignExpression(interpreter.expression(),
-				types.NewIdentifier(tok.GetValue().(string), tok.GetLocation()))
+			return ast.NewAssignExpression(interpreter.expression(), identifier)
+		} else if create, ok := compoundAssignOperators[nToken.GetType()]; ok {
+			// x op= y is the same as x = x op y
+			expression := interpreter.expression()
+			interpreter.checkOperands(nToken, expression)
+			if len(fields) > 0 {
+				object := interpreter.fieldAccess(ast.NewIdentifierExpression(identifier), fields[:len(fields)-1])
+				field := fields[len(fields)-1]
+				return ast.NewFieldAssignExpression(object, field, create(ast.NewFieldExpression(object, field),
+					expression, nToken.GetLocation()))
+			}
+			return ast.NewAssignExpression(create(ast.NewIdentifierExpression(identifier),
+				expression, nToken.GetLocation()), identifier)
 		} else {
 			parser.RollBack(nToken)
+			for i := len(consumed) - 1; i >= 0; i-- {
+				parser.RollBack(consumed[i])
+			}
 			parser.RollBack(tok)
 		}
 	} else {
 		parser.RollBack(tok)
 	}
 
-	return interpreter.logicalOrExpression()
+	return interpreter.conditionalExpression()
+
+}
+
+type binaryCreator func(left, right ast.Expression, location *common.Location) ast.Expression
+
+var compoundAssignOperators map[int]binaryCreator = map[int]binaryCreator{
+	token.ADD_ASSIGN_ID: func(left, right ast.Expression, location *common.Location) ast.Expression {
+		return ast.NewAddExpression(left, right, location)
+	},
+	token.SUBTRACT_ASSIGN_ID: func(left, right ast.Expression, location *common.Location) ast.Expression {
+		return ast.NewSubtractExpression(left, right, location)
+	},
+	token.MULTIPLY_ASSIGN_ID: func(left, right ast.Expression, location *common.Location) ast.Expression {
+		return ast.NewMultiplyExpression(left, right, location)
+	},
+	token.DIVIDE_ASSIGN_ID: func(left, right ast.Expression, location *common.Location) ast.Expression {
+		return ast.NewDivideExpression(left, right, location)
+	},
+	token.MOD_ASSIGN_ID: func(left, right ast.Expression, location *common.Location) ast.Expression {
+		return ast.NewModExpression(left, right, location)
+	},
+}
+
+func (interpreter *Interpreter) conditionalExpression() ast.Expression {
+	parser := interpreter.parser
+
+	condition := interpreter.logicalOrExpression()
+
+	tok, err := parser.Next()
+	if err != nil {
+		interpreter.compileError(err)
+	}
+	if tok.GetType() != token.QUESTION_ID {
+		parser.RollBack(tok)
+		return condition
+	}
+
+	trueBranch := interpreter.conditionalExpression()
+
+	colon, err := parser.Next()
+	if err != nil {
+		interpreter.compileError(err)
+	}
+	if colon.GetType() != token.COLON_ID {
+		parser.RollBack(colon)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Conditional expression should separated by %s, not %s",
+				token.GetDescription(token.COLON_ID), token.GetDescription(colon.GetType())),
+			colon.GetLocation()))
+	}
+
+	// right associative, a ? b : c ? d : e is a ? b : (c ? d : e)
+	falseBranch := interpreter.conditionalExpression()
+	interpreter.checkOperands(tok, condition, trueBranch, falseBranch)
 
+	return ast.NewConditionalExpression(condition, trueBranch, falseBranch, tok.GetLocation())
 }
 
 func (interpreter *Interpreter) logicalOrExpression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	result := interpreter.logicalAndExpression()
 
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if tok.GetType() != token.OR_ID {
 			parser.RollBack(tok)
 			break
 		}
 		expression := interpreter.logicalAndExpression()
-
-		// Constant folding
-		if _, ok := result.(*ast.BoolExpression); ok {
-			if _, ok = expression.(*ast.BoolExpression); ok {
-				left, _ := result.Evaluate(nil)
-				right, _ := expression.Evaluate(nil)
-				value, _ := types.LogicalOperation(types.Or, left, right)
-				result = ast.NewBoolExpression(value.GetValue().(bool))
-				continue
-			}
-		}
+		interpreter.checkOperands(tok, result, expression)
 
 		result = ast.NewOrExpression(result, expression, tok.GetLocation())
 	}
@@ -105,33 +182,22 @@ func (interpreter *Interpreter) logicalOrExpression() ast.Expression {
 
 func (interpreter *Interpreter) logicalAndExpression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	result := interpreter.equalityExpression()
 
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if tok.GetType() != token.AND_ID {
 			parser.RollBack(tok)
 			break
 		}
 		expression := interpreter.equalityExpression()
+		interpreter.checkOperands(tok, result, expression)
 
-		// Constant folding
-		if _, ok := result.(*ast.BoolExpression); ok {
-			if _, ok = expression.(*ast.BoolExpression); ok {
-				left, _ := result.Evaluate(nil)
-				right, _ := expression.Evaluate(nil)
-				value, _ := types.LogicalOperation(types.AND, left, right)
-				result = ast.NewBoolExpression(value.GetValue().(bool))
-				continue
-			}
-		}
-
-		result = ast.NewOrExpression(result, expression, tok.GetLocation())
+		result = ast.NewAndExpression(result, expression, tok.GetLocation())
 	}
 
 	return result
@@ -139,14 +205,13 @@ func (interpreter *Interpreter) logicalAndExpression() ast.Expression {
 
 func (interpreter *Interpreter) equalityExpression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	result := interpreter.relationalExpression()
 
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if tok.GetType() != token.EQUAL_ID &&
 			tok.GetType() != token.UNEQUAL_ID {
@@ -155,6 +220,7 @@ func (interpreter *Interpreter) equalityExpression() ast.Expression {
 		}
 
 		expression := interpreter.relationalExpression()
+		interpreter.checkOperands(tok, result, expression)
 
 		if tok.GetType() == token.EQUAL_ID {
 			result = ast.NewEqualExpression(result, expression, tok.GetLocation())
@@ -168,14 +234,13 @@ func (interpreter *Interpreter) equalityExpression() ast.Expression {
 
 func (interpreter *Interpreter) relationalExpression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	result := interpreter.additiveExpression()
 
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if tok.GetType() != token.GT_ID &&
 			tok.GetType() != token.LT_ID &&
@@ -186,6 +251,7 @@ func (interpreter *Interpreter) relationalExpression() ast.Expression {
 		}
 
 		expression := interpreter.additiveExpression()
+		interpreter.checkOperands(tok, result, expression)
 
 		if tok.GetType() == token.GT_ID {
 			result = ast.NewGTExpression(result, expression, tok.GetLocation())
@@ -203,14 +269,13 @@ func (interpreter *Interpreter) relationalExpression() ast.Expression {
 
 func (interpreter *Interpreter) additiveExpression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	result := interpreter.multiplicativeExpression()
 
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if tok.GetType() != token.ADD_ID &&
 			tok.GetType() != token.SUBTRACT_ID {
@@ -219,6 +284,7 @@ func (interpreter *Interpreter) additiveExpression() ast.Expression {
 		}
 
 		expression := interpreter.multiplicativeExpression()
+		interpreter.checkOperands(tok, result, expression)
 
 		if tok.GetType() == token.ADD_ID {
 			result = ast.NewAddExpression(result, expression, tok.GetLocation())
@@ -232,14 +298,13 @@ func (interpreter *Interpreter) additiveExpression() ast.Expression {
 
 func (interpreter *Interpreter) multiplicativeExpression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	result := interpreter.unaryExpression()
 
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if tok.GetType() != token.MULTIPLY_ID &&
 			tok.GetType() != token.DIVIDE_ID &&
@@ -249,6 +314,7 @@ func (interpreter *Interpreter) multiplicativeExpression() ast.Expression {
 		}
 
 		expression := interpreter.unaryExpression()
+		interpreter.checkOperands(tok, result, expression)
 
 		if tok.GetType() == token.MULTIPLY_ID {
 			result = ast.NewMultiplyExpression(result, expression, tok.GetLocation())
@@ -264,7 +330,6 @@ func (interpreter *Interpreter) multiplicativeExpression() ast.Expression {
 
 func (interpreter *Interpreter) unaryExpression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
@@ -274,15 +339,22 @@ func (interpreter *Interpreter) unaryExpression() ast.Expression {
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 
 	if tok.GetType() == token.SUBTRACT_ID {
-		expression = interpreter.expression()
+		expression = interpreter.unaryExpression()
+		interpreter.checkOperands(tok, expression)
 		result = ast.NewMinusExpression(expression, tok.GetLocation())
 	} else if tok.GetType() == token.NOT_ID {
-		expression = interpreter.expression()
+		expression = interpreter.unaryExpression()
+		interpreter.checkOperands(tok, expression)
 		result = ast.NewNotExpression(expression, tok.GetLocation())
+	} else if op, ok := incrementOperators[tok.GetType()]; ok {
+		identifier, fields := interpreter.incrementOperand(tok)
+		result = interpreter.incrementExpression(identifier, fields, op, true, tok.GetLocation())
+	} else if tok.GetType() == token.SPAWN_ID {
+		result = interpreter.spawnExpression(tok)
 	} else {
 		parser.RollBack(tok)
 		result = interpreter.primaryExpression()
@@ -293,38 +365,56 @@ func (interpreter *Interpreter) unaryExpression() ast.Expression {
 
 func (interpreter *Interpreter) primaryExpression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	tok, err := parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 
 	switch tok.GetType() {
 	case token.IDENTIFIER_ID:
+		identifier, _ := interpreter.qualifiedIdentifier(tok)
 		var nToken *token.Token
 		if nToken, err = parser.Next(); err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 
 		if nToken.GetType() == token.LSP_ID {
 			// function call expression
 			parser.RollBack(nToken)
 			arguments := interpreter.argumentList()
-			return ast.NewFunctionCallExpression(arguments, types.NewIdentifier(
-				tok.GetValue().(string), tok.GetLocation()), tok.GetLocation())
+			call := ast.NewFunctionCallExpression(arguments, identifier, tok.GetLocation())
+			fields, _ := interpreter.fieldChain()
+			return interpreter.fieldAccess(call, fields)
+		} else if op, ok := incrementOperators[nToken.GetType()]; ok {
+			// postfix increment expression
+			return ast.NewIncrementExpression(identifier, op, false, nToken.GetLocation())
 		} else {
 			parser.RollBack(nToken)
 			// identifier expression
-			return ast.NewIdentifierExpression(types.NewIdentifier(
-				tok.GetValue().(string), tok.GetLocation()))
+			fields, _ := interpreter.fieldChain()
+			if len(fields) > 0 {
+				nToken = interpreter.nextToken()
+				if op, ok := incrementOperators[nToken.GetType()]; ok {
+					// postfix increment of a field
+					return interpreter.incrementExpression(identifier, fields, op, false,
+						nToken.GetLocation())
+				}
+				parser.RollBack(nToken)
+			}
+			return interpreter.fieldAccess(ast.NewIdentifierExpression(identifier), fields)
 		}
 
 	case token.LSP_ID:
 		parser.RollBack(tok)
-		return interpreter.embedExpression()
+		expression := interpreter.embedExpression()
+		fields, _ := interpreter.fieldChain()
+		return interpreter.fieldAccess(expression, fields)
 
 	case token.INTEGER_ID:
+		if value, ok := tok.GetValue().(*big.Int); ok {
+			return ast.NewBigIntegerExpression(value)
+		}
 		return ast.NewIntegerExpression(tok.GetValue().(int64))
 
 	case token.FLOAT_ID:
@@ -334,7 +424,7 @@ func (interpreter *Interpreter) primaryExpression() ast.Expression {
 		value, err := ast.NewStringExpression(tok.GetValue().(string))
 		if err != nil {
 			err.SetLocation(tok.GetLocation())
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		return value
 
@@ -352,9 +442,85 @@ func (interpreter *Interpreter) primaryExpression() ast.Expression {
 	}
 }
 
+// The operand of spawn must be a function call.
+func (interpreter *Interpreter) spawnExpression(spawn *token.Token) ast.Expression {
+	expression := interpreter.primaryExpression()
+	call, ok := expression.(*ast.FunctionCallExpression)
+	if !ok {
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("%s should be followed by a function call",
+				token.GetDescription(token.SPAWN_ID)), spawn.GetLocation()))
+	}
+	return ast.NewSpawnExpression(call, spawn.GetLocation())
+}
+
+var incrementOperators map[int]string = map[int]string{
+	token.INCREMENT_ID: types.INCREMENT,
+	token.DECREMENT_ID: types.DECREMENT,
+}
+
+// The operand of prefix increment must be a variable or its field.
+func (interpreter *Interpreter) incrementOperand(
+	operator *token.Token) (*types.Identifier, []*types.Identifier) {
+	tok, err := interpreter.parser.Next()
+	if err != nil {
+		interpreter.compileError(err)
+	}
+	if tok.GetType() != token.IDENTIFIER_ID {
+		interpreter.parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Operand of %s should be a variable, not %s",
+				token.GetDescription(operator.GetType()), token.GetDescription(tok.GetType())),
+			tok.GetLocation()))
+	}
+	identifier, _ := interpreter.qualifiedIdentifier(tok)
+	fields, _ := interpreter.fieldChain()
+	return identifier, fields
+}
+
+// Increment the variable, or the last field accessed from it.
+func (interpreter *Interpreter) incrementExpression(identifier *types.Identifier,
+	fields []*types.Identifier, op string, prefix bool, location *common.Location) ast.Expression {
+	if len(fields) == 0 {
+		return ast.NewIncrementExpression(identifier, op, prefix, location)
+	}
+	object := interpreter.fieldAccess(ast.NewIdentifierExpression(identifier), fields[:len(fields)-1])
+	return ast.NewFieldIncrementExpression(object, fields[len(fields)-1], op, prefix, location)
+}
+
+// Fields accessed by dots, returns the tokens consumed.
+func (interpreter *Interpreter) fieldChain() ([]*types.Identifier, []*token.Token) {
+	fields := []*types.Identifier{}
+	consumed := []*token.Token{}
+	for {
+		dot := interpreter.nextToken()
+		if dot.GetType() != token.DOT_ID {
+			interpreter.parser.RollBack(dot)
+			return fields, consumed
+		}
+		member := interpreter.nextToken()
+		if member.GetType() != token.IDENTIFIER_ID {
+			interpreter.parser.RollBack(member)
+			interpreter.compileError(gerror.NewSyntaxError(
+				fmt.Sprintf("%s should followed by field, not %s",
+					token.GetDescription(token.DOT_ID), token.GetDescription(member.GetType())),
+				member.GetLocation()))
+		}
+		fields = append(fields, types.NewIdentifier(member.GetValue().(string), member.GetLocation()))
+		consumed = append(consumed, dot, member)
+	}
+}
+
+// Access fields of object one by one.
+func (interpreter *Interpreter) fieldAccess(object ast.Expression, fields []*types.Identifier) ast.Expression {
+	for _, field := range fields {
+		object = ast.NewFieldExpression(object, field)
+	}
+	return object
+}
+
 func (interpreter *Interpreter) argumentList() []*ast.Argument {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
@@ -365,7 +531,7 @@ func (interpreter *Interpreter) argumentList() []*ast.Argument {
 	arguments := []*ast.Argument{}
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 
 	if tok.GetType() == token.RSP_ID {
@@ -380,13 +546,14 @@ func (interpreter *Interpreter) argumentList() []*ast.Argument {
 
 		tok, err = parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if tok.GetType() == token.RSP_ID {
 			// arguments list finished
 			break
 		}  else if tok.GetType() != token.COMMA_ID {
-			logger.CompileError(gerror.NewSyntaxError(
+			parser.RollBack(tok)
+			interpreter.compileError(gerror.NewSyntaxError(
 				fmt.Sprintf("Can't use %s in function arguments list",
 					token.GetDescription(tok.GetType())), tok.GetLocation()))
 		}
@@ -397,7 +564,6 @@ func (interpreter *Interpreter) argumentList() []*ast.Argument {
 
 func (interpreter *Interpreter) embedExpression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
@@ -410,10 +576,11 @@ func (interpreter *Interpreter) embedExpression() ast.Expression {
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 	if tok.GetType() != token.RSP_ID {
-		logger.CompileError(gerror.NewSyntaxError(
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
 			fmt.Sprintf("Embed expression should ended with %s, not %s",
 			token.GetDescription(token.RSP_ID), token.GetDescription(tok.GetType())),
 			tok.GetLocation()))
@@ -421,3 +588,14 @@ func (interpreter *Interpreter) embedExpression() ast.Expression {
 
 	return expression
 }
+
+// All operands of an operator must exist.
+func (interpreter *Interpreter) checkOperands(operator *token.Token, operands ...ast.Expression) {
+	for _, operand := range operands {
+		if operand == nil {
+			interpreter.compileError(gerror.NewSyntaxError(
+				fmt.Sprintf("Missing operand for %s",
+					token.GetDescription(operator.GetType())), operator.GetLocation()))
+		}
+	}
+}
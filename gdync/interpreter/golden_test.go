@@ -0,0 +1,41 @@
+package interpreter
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mlmhl/compiler/common/golden"
+	"github.com/mlmhl/compiler/gdync/interpreter/clog"
+)
+
+// TestGolden runs the scripts under testdata like the gdync command does,
+// errors are logged to stderr. The vm should behave the same as the tree
+// walker. Run with -update to rewrite the golden files.
+func TestGolden(t *testing.T) {
+	golden.Run(t, "testdata", ".gd", func(t *testing.T, file string) (string, string) {
+		source, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Can't read %s: %s", file, err.Error())
+		}
+
+		stdouts, stderrs := []string{}, []string{}
+		for _, vm := range []bool{false, true} {
+			stderr := &bytes.Buffer{}
+			stdout, errs := runScript(string(source), vm, func(inter *Interpreter) {
+				inter.SetStdin(strings.NewReader(""))
+				inter.SetStderr(stderr)
+			})
+			if len(errs) > 0 {
+				clog.NewWriterLogger(stderr).Errors(errs)
+			}
+			stdouts, stderrs = append(stdouts, stdout), append(stderrs, stderr.String())
+		}
+		if stdouts[1] != stdouts[0] || stderrs[1] != stderrs[0] {
+			t.Fatalf("Wrong output of vm: Wanted\n%s%s\ngot\n%s%s",
+				stdouts[0], stderrs[0], stdouts[1], stderrs[1])
+		}
+		return stdouts[0], stderrs[0]
+	})
+}
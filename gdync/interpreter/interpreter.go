@@ -1,9 +1,14 @@
 package interpreter
 
 import (
-	"github.com/mlmhl/compiler/gdync/parser"
+	"context"
+	"io"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
 	"github.com/mlmhl/compiler/gdync/interpreter/ast"
-	"github.com/mlmhl/compiler/gdync/interpreter/clog"
+	"github.com/mlmhl/compiler/gdync/interpreter/stdlib"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+	"github.com/mlmhl/compiler/gdync/parser"
 )
 
 //
@@ -14,50 +19,259 @@ type Interpreter struct {
 	env    *ast.Environment // global scope context
 	parser *parser.Parser
 
+	fileName   string            // file being interpreted, imports are relative to it
+	loader     *moduleLoader     // shared with imported modules
+	namespaces map[string]string // absolute path of each imported module, empty if not found
+
 	statements []ast.Statement
+	functions  []*ast.CustomFunction // functions defined by the latest source
+	structs    []*ast.StructDefinition // structs defined by the latest source
+	imports    []*moduleImport         // modules imported by the latest source
+	globals    []string                // global variables assigned by the latest source
+
+	// diagnostics of the latest interpretation
+	errors []gerror.Error
 
-	logger *clog.Logger
+	vm *ast.VM // nil if scripts are executed by the tree walker
+
+	limits ast.Limits // limits of each interpretation and call
 }
 
 func NewInterpreter() *Interpreter {
-	logger, err := clog.NewLogger("stdout")
-	if err != nil {
-		logger = nil
-	}
-	return &Interpreter{
+	interpreter := &Interpreter{
 		env:    ast.NewEnvironment(ast.VariableSet{}, ast.FunctionSet{}, true),
 		parser: parser.NewParser(),
 
+		loader:     newModuleLoader(),
+		namespaces: map[string]string{},
+
 		statements: []ast.Statement{},
+		functions:  []*ast.CustomFunction{},
+		structs:    []*ast.StructDefinition{},
+		imports:    []*moduleImport{},
+		globals:    []string{},
+
+		errors: []gerror.Error{},
+	}
+	interpreter.env.SetScheduler(ast.NewScheduler())
+	interpreter.initNativeFunctions()
+	interpreter.initStandardFiles()
+	return interpreter
+}
+
+// Interpret parse and execute the file, all syntax errors are reported,
+// the file won't be executed if there is any of them. Execution stops at
+// the first runtime error.
+func (interpreter *Interpreter) Interpret(file string) []gerror.Error {
+	return interpreter.InterpretContext(context.Background(), file)
+}
+
+// InterpretContext is the same as Interpret, but execution is aborted by a
+// LimitExceededError once ctx is done.
+func (interpreter *Interpreter) InterpretContext(ctx context.Context, file string) []gerror.Error {
+	return interpreter.main(ctx, func(limiter *ast.Limiter) []gerror.Error {
+		return interpreter.interpretFile(file, limiter)
+	})
+}
+
+// InterpretReader is the same as Interpret, but read source code from reader,
+// fileName is only used to report errors.
+func (interpreter *Interpreter) InterpretReader(fileName string, reader io.Reader) []gerror.Error {
+	return interpreter.InterpretReaderContext(context.Background(), fileName, reader)
+}
+
+// InterpretReaderContext is the same as InterpretReader, but execution is
+// aborted by a LimitExceededError once ctx is done.
+func (interpreter *Interpreter) InterpretReaderContext(ctx context.Context,
+	fileName string, reader io.Reader) []gerror.Error {
+	interpreter.parser.ParseReader(fileName, reader)
+	interpreter.fileName = fileName
+	return interpreter.main(ctx, interpreter.interpret)
+}
 
-		logger: logger,
+// Run the main task of an execution, then wait for the tasks it spawns,
+// which are aborted if the main task fails. Errors of the tasks not
+// waited by script are reported after the main task's ones.
+func (interpreter *Interpreter) main(ctx context.Context,
+	run func(limiter *ast.Limiter) []gerror.Error) []gerror.Error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	limiter := ast.NewLimiter(ctx, interpreter.limits)
+
+	errs := run(limiter)
+	if len(errs) > 0 {
+		cancel()
+		interpreter.env.GetScheduler().WaitAll(limiter)
+		return errs
 	}
+	return interpreter.env.GetScheduler().WaitAll(limiter)
 }
 
-func (interpreter *Interpreter) Interpret(file string) {
+// Imported modules are interpreted by interpretFile too, sharing the
+// limiter of the importer.
+func (interpreter *Interpreter) interpretFile(file string, limiter *ast.Limiter) []gerror.Error {
 	if err := interpreter.parser.Parse(file); err != nil {
-		interpreter.logger.InternalError(err)
+		return []gerror.Error{err}
 	}
+	interpreter.fileName = file
+	return interpreter.interpret(limiter)
+}
 
-	interpreter.initNativeFunctions()
-	interpreter.create()
-	interpreter.execute()
+func (interpreter *Interpreter) interpret(limiter *ast.Limiter) []gerror.Error {
+	defer interpreter.enterFile()()
+
+	interpreter.env.SetLimiter(limiter)
+	defer interpreter.env.SetLimiter(nil)
+
+	if interpreter.prepare() {
+		interpreter.execute()
+	}
+	return interpreter.errors
+}
+
+// Run all passes before execution, each pass runs only if the previous
+// ones have no error. Returns true if the source can be executed.
+func (interpreter *Interpreter) prepare() bool {
+	passes := []func(){
+		interpreter.create,
+		interpreter.resolve,
+		interpreter.optimize,
+	}
+	for _, pass := range passes {
+		pass()
+		if len(interpreter.errors) > 0 {
+			return false
+		}
+	}
+
+	for _, function := range interpreter.functions {
+		interpreter.env.AddFunction(function)
+	}
+	for _, definition := range interpreter.structs {
+		interpreter.env.AddFunction(definition)
+	}
+	return true
+}
+
+// UseVM choose the backend executing scripts, the bytecode vm if enabled
+// is true, otherwise the tree walker, which is the default one.
+func (interpreter *Interpreter) UseVM(enabled bool) {
+	if enabled {
+		interpreter.vm = ast.NewVM()
+	} else {
+		interpreter.vm = nil
+	}
+}
+
+// SetLimits limit the execution of later interpretations and calls,
+// a zero field of limits means no limit.
+func (interpreter *Interpreter) SetLimits(limits ast.Limits) {
+	interpreter.limits = limits
+}
+
+// SetObserver let observer watch the execution of scripts, like a
+// debugger, nil removes the observer.
+func (interpreter *Interpreter) SetObserver(observer ast.Observer) {
+	interpreter.env.SetObserver(observer)
 }
 
 func (interpreter *Interpreter) initNativeFunctions() {
 	for _, function := range ast.GetNativeFunctions() {
 		interpreter.env.AddFunction(function)
 	}
+	for _, function := range stdlib.GetFunctions() {
+		interpreter.env.AddFunction(function)
+	}
+}
+
+func (interpreter *Interpreter) initStandardFiles() {
+	for _, name := range []string{types.STDIN, types.STDOUT, types.STDERR} {
+		interpreter.SetGlobal(name, types.NewStandardFile(name))
+	}
+}
+
+// SetStdin redirect the standard input of scripts to reader.
+func (interpreter *Interpreter) SetStdin(reader io.Reader) {
+	interpreter.SetGlobal(types.STDIN, types.NewFile(types.STDIN, reader, nil, nil))
+}
+
+// SetStdout redirect the standard output of scripts to writer.
+func (interpreter *Interpreter) SetStdout(writer io.Writer) {
+	interpreter.SetGlobal(types.STDOUT, types.NewFile(types.STDOUT, nil, writer, nil))
+}
+
+// SetStderr redirect the standard error of scripts to writer.
+func (interpreter *Interpreter) SetStderr(writer io.Writer) {
+	interpreter.SetGlobal(types.STDERR, types.NewFile(types.STDERR, nil, writer, nil))
 }
 
 func (interpreter *Interpreter) create() {
+	interpreter.errors = []gerror.Error{}
+	interpreter.statements = []ast.Statement{}
+	interpreter.functions = []*ast.CustomFunction{}
+	interpreter.structs = []*ast.StructDefinition{}
+	interpreter.imports = []*moduleImport{}
+
 	interpreter.compileUnit()
 }
 
+// Bind identifiers of the statements and functions just created.
+func (interpreter *Interpreter) resolve() {
+	resolver := ast.NewResolver(interpreter.env)
+	resolver.DefineStructs(interpreter.structs)
+	for _, imported := range interpreter.imports {
+		resolver.DefineImports(imported.module.members(imported.namespace))
+	}
+	resolver.Resolve(interpreter.statements, interpreter.functions)
+	interpreter.errors = append(interpreter.errors, resolver.GetErrors()...)
+	interpreter.globals = resolver.GetGlobals()
+}
+
+// Constant folding over the statements and functions just created.
+func (interpreter *Interpreter) optimize() {
+	folder := ast.NewFolder()
+	folder.SetLimiter(interpreter.env.GetLimiter())
+	interpreter.statements = folder.FoldStatements(interpreter.statements)
+	for _, function := range interpreter.functions {
+		folder.FoldFunction(function)
+	}
+	interpreter.errors = append(interpreter.errors, folder.GetErrors()...)
+}
+
 func (interpreter *Interpreter) execute() {
 	for _, statement := range interpreter.statements {
-		if _, err := statement.Execute(interpreter.env); err != nil {
-			interpreter.logger.RuntimeError(err)
+		if _, err := interpreter.executeStatement(statement); err != nil {
+			interpreter.errors = append(interpreter.errors, err)
+			return
+		}
+	}
+}
+
+// Execute a top level statement by the selected backend,
+// returns the value of an expression statement.
+func (interpreter *Interpreter) executeStatement(statement ast.Statement) (types.Value, gerror.Error) {
+	if statement, ok := statement.(*ast.ImportStatement); ok {
+		// the same for both backends
+		if err := interpreter.env.Step(statement.GetLocation()); err != nil {
+			return nil, err
 		}
+		_, err := statement.Execute(interpreter.env)
+		return nil, err
+	}
+	if interpreter.vm != nil {
+		return interpreter.vm.Execute(statement, interpreter.env)
+	}
+
+	if err := interpreter.env.Step(statement.GetLocation()); err != nil {
+		return nil, err
 	}
-}
\ No newline at end of file
+	result, err := statement.Execute(interpreter.env)
+	if err != nil {
+		return nil, err
+	}
+	if result.GetType() == ast.THROW_STATEMENT_RESULT {
+		// not caught by any try statement
+		return nil, ast.NewException(result.GetValue(), result.GetLocation())
+	}
+	return result.GetValue(), nil
+}
@@ -0,0 +1,753 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeScript(source string, t *testing.T) string {
+	fileName := filepath.Join(t.TempDir(), "script.gd")
+	if err := os.WriteFile(fileName, []byte(source), 0644); err != nil {
+		t.Fatalf("Can't write script: %s", err.Error())
+	}
+	return fileName
+}
+
+func TestErrorRecovery(t *testing.T) {
+	t.Log("Test: error recovery ...")
+
+	fileName := writeScript(strings.Join([]string{
+		"x = (2 + 3",
+		"def f(a b) {",
+		"    return a",
+		"}",
+		"while (x < ) {",
+		"    x = x + 1",
+		"}",
+		"if (x > 0 {",
+		"    x = 1",
+		"} else {",
+		"    x = 2",
+		"}",
+		"def g() {",
+		"    a = 1 $ 2",
+		"    def h() {}",
+		"    return a",
+		"}",
+	}, "\n"), t)
+
+	targets := []string{
+		"Embed expression should ended with right small parentheses, not function",
+		"identifier can't be used as function paramter",
+		"Missing operand for less than",
+		"Condition expression should stopped with right small parentheses, " +
+			"not left large parentheses",
+		"Unsupported syntax",
+		"Function can only be defined in top level",
+	}
+	lines := []int{2, 2, 5, 8, 14, 15}
+
+	errs := NewInterpreter().Interpret(fileName)
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+		if err.GetLocation().GetLine() != lines[i] {
+			t.Fatalf("Wrong error line(%d): Wanted %d, got %d",
+				i, lines[i], err.GetLocation().GetLine())
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestUnclosedBlock(t *testing.T) {
+	t.Log("Test: unclosed block ...")
+
+	fileName := writeScript(strings.Join([]string{
+		"x = 1",
+		"if (x > 0) {",
+		"    x = x +",
+	}, "\n"), t)
+
+	targets := []string{
+		"Missing operand for add",
+		"Block should stop with right large parentheses",
+	}
+
+	errs := NewInterpreter().Interpret(fileName)
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestRuntimeError(t *testing.T) {
+	t.Log("Test: runtime error ...")
+
+	fileName := writeScript(strings.Join([]string{
+		"x = 1",
+		"y = x + undefined",
+		"y = x * \"a\"",
+	}, "\n"), t)
+
+	errs := NewInterpreter().Interpret(fileName)
+	if len(errs) != 1 {
+		t.Fatalf("Wrong error count: Wanted 1, got %d", len(errs))
+	}
+	if errs[0].GetMessage() != "Undefined variable undefined" {
+		t.Fatalf("Wrong error: %s", errs[0].GetMessage())
+	}
+
+	t.Log("Passed")
+}
+
+func TestStandardFiles(t *testing.T) {
+	t.Log("Test: standard files and file handles ...")
+
+	path := filepath.Join(t.TempDir(), "data.txt")
+	fileName := writeScript(strings.Join([]string{
+		"name = input()",
+		"Printf(\"hello %s\\n\", name)",
+		"f = open(\"" + path + "\", \"w\")",
+		"write(f, \"first\\nsecond\\n\")",
+		"close(f)",
+		"f = open(\"" + path + "\", \"r\")",
+		"write(stdout, readLine(f) + \",\")",
+		"write(stdout, read(f))",
+		"write(stdout, readLine(f))",
+		"close(f)",
+		"write(stderr, \"done\")",
+		"read(f)",
+	}, "\n"), t)
+
+	stdout := &strings.Builder{}
+	stderr := &strings.Builder{}
+	inter := NewInterpreter()
+	inter.SetStdin(strings.NewReader("gdync\n"))
+	inter.SetStdout(stdout)
+	inter.SetStderr(stderr)
+
+	errs := inter.Interpret(fileName)
+	if len(errs) != 1 {
+		t.Fatalf("Wrong error count: Wanted 1, got %d", len(errs))
+	}
+	target := "Error in native function read: file " + path + " is closed"
+	if errs[0].GetMessage() != target {
+		t.Fatalf("Wrong error: Wanted (%s), got (%s)", target, errs[0].GetMessage())
+	}
+
+	if stdout.String() != "hello gdync\nfirst,second\nnull" {
+		t.Fatalf("Wrong stdout: %q", stdout.String())
+	}
+	if stderr.String() != "done" {
+		t.Fatalf("Wrong stderr: %q", stderr.String())
+	}
+
+	t.Log("Passed")
+}
+
+func TestConstantFolding(t *testing.T) {
+	t.Log("Test: constant folding ...")
+
+	inter := NewInterpreter()
+	errs := inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"x = -1 + 2 * 3",
+		"y = !true || 2.5 >= 2",
+		"if (false) {",
+		"    x = 0",
+		"} elif (x > 0) {",
+		"    x = x * 2",
+		"}",
+		"while (false) {",
+		"    x = 0",
+		"}",
+	}, "\n")))
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+	if x, _ := inter.GetGlobal("x"); x != int64(10) {
+		t.Fatalf("Wrong x: Wanted 10, got %v", x)
+	}
+	if y, _ := inter.GetGlobal("y"); y != true {
+		t.Fatalf("Wrong y: Wanted true, got %v", y)
+	}
+
+	stdout := &strings.Builder{}
+	inter.SetStdout(stdout)
+	errs = inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"Printf(\"executed\")",
+		"def f(a) {",
+		"    return a % 0",
+		"}",
+		"while (1) {",
+		"}",
+	}, "\n")))
+	targets := []string{
+		"Condition expression for while must be a bool expression",
+		"Division by zero",
+	}
+	lines := []int{5, 3}
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] || err.GetLocation().GetLine() != lines[i] {
+			t.Fatalf("Wrong error(%d): %s at line %d", i,
+				err.GetMessage(), err.GetLocation().GetLine())
+		}
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("Script with compile errors shouldn't be executed")
+	}
+
+	t.Log("Passed")
+
+	t.Log("Test: constant folding of pruned branches ...")
+
+	for _, vm := range []bool{false, true} {
+		output, errs := runScript(strings.Join([]string{
+			"if (false) {",
+			"    x = 1 / 0",
+			"} elif (true) {",
+			"    Printf(\"elif\\n\")",
+			"} elif (1 % 0) {",
+			"    x = 2 / 0",
+			"} else {",
+			"    x = 3 / 0",
+			"}",
+			"while (false) {",
+			"    x = 1 / 0",
+			"}",
+			"for (i = 0; false; i = i / 0) {",
+			"    x = 1 / 0",
+			"}",
+			"y = true ? 1 : 1 / 0",
+			"z = false && 1 / 0",
+			"Printf(\"%d %d %v\\n\", i, y, z)",
+		}, "\n"), vm)
+		if len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+		if target := "elif\n0 1 false\n"; output != target {
+			t.Fatalf("Wrong output: Wanted (%s), got (%s)", target, output)
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestResolve(t *testing.T) {
+	t.Log("Test: resolve identifiers ...")
+
+	inter := NewInterpreter()
+	errs := inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"count = 0",
+		"result = fib(10)",
+		"def fib(n) {",
+		"    global count",
+		"    count = count + 1",
+		"    if (n < 2) {",
+		"        return n",
+		"    }",
+		"    a = fib(n - 1)",
+		"    return a + fib(n - 2)",
+		"}",
+	}, "\n")))
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+	if result, _ := inter.GetGlobal("result"); result != int64(55) {
+		t.Fatalf("Wrong result: Wanted 55, got %v", result)
+	}
+	if count, _ := inter.GetGlobal("count"); count != int64(177) {
+		t.Fatalf("Wrong count: Wanted 177, got %v", count)
+	}
+
+	t.Log("Passed")
+}
+
+func TestResolveError(t *testing.T) {
+	t.Log("Test: resolve errors ...")
+
+	stdout := &strings.Builder{}
+	inter := NewInterpreter()
+	inter.SetStdout(stdout)
+	errs := inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"Printf(\"executed\")",
+		"x = y",
+		"global x",
+		"undefined(x)",
+		"def f(a, a) {",
+		"    global missing",
+		"    b = a",
+		"    global b",
+		"    return c",
+		"}",
+		"def f() {",
+		"}",
+	}, "\n")))
+
+	targets := []string{
+		"Duplicated function definition f, at script, 5, 4",
+		"Undefined variable y",
+		"Can't use 'global' in global scope",
+		"Undefined function undefined",
+		"Duplicated variable definition a, has been defined at script, 5, 6",
+		"Undefined variable missing",
+		"Duplicated variable definition b, has been defined at script, 7, 4",
+		"Undefined variable c",
+	}
+	lines := []int{11, 2, 3, 4, 5, 6, 8, 9}
+	if len(errs) != len(targets) {
+		for _, err := range errs {
+			t.Log(err.GetMessage())
+		}
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+		if err.GetLocation().GetLine() != lines[i] {
+			t.Fatalf("Wrong error line(%d): Wanted %d, got %d",
+				i, lines[i], err.GetLocation().GetLine())
+		}
+	}
+	if stdout.Len() != 0 {
+		t.Fatalf("Script with resolve errors shouldn't be executed")
+	}
+	if _, err := inter.Call("f"); err == nil {
+		t.Fatalf("Function of a failed script shouldn't be defined")
+	}
+
+	t.Log("Passed")
+}
+
+func TestOperators(t *testing.T) {
+	t.Log("Test: compound assignment, increment and conditional operators ...")
+
+	stdout := &strings.Builder{}
+	inter := NewInterpreter()
+	inter.SetStdout(stdout)
+	errs := inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"x = 5",
+		"x += 2",
+		"x -= 1",
+		"x *= 3",
+		"x /= 2",
+		"x %= 5",
+		"y = x++",
+		"z = ++x",
+		"Printf(\"%d %d %d\\n\", x, y, z)",
+		"w = x-- + --x",
+		"f = 1.5",
+		"f--",
+		"Printf(\"%d %d %v\\n\", x, w, f)",
+		"s = x > 2 ? \"big\" : x > 0 ? \"small\" : \"negative\"",
+		"s += \"!\"",
+		"Printf(\"%s\\n\", s)",
+		"s++",
+	}, "\n")))
+
+	if stdout.String() != "6 4 6\n4 10 0.5\nbig!\n" {
+		t.Fatalf("Wrong output: %s", stdout.String())
+	}
+	if len(errs) != 1 || errs[0].GetMessage() != "Can't invoke Increment operation on [String]" {
+		t.Fatalf("Increment on string should be reported")
+	}
+	if errs[0].GetLocation().GetLine() != 17 || errs[0].GetLocation().GetPosition() != 1 {
+		t.Fatalf("Wrong error location: %d, %d",
+			errs[0].GetLocation().GetLine(), errs[0].GetLocation().GetPosition())
+	}
+
+	t.Log("Passed")
+
+	t.Log("Test: operator syntax errors ...")
+
+	errs = NewInterpreter().InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"x = 1",
+		"y = x ? 1 2",
+		"++3",
+		"x +=;",
+	}, "\n")))
+	targets := []string{
+		"Conditional expression should separated by colon, not integer",
+		"Operand of increment should be a variable, not integer",
+		"Missing operand for add assign",
+	}
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestShortCircuit(t *testing.T) {
+	t.Log("Test: short circuit logical operators ...")
+
+	stdout := &strings.Builder{}
+	inter := NewInterpreter()
+	inter.SetStdout(stdout)
+	errs := inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"def trace(name, value) {",
+		"    Printf(\"%s \", name)",
+		"    return value",
+		"}",
+		"a = null",
+		"Printf(\"%v\\n\", a != null && a > 0)",
+		"Printf(\"%v\\n\", trace(\"a\", false) && trace(\"b\", true))",
+		"Printf(\"%v\\n\", trace(\"a\", true) || trace(\"b\", false))",
+		"Printf(\"%v\\n\", trace(\"a\", true) && trace(\"b\", false) || trace(\"c\", true))",
+		"Printf(\"%v\\n\", trace(\"a\", false) || trace(\"b\", 1))",
+	}, "\n")))
+
+	if stdout.String() != "false\na false\na true\na b c true\na b " {
+		t.Fatalf("Wrong output: %s", stdout.String())
+	}
+	if len(errs) != 1 || errs[0].GetMessage() != "Condition expression for || must be a bool expression" {
+		t.Fatalf("Non-bool operand should be reported")
+	}
+	if errs[0].GetLocation().GetLine() != 10 || errs[0].GetLocation().GetPosition() != 33 {
+		t.Fatalf("Wrong error location: %d, %d",
+			errs[0].GetLocation().GetLine(), errs[0].GetLocation().GetPosition())
+	}
+
+	t.Log("Passed")
+}
+
+func TestException(t *testing.T) {
+	source := strings.Join([]string{
+		"def check(n) {",
+		"    if (n > 2) {",
+		"        throw \"too big\"",
+		"    }",
+		"    return n",
+		"}",
+		"try {",
+		"    x = 1 / 0",
+		"} catch (e) {",
+		"    Printf(\"%s %v\\n\", errorMessage(e), errorLocation(e))",
+		"} finally {",
+		"    Printf(\"finally\\n\")",
+		"}",
+		"try {",
+		"    check(3)",
+		"} catch (e) {",
+		"    Printf(\"%v\\n\", e)",
+		"}",
+		"try {",
+		"    check(1, 2)",
+		"} catch (e) {",
+		"    Printf(\"%v\\n\", e)",
+		"}",
+		"try {",
+		"    check(5)",
+		"} finally {",
+		"    Printf(\"cleanup\\n\")",
+		"}",
+		"Printf(\"unreachable\\n\")",
+	}, "\n")
+	target := strings.Join([]string{
+		"Division by zero [script 8 10]",
+		"finally",
+		"too big",
+		"Too many arguments in call to check, need 1, but found 2 instead",
+		"cleanup",
+		"",
+	}, "\n")
+
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: exception with vm %v ...", vm)
+
+		output, errs := runScript(source, vm)
+		if output != target {
+			t.Fatalf("Wrong output: Wanted (%s), got (%s)", target, output)
+		}
+		if len(errs) != 1 || errs[0].GetMessage() != "Uncaught exception: too big" {
+			t.Fatalf("Uncaught exception should be reported")
+		}
+		if errs[0].GetLocation().GetLine() != 3 || errs[0].GetLocation().GetPosition() != 8 {
+			t.Fatalf("Wrong error location: %d, %d",
+				errs[0].GetLocation().GetLine(), errs[0].GetLocation().GetPosition())
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestTryError(t *testing.T) {
+	t.Log("Test: try statement syntax error ...")
+
+	errs := NewInterpreter().InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"try {",
+		"    x = 1",
+		"}",
+		"try {",
+		"    x = 2",
+		"} catch e {",
+		"    x = 3",
+		"} finally {",
+		"    x = 4",
+		"}",
+		"throw;",
+	}, "\n")))
+
+	targets := []string{
+		"Try block should followed by catch or finally, not try",
+		"Catch variable should start with left small parentheses, not identifier",
+		"throw should followed by a value, not semicolon",
+	}
+	lines := []int{4, 6, 11}
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+		if err.GetLocation().GetLine() != lines[i] {
+			t.Fatalf("Wrong error line(%d): Wanted %d, got %d",
+				i, lines[i], err.GetLocation().GetLine())
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestForeachError(t *testing.T) {
+	t.Log("Test: foreach statement syntax error ...")
+
+	errs := NewInterpreter().InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"for (x in ) {",
+		"    x = 1",
+		"}",
+		"for (x in \"ab\" {",
+		"    x = 2",
+		"}",
+		"for (x in \"ab\") {",
+		"    Printf(\"%s\", x)",
+		"}",
+	}, "\n")))
+
+	targets := []string{
+		"in should followed by a collection, not right small parentheses",
+		"Foreach expression should stopped with right small parentheses, " +
+			"not left large parentheses",
+	}
+	lines := []int{1, 4}
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+		if err.GetLocation().GetLine() != lines[i] {
+			t.Fatalf("Wrong error line(%d): Wanted %d, got %d",
+				i, lines[i], err.GetLocation().GetLine())
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestSwitchError(t *testing.T) {
+	t.Log("Test: switch statement syntax error ...")
+
+	errs := NewInterpreter().InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"x = 1",
+		"switch (x) {",
+		"    case 1, 2, 1:",
+		"        x = 1",
+		"    case 2, 1.0:",
+		"        x = 2",
+		"    case x:",
+		"        x = 3",
+		"    default:",
+		"    default:",
+		"}",
+		"switch (x) {",
+		"    x = 1",
+		"}",
+	}, "\n")))
+
+	targets := []string{
+		"Duplicated case 1 in switch, at script, 3, 9",
+		"Duplicated case 2 in switch, at script, 3, 12",
+		"Case value should be a constant, not identifier",
+		"Duplicated default in switch, at script, 9, 4",
+		"Switch body should start with case or default, not identifier",
+	}
+	lines := []int{3, 5, 7, 10, 13}
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+		if err.GetLocation().GetLine() != lines[i] {
+			t.Fatalf("Wrong error line(%d): Wanted %d, got %d",
+				i, lines[i], err.GetLocation().GetLine())
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestStructError(t *testing.T) {
+	t.Log("Test: struct definition syntax error ...")
+
+	errs := NewInterpreter().InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"struct Point { x, y, x }",
+		"if (true) {",
+		"    struct Inner { a }",
+		"}",
+		"struct Line a, b",
+		"struct Pair { a b }",
+		"p = Point(1, 2)",
+		"p.1 = 2",
+	}, "\n")))
+
+	targets := []string{
+		"Duplicated field x in struct Point, at script, 1, 15",
+		"Struct can only be defined in top level",
+		"Struct fields should start with left large parentheses, not identifier",
+		"Struct fields should be separated by comma, not identifier",
+		"dot should followed by field, not integer",
+	}
+	lines := []int{1, 3, 5, 6, 8}
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+		if err.GetLocation().GetLine() != lines[i] {
+			t.Fatalf("Wrong error line(%d): Wanted %d, got %d",
+				i, lines[i], err.GetLocation().GetLine())
+		}
+	}
+
+	t.Log("Passed")
+
+	t.Log("Test: struct duplicated definition ...")
+
+	errs = NewInterpreter().InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"struct Point { x }",
+		"struct Point { y }",
+	}, "\n")))
+	target := "Duplicated struct definition Point, at script, 1, 7"
+	if len(errs) != 1 || errs[0].GetMessage() != target {
+		t.Fatalf("Wrong errors: Wanted (%s), got %v", target, errs)
+	}
+
+	t.Log("Passed")
+}
+
+func TestCyclicStruct(t *testing.T) {
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: cyclic struct, vm %v ...", vm)
+
+		output, errs := runScript(strings.Join([]string{
+			"struct P { x }",
+			"a = P(1)",
+			"a.x = a",
+			"Printf(\"%s\\n\", a)",
+			"b = P(1)",
+			"b.x = P(b)",
+			"Printf(\"%s\\n\", b)",
+			"c = P(null)",
+			"c.x = c",
+			"d = P(null)",
+			"d.x = P(d)",
+			"Printf(\"%v %v %v %v\\n\", a == c, c == d, a == b, a != P(2))",
+			"Printf(\"%v %v\\n\", P(1) == P(1.0), P(1) == P(\"1\"))",
+		}, "\n"), vm)
+		if len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+		target := "P{x: P{...}}\nP{x: P{x: P{...}}}\ntrue true true true\ntrue false\n"
+		if output != target {
+			t.Fatalf("Wrong output: Wanted (%s), got (%s)", target, output)
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestFieldIncrement(t *testing.T) {
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: increment of fields, vm %v ...", vm)
+
+		output, errs := runScript(strings.Join([]string{
+			"struct P { x, y }",
+			"p = P(1, P(1.5))",
+			"p.x++",
+			"Printf(\"%d\\n\", p.x)",
+			"a = p.x-- + 10",
+			"b = ++p.y.x",
+			"c = --p.x * 2",
+			"Printf(\"%d %v %d %d %v\\n\", a, b, c, p.x, p.y.x)",
+			"p.y.x--",
+		}, "\n"), vm)
+		if len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+		target := "2\n12 2.5 0 0 2.5\n"
+		if output != target {
+			t.Fatalf("Wrong output: Wanted (%s), got (%s)", target, output)
+		}
+
+		_, errs = runScript(strings.Join([]string{
+			"struct P { x }",
+			"p = P(\"a\")",
+			"p.x++",
+		}, "\n"), vm)
+		if len(errs) != 1 || errs[0].GetMessage() != "Can't invoke Increment operation on [String]" ||
+			errs[0].GetLocation().GetLine() != 3 {
+			t.Fatalf("Increment on string field should be reported at line 3, got %v", errs)
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestPrintfResult(t *testing.T) {
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: result of Printf, vm %v ...", vm)
+
+		output, errs := runScript(strings.Join([]string{
+			"x = Printf(\"a\\n\")",
+			"if (x == null) {",
+			"    Printf(\"null\\n\")",
+			"}",
+		}, "\n"), vm)
+		if len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+		if output != "a\nnull\n" {
+			t.Fatalf("Wrong output: Wanted (a\\nnull\\n), got (%s)", output)
+		}
+
+		t.Log("Passed")
+	}
+}
@@ -0,0 +1,176 @@
+package interpreter
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+)
+
+var limitScripts = []struct {
+	name    string
+	limits  ast.Limits
+	lines   []string
+	limit   string
+	message string
+	output  string // output before the limit is exceeded
+}{
+	{"statements", ast.Limits{Statements: 100}, []string{
+		"i = 0",
+		"while (true) {",
+		"    i++",
+		"}",
+	}, ast.STATEMENTS_LIMIT, "Execution exceeds the limit of 100 statements", ""},
+	{"empty loop", ast.Limits{Statements: 10}, []string{
+		"for (i = 0; true; i++) {}",
+	}, ast.STATEMENTS_LIMIT, "Execution exceeds the limit of 10 statements", ""},
+	{"call depth", ast.Limits{CallDepth: 50}, []string{
+		"def recurse(n) {",
+		"    return recurse(n + 1)",
+		"}",
+		"recurse(0)",
+	}, ast.CALL_DEPTH_LIMIT, "Call depth exceeds the limit of 50", ""},
+	{"string size", ast.Limits{StringSize: 16}, []string{
+		"s = \"abcd\" * 4",
+		"Printf(\"%s\\n\", s)",
+		"s = s + \"e\"",
+	}, ast.STRING_SIZE_LIMIT, "String size 17 exceeds the limit of 16", "abcdabcdabcdabcd\n"},
+	{"huge repetition", ast.Limits{StringSize: 1024}, []string{
+		"s = 1000000000000 * \"abc\"",
+	}, ast.STRING_SIZE_LIMIT, "String size 3000000000000 exceeds the limit of 1024", ""},
+	{"concatenated number", ast.Limits{StringSize: 16}, []string{
+		"s = \"abcd\" * 4",
+		"s = s + 1",
+	}, ast.STRING_SIZE_LIMIT, "String size 17 exceeds the limit of 16", ""},
+	{"native result", ast.Limits{StringSize: 4}, []string{
+		"s = toString(123456)",
+	}, ast.STRING_SIZE_LIMIT, "String size 6 exceeds the limit of 4", ""},
+	{"not caught", ast.Limits{Statements: 50}, []string{
+		"try {",
+		"    while (true) {}",
+		"} catch (e) {",
+		"    Printf(\"caught\\n\")",
+		"} finally {",
+		"    Printf(\"finally\\n\")",
+		"}",
+	}, ast.STATEMENTS_LIMIT, "Execution exceeds the limit of 50 statements", ""},
+	{"time", ast.Limits{Time: 10 * time.Millisecond}, []string{
+		"while (true) {}",
+	}, ast.TIME_LIMIT, "Execution exceeds the time limit of 10ms", ""},
+}
+
+// Option of runScript setting the limits.
+func withLimits(limits ast.Limits) func(*Interpreter) {
+	return func(inter *Interpreter) {
+		inter.SetLimits(limits)
+	}
+}
+
+func checkLimitError(errs []gerror.Error, limit, message string, t *testing.T) {
+	if len(errs) != 1 {
+		t.Fatalf("Wrong error count: Wanted 1, got %d", len(errs))
+	}
+	err, ok := errs[0].(*gerror.LimitExceededError)
+	if !ok {
+		t.Fatalf("Wrong error: %s", errs[0].GetMessage())
+	}
+	if err.GetLimit() != limit {
+		t.Fatalf("Wrong limit: Wanted %s, got %s", limit, err.GetLimit())
+	}
+	if err.GetMessage() != message {
+		t.Fatalf("Wrong message: Wanted (%s), got (%s)", message, err.GetMessage())
+	}
+}
+
+func TestLimits(t *testing.T) {
+	for _, script := range limitScripts {
+		t.Logf("Test: limit of %s ...", script.name)
+
+		source := strings.Join(script.lines, "\n")
+		target, targetErrs := runScript(source, false, withLimits(script.limits))
+		output, errs := runScript(source, true, withLimits(script.limits))
+
+		for _, result := range []string{target, output} {
+			if result != script.output {
+				t.Fatalf("Wrong output: Wanted (%s), got (%s)", script.output, result)
+			}
+		}
+		checkLimitError(targetErrs, script.limit, script.message, t)
+		checkLimitError(errs, script.limit, script.message, t)
+		if !errs[0].GetLocation().Equal(targetErrs[0].GetLocation()) {
+			t.Fatalf("Wrong error location: Wanted %v, got %v",
+				targetErrs[0].GetLocation(), errs[0].GetLocation())
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestStringLimitOfStruct(t *testing.T) {
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: string limit on a cyclic struct, vm %v ...", vm)
+
+		// the struct isn't printed to check the size
+		_, errs := runScript(strings.Join([]string{
+			"struct P { x }",
+			"a = P(1)",
+			"a.x = a",
+			"s = \"a\" + a",
+		}, "\n"), vm, withLimits(ast.Limits{StringSize: 8}))
+		target := "Can't invoke Add operation on [String Struct]"
+		if len(errs) != 1 || errs[0].GetMessage() != target {
+			t.Fatalf("Wrong errors: Wanted (%s), got %v", target, errs)
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestInterpretContext(t *testing.T) {
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: interpret with context, vm %v ...", vm)
+
+		inter := NewInterpreter()
+		inter.UseVM(vm)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		errs := inter.InterpretReaderContext(ctx, "script",
+			strings.NewReader("while (true) {}"))
+		cancel()
+		checkLimitError(errs, ast.CONTEXT_LIMIT,
+			"Execution is canceled: context deadline exceeded", t)
+
+		// the limiter of an interpretation isn't kept by the next one
+		if errs = inter.InterpretReader("script", strings.NewReader("x = 1")); len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestCallLimits(t *testing.T) {
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: call with limits, vm %v ...", vm)
+
+		inter := NewInterpreter()
+		inter.UseVM(vm)
+		inter.SetLimits(ast.Limits{Statements: 1000})
+		errs := inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+			"def spin() {",
+			"    while (true) {}",
+			"}",
+		}, "\n")))
+		if len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+
+		_, err := inter.Call("spin")
+		checkLimitError([]gerror.Error{err}, ast.STATEMENTS_LIMIT,
+			"Execution exceeds the limit of 1000 statements", t)
+
+		t.Log("Passed")
+	}
+}
@@ -0,0 +1,323 @@
+package interpreter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+	"github.com/mlmhl/compiler/gdync/token"
+)
+
+//
+// Modules: `import "path" [as name]` executes another file once, its global
+// variables and functions are exposed to the importer as name.member, name
+// is the base name of the file without extension by default. Relative paths
+// are searched in the directory of the importing file, then in search path.
+//
+// A module is compiled while creating the importer, so that its members can
+// be resolved, and executed by the import statement.
+//
+
+type module struct {
+	path string
+	env  *ast.Environment // global scope of the module
+
+	functions []*ast.CustomFunction
+	structs   []*ast.StructDefinition
+	globals   []string // global variables assigned by the module
+
+	main   *Interpreter // compiled the module, nil once it's executed
+	failed bool         // the execution has errors
+}
+
+// A module imported by the latest source under namespace.
+type moduleImport struct {
+	namespace string
+	module    *module
+}
+
+// moduleLoader is shared by an interpreter and all modules it imports.
+type moduleLoader struct {
+	searchPath []string
+	modules    map[string]*module // keyed by absolute path
+
+	loading []string // absolute paths of the files being interpreted
+}
+
+func newModuleLoader() *moduleLoader {
+	return &moduleLoader{
+		searchPath: []string{},
+		modules:    map[string]*module{},
+
+		loading: []string{},
+	}
+}
+
+var namespacePattern *regexp.Regexp = regexp.MustCompile("^[A-Za-z][A-Za-z0-9_]*$")
+
+// SetSearchPath set directories searched for imported files, which
+// aren't found in the directory of the importing file.
+func (interpreter *Interpreter) SetSearchPath(paths []string) {
+	interpreter.loader.searchPath = paths
+}
+
+// Mark the file being interpreted, returns a function to unmark it.
+func (interpreter *Interpreter) enterFile() func() {
+	loader := interpreter.loader
+	path, err := filepath.Abs(interpreter.fileName)
+	if err != nil {
+		path = interpreter.fileName
+	}
+	loader.loading = append(loader.loading, path)
+	return func() {
+		loader.loading = loader.loading[:len(loader.loading)-1]
+	}
+}
+
+func (interpreter *Interpreter) importStatement() {
+	parser := interpreter.parser
+
+	// Next token's type must be IMPORT_ID
+	tok, _ := parser.Next()
+	location := tok.GetLocation()
+
+	if tok = interpreter.nextToken(); tok.GetType() != token.STRING_ID {
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("%s should followed by %s, not %s",
+				token.GetDescription(token.IMPORT_ID), token.GetDescription(token.STRING_ID),
+				token.GetDescription(tok.GetType())), tok.GetLocation()))
+	}
+	path := strings.Trim(tok.GetValue().(string), "\"")
+
+	namespace := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	nameLocation := tok.GetLocation()
+	if tok = interpreter.nextToken(); tok.GetType() == token.AS_ID {
+		if tok = interpreter.nextToken(); tok.GetType() != token.IDENTIFIER_ID {
+			parser.RollBack(tok)
+			interpreter.compileError(gerror.NewSyntaxError(
+				fmt.Sprintf("%s should followed by identifier, not %s",
+					token.GetDescription(token.AS_ID), token.GetDescription(tok.GetType())),
+				tok.GetLocation()))
+		}
+		namespace = tok.GetValue().(string)
+		nameLocation = tok.GetLocation()
+	} else if tok.GetType() != token.SEMICOLON_ID {
+		parser.RollBack(tok)
+	}
+	if !namespacePattern.MatchString(namespace) {
+		interpreter.compileError(gerror.NewImportError(path,
+			fmt.Sprintf("invalid module name %s, should be renamed by %s",
+				namespace, token.GetDescription(token.AS_ID)), nameLocation))
+	}
+
+	interpreter.importModule(path, namespace, location)
+}
+
+// Returns the next token, a lexical error abandons current statement.
+func (interpreter *Interpreter) nextToken() *token.Token {
+	tok, err := interpreter.parser.Next()
+	if err != nil {
+		interpreter.compileError(err)
+	}
+	return tok
+}
+
+// Compile the module at path and create the statement importing it, a
+// module is compiled only once, later imports share it.
+func (interpreter *Interpreter) importModule(path, namespace string, location *common.Location) {
+	file, err := interpreter.findModule(path, location)
+	if err != nil {
+		if _, ok := interpreter.namespaces[namespace]; !ok {
+			// placeholder, so that members of namespace are still parsed
+			// as qualified names, and only this error is reported
+			interpreter.namespaces[namespace] = ""
+		}
+		interpreter.compileError(err)
+	}
+
+	if imported, ok := interpreter.namespaces[namespace]; ok && imported != "" && imported != file {
+		interpreter.compileError(gerror.NewImportError(path, fmt.Sprintf(
+			"namespace %s is used by %s", namespace, filepath.Base(imported)), location))
+	}
+	interpreter.namespaces[namespace] = file
+
+	loader := interpreter.loader
+	for i, loading := range loader.loading {
+		if loading == file {
+			chain := []string{}
+			for _, p := range append(loader.loading[i:], file) {
+				chain = append(chain, filepath.Base(p))
+			}
+			interpreter.compileError(gerror.NewImportError(path,
+				"import cycle "+strings.Join(chain, " -> "), location))
+		}
+	}
+
+	m, ok := loader.modules[file]
+	if !ok {
+		if m, ok = interpreter.compileModule(file); !ok {
+			interpreter.compileError(gerror.NewImportError(path, "module has errors", location))
+		}
+		loader.modules[file] = m
+	}
+	interpreter.imports = append(interpreter.imports, &moduleImport{namespace: namespace, module: m})
+	interpreter.statements = append(interpreter.statements, ast.NewImportStatement(path, namespace, location,
+		func(env *ast.Environment) gerror.Error {
+			return interpreter.executeModule(m, path, namespace, location)
+		}))
+}
+
+// Absolute path of the imported file.
+func (interpreter *Interpreter) findModule(path string, location *common.Location) (string, gerror.Error) {
+	candidates := []string{path}
+	if !filepath.IsAbs(path) {
+		candidates = []string{filepath.Join(filepath.Dir(interpreter.fileName), path)}
+		for _, dir := range interpreter.loader.searchPath {
+			candidates = append(candidates, filepath.Join(dir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			if abs, err := filepath.Abs(candidate); err == nil {
+				return abs, nil
+			}
+			return candidate, nil
+		}
+	}
+	return "", gerror.NewImportError(path, "file not found", location)
+}
+
+// Compile the file by a new interpreter sharing the loader, backend,
+// standard files and host functions of the importer. Errors of the module
+// are reported by the importer, ok is false if there is any of them.
+func (interpreter *Interpreter) compileModule(file string) (*module, bool) {
+	child := NewInterpreter()
+	child.loader = interpreter.loader
+	child.vm = interpreter.vm
+	child.limits = interpreter.limits
+	child.env.SetScheduler(interpreter.env.GetScheduler())
+
+	for _, name := range []string{types.STDIN, types.STDOUT, types.STDERR} {
+		variable := interpreter.env.GetGlobalVariable(types.NewIdentifier(name, nil))
+		child.env.SetGlobalVariable(name, variable)
+	}
+	for _, function := range interpreter.env.GetFunctions() {
+		if native, ok := function.(*ast.NativeFunction); ok {
+			child.env.AddFunction(native)
+		}
+	}
+
+	if err := child.parser.Parse(file); err != nil {
+		interpreter.errors = append(interpreter.errors, err)
+		return nil, false
+	}
+	child.fileName = file
+	defer child.enterFile()()
+	child.env.SetLimiter(interpreter.env.GetLimiter())
+	defer child.env.SetLimiter(nil)
+
+	if !child.prepare() {
+		interpreter.errors = append(interpreter.errors, child.errors...)
+		return nil, false
+	}
+	return &module{
+		path: file,
+		env:  child.env,
+
+		functions: child.functions,
+		structs:   child.structs,
+		globals:   child.globals,
+
+		main: child,
+	}, true
+}
+
+// Execute m by its first import, then expose its members under namespace.
+// Errors of the module are reported by the importer.
+func (interpreter *Interpreter) executeModule(m *module, path, namespace string,
+	location *common.Location) gerror.Error {
+	if child := m.main; child != nil {
+		m.main = nil
+		child.SetObserver(interpreter.env.GetObserver())
+		child.env.SetLimiter(interpreter.env.GetLimiter())
+		child.execute()
+		child.env.SetLimiter(nil)
+		if len(child.errors) > 0 {
+			m.failed = true
+			interpreter.errors = append(interpreter.errors, child.errors...)
+		}
+	}
+	if m.failed {
+		return gerror.NewImportError(path, "module has errors", location)
+	}
+	interpreter.expose(namespace, m)
+	return nil
+}
+
+// Qualified names of the members of m under namespace, variables assigned
+// by m and its functions and structs.
+func (m *module) members(namespace string) ([]string, map[string]ast.Function) {
+	variables := []string{}
+	for _, name := range m.globals {
+		variables = append(variables, namespace+"."+name)
+	}
+	functions := map[string]ast.Function{}
+	for _, function := range m.functions {
+		functions[namespace+"."+function.GetName()] = ast.NewModuleFunction(namespace, function, m.env)
+	}
+	for _, definition := range m.structs {
+		functions[namespace+"."+definition.GetName()] = definition
+	}
+	return variables, functions
+}
+
+// Expose global variables and functions of m under namespace.
+func (interpreter *Interpreter) expose(namespace string, m *module) {
+	for name, variable := range m.env.GetGlobalVariables() {
+		switch name {
+		case types.STDIN, types.STDOUT, types.STDERR:
+			continue
+		}
+		if strings.Contains(name, ".") {
+			// imported by the module itself
+			continue
+		}
+		interpreter.env.SetGlobalVariable(namespace+"."+name, variable)
+	}
+	_, functions := m.members(namespace)
+	for name, function := range functions {
+		interpreter.env.SetFunction(name, function)
+	}
+}
+
+// Identifier started by tok, which is qualified if it's a namespace followed
+// by a dot and a member name. Returns the tokens consumed after tok.
+func (interpreter *Interpreter) qualifiedIdentifier(tok *token.Token) (*types.Identifier, []*token.Token) {
+	name := tok.GetValue().(string)
+
+	dot := interpreter.nextToken()
+	if _, ok := interpreter.namespaces[name]; !ok || dot.GetType() != token.DOT_ID {
+		// the dot may access a field
+		interpreter.parser.RollBack(dot)
+		return types.NewIdentifier(name, tok.GetLocation()), []*token.Token{}
+	}
+
+	member := interpreter.nextToken()
+	if member.GetType() != token.IDENTIFIER_ID {
+		interpreter.parser.RollBack(member)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("%s should followed by identifier, not %s",
+				token.GetDescription(token.DOT_ID), token.GetDescription(member.GetType())),
+			member.GetLocation()))
+	}
+	return types.NewIdentifier(name+"."+member.GetValue().(string), tok.GetLocation()),
+		[]*token.Token{dot, member}
+}
@@ -0,0 +1,268 @@
+package interpreter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Write files of a module tree into a temporary directory, returns the directory.
+func writeModules(files map[string][]string, t *testing.T) string {
+	dir := t.TempDir()
+	for name, lines := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Can't create directory: %s", err.Error())
+		}
+		if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0644); err != nil {
+			t.Fatalf("Can't write module: %s", err.Error())
+		}
+	}
+	return dir
+}
+
+func TestImport(t *testing.T) {
+	dir := writeModules(map[string][]string{
+		"lib/util.gd": {
+			"Printf(\"loading util\\n\")",
+			"count = 0",
+			"def inc(n) {",
+			"    global count",
+			"    count += n",
+			"    return scale(count)",
+			"}",
+			"def scale(x) {",
+			"    return x * 10",
+			"}",
+		},
+		"other.gd": {
+			"import \"util.gd\" as u",
+			"def twice() {",
+			"    return u.inc(1) + u.inc(1)",
+			"}",
+		},
+		"main.gd": {
+			"import \"util.gd\"",
+			"import \"other.gd\";",
+			"Printf(\"%d %d\\n\", util.inc(2), util.count)",
+			"util.count = 5",
+			"util.count++",
+			"def f() {",
+			"    return util.count + other.twice()",
+			"}",
+			"Printf(\"%d %d\\n\", f(), util.count)",
+		},
+	}, t)
+	target := "loading util\n20 2\n156 8\n"
+
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: import with vm %v ...", vm)
+
+		output := &strings.Builder{}
+		inter := NewInterpreter()
+		inter.UseVM(vm)
+		inter.SetStdout(output)
+		inter.SetSearchPath([]string{filepath.Join(dir, "lib")})
+		if errs := inter.Interpret(filepath.Join(dir, "main.gd")); len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+		if output.String() != target {
+			t.Fatalf("Wrong output: Wanted (%s), got (%s)", target, output.String())
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestImportError(t *testing.T) {
+	dir := writeModules(map[string][]string{
+		"a.gd": {
+			"import \"b.gd\"",
+		},
+		"b.gd": {
+			"import \"a.gd\"",
+		},
+		"e.gd": {
+			"y = 1",
+		},
+		"c.gd": {
+			"import \"missing.gd\"",
+			"import \"a.gd\" as b",
+			"import \"e.gd\" as d",
+			"import \"e.gd\" as d",
+			"import \"a.gd\" as d",
+			"if (true) {",
+			"    import \"a.gd\"",
+			"}",
+			"x = d.y",
+		},
+		"f.gd": {
+			"def f() { return 1 + }",
+		},
+		"g.gd": {
+			"import \"missing.gd\" as m",
+			"import \"f.gd\"",
+			"x = m.f()",
+			"y = f.f(m.x) + m.y",
+		},
+	}, t)
+
+	t.Log("Test: import cycle ...")
+
+	errs := NewInterpreter().Interpret(filepath.Join(dir, "a.gd"))
+	targets := []string{
+		"Can't import a.gd: import cycle a.gd -> b.gd -> a.gd",
+		"Can't import b.gd: module has errors",
+	}
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+	}
+
+	t.Log("Passed")
+
+	t.Log("Test: import syntax error ...")
+
+	errs = NewInterpreter().Interpret(filepath.Join(dir, "c.gd"))
+	targets = []string{
+		"Can't import missing.gd: file not found",
+		"Can't import a.gd: import cycle a.gd -> b.gd -> a.gd",
+		"Can't import b.gd: module has errors",
+		"Can't import a.gd: module has errors",
+		"Can't import a.gd: namespace d is used by e.gd",
+		"Module can only be imported in top level",
+	}
+	lines := []int{1, 1, 1, 2, 5, 7}
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+		if err.GetLocation().GetLine() != lines[i] {
+			t.Fatalf("Wrong error line(%d): Wanted %d, got %d",
+				i, lines[i], err.GetLocation().GetLine())
+		}
+	}
+
+	t.Log("Passed")
+
+	t.Log("Test: members of failed import ...")
+
+	errs = NewInterpreter().Interpret(filepath.Join(dir, "g.gd"))
+	targets = []string{
+		"Can't import missing.gd: file not found",
+		"Missing operand for add",
+		"Can't import f.gd: module has errors",
+	}
+	lines = []int{1, 1, 2}
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+		if err.GetLocation().GetLine() != lines[i] {
+			t.Fatalf("Wrong error line(%d): Wanted %d, got %d",
+				i, lines[i], err.GetLocation().GetLine())
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestImportOrder(t *testing.T) {
+	dir := writeModules(map[string][]string{
+		"util.gd": {
+			"Printf(\"util\\n\")",
+			"x = 1",
+			"def get() {",
+			"    global x",
+			"    return x + 1",
+			"}",
+		},
+		"broken.gd": {
+			"Printf(\"broken\\n\")",
+			"zero = 0",
+			"y = 1 / zero",
+		},
+		"main.gd": {
+			"Printf(\"main first\\n\")",
+			"import \"util.gd\"",
+			"Printf(\"%d %d\\n\", util.x, util.get())",
+		},
+		"syntax.gd": {
+			"import \"util.gd\"",
+			"Printf(\"%d\\n\", util.x",
+		},
+		"runtime.gd": {
+			"Printf(\"main\\n\")",
+			"import \"broken.gd\"",
+			"Printf(\"after\\n\")",
+		},
+	}, t)
+
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: modules executed by import statements, vm %v ...", vm)
+
+		output := &strings.Builder{}
+		inter := NewInterpreter()
+		inter.UseVM(vm)
+		inter.SetStdout(output)
+		if errs := inter.Interpret(filepath.Join(dir, "main.gd")); len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+		if target := "main first\nutil\n1 2\n"; output.String() != target {
+			t.Fatalf("Wrong output: Wanted (%s), got (%s)", target, output.String())
+		}
+
+		t.Log("Passed")
+
+		t.Logf("Test: module not executed with syntax errors, vm %v ...", vm)
+
+		output.Reset()
+		inter = NewInterpreter()
+		inter.UseVM(vm)
+		inter.SetStdout(output)
+		if errs := inter.Interpret(filepath.Join(dir, "syntax.gd")); len(errs) == 0 {
+			t.Fatalf("There should be a syntax error")
+		}
+		if output.Len() != 0 {
+			t.Fatalf("Unexpected output: %s", output.String())
+		}
+
+		t.Log("Passed")
+
+		t.Logf("Test: module with runtime errors, vm %v ...", vm)
+
+		output.Reset()
+		inter = NewInterpreter()
+		inter.UseVM(vm)
+		inter.SetStdout(output)
+		errs := inter.Interpret(filepath.Join(dir, "runtime.gd"))
+		targets := []string{
+			"Division by zero",
+			"Can't import broken.gd: module has errors",
+		}
+		if len(errs) != len(targets) {
+			t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+		}
+		for i, err := range errs {
+			if err.GetMessage() != targets[i] {
+				t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+			}
+		}
+		if target := "main\nbroken\n"; output.String() != target {
+			t.Fatalf("Wrong output: Wanted (%s), got (%s)", target, output.String())
+		}
+
+		t.Log("Passed")
+	}
+}
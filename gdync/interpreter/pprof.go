@@ -0,0 +1,206 @@
+package interpreter
+
+import (
+	"compress/gzip"
+	"io"
+)
+
+//
+// pprof profile: a gzipped protocol buffer of the message Profile defined
+// in github.com/google/pprof/proto/profile.proto, encoded by hand.
+//
+
+// field numbers of the messages
+const (
+	fieldProfileSampleType        = 1
+	fieldProfileSample            = 2
+	fieldProfileLocation          = 4
+	fieldProfileFunction          = 5
+	fieldProfileStringTable       = 6
+	fieldProfileTimeNanos         = 9
+	fieldProfileDurationNanos     = 10
+	fieldProfilePeriodType        = 11
+	fieldProfilePeriod            = 12
+	fieldProfileDefaultSampleType = 14
+
+	fieldValueTypeType = 1
+	fieldValueTypeUnit = 2
+
+	fieldSampleLocationID = 1
+	fieldSampleValue      = 2
+
+	fieldLocationID   = 1
+	fieldLocationLine = 4
+
+	fieldLineFunctionID = 1
+	fieldLineLine       = 2
+
+	fieldFunctionID         = 1
+	fieldFunctionName       = 2
+	fieldFunctionSystemName = 3
+	fieldFunctionFilename   = 4
+)
+
+// protoBuffer encodes fields of a message.
+type protoBuffer struct {
+	data []byte
+}
+
+func (buffer *protoBuffer) varint(value uint64) {
+	for value >= 0x80 {
+		buffer.data = append(buffer.data, byte(value)|0x80)
+		value >>= 7
+	}
+	buffer.data = append(buffer.data, byte(value))
+}
+
+// wire type 0
+func (buffer *protoBuffer) uint64Field(field int, value uint64) {
+	if value == 0 {
+		return
+	}
+	buffer.varint(uint64(field) << 3)
+	buffer.varint(value)
+}
+
+func (buffer *protoBuffer) int64Field(field int, value int64) {
+	buffer.uint64Field(field, uint64(value))
+}
+
+// wire type 2
+func (buffer *protoBuffer) bytesField(field int, value []byte) {
+	buffer.varint(uint64(field)<<3 | 2)
+	buffer.varint(uint64(len(value)))
+	buffer.data = append(buffer.data, value...)
+}
+
+func (buffer *protoBuffer) stringField(field int, value string) {
+	buffer.bytesField(field, []byte(value))
+}
+
+func (buffer *protoBuffer) messageField(field int, message *protoBuffer) {
+	buffer.bytesField(field, message.data)
+}
+
+func (buffer *protoBuffer) packedField(field int, values []uint64) {
+	packed := &protoBuffer{}
+	for _, value := range values {
+		packed.varint(value)
+	}
+	buffer.bytesField(field, packed.data)
+}
+
+// pprofBuilder assigns ids to strings, functions and locations.
+type pprofBuilder struct {
+	profile   *protoBuffer
+	strings   map[string]int64
+	functions map[profileFrame]uint64 // by function key
+	locations map[profileFrame]uint64 // by frame
+}
+
+func newPprofBuilder() *pprofBuilder {
+	builder := &pprofBuilder{
+		profile:   &protoBuffer{},
+		strings:   map[string]int64{},
+		functions: map[profileFrame]uint64{},
+		locations: map[profileFrame]uint64{},
+	}
+	// the first string must be empty
+	builder.stringID("")
+	return builder
+}
+
+// Strings are appended to the table once they're used, since repeated
+// fields of a message can be interleaved.
+func (builder *pprofBuilder) stringID(value string) int64 {
+	if id, ok := builder.strings[value]; ok {
+		return id
+	}
+	id := int64(len(builder.strings))
+	builder.strings[value] = id
+	builder.profile.stringField(fieldProfileStringTable, value)
+	return id
+}
+
+func (builder *pprofBuilder) valueType(field int, typ, unit string) {
+	valueType := &protoBuffer{}
+	valueType.int64Field(fieldValueTypeType, builder.stringID(typ))
+	valueType.int64Field(fieldValueTypeUnit, builder.stringID(unit))
+	builder.profile.messageField(field, valueType)
+}
+
+func (builder *pprofBuilder) functionID(frame profileFrame) uint64 {
+	key := frame.functionKey()
+	if id, ok := builder.functions[key]; ok {
+		return id
+	}
+	id := uint64(len(builder.functions) + 1)
+	builder.functions[key] = id
+
+	// pprof strips a name in angle brackets like template parameters
+	name := key.function
+	if name == "<global>" {
+		name = "global"
+	}
+	function := &protoBuffer{}
+	function.uint64Field(fieldFunctionID, id)
+	function.int64Field(fieldFunctionName, builder.stringID(name))
+	function.int64Field(fieldFunctionSystemName, builder.stringID(name))
+	function.int64Field(fieldFunctionFilename, builder.stringID(key.file))
+	builder.profile.messageField(fieldProfileFunction, function)
+	return id
+}
+
+func (builder *pprofBuilder) locationID(frame profileFrame) uint64 {
+	if id, ok := builder.locations[frame]; ok {
+		return id
+	}
+	id := uint64(len(builder.locations) + 1)
+	builder.locations[frame] = id
+
+	line := &protoBuffer{}
+	line.uint64Field(fieldLineFunctionID, builder.functionID(frame))
+	line.int64Field(fieldLineLine, int64(frame.line))
+	location := &protoBuffer{}
+	location.uint64Field(fieldLocationID, id)
+	location.messageField(fieldLocationLine, line)
+	builder.profile.messageField(fieldProfileLocation, location)
+	return id
+}
+
+func (builder *pprofBuilder) sample(sample *profileSample) {
+	locations := make([]uint64, len(sample.frames))
+	for i, frame := range sample.frames {
+		locations[i] = builder.locationID(frame)
+	}
+	message := &protoBuffer{}
+	message.packedField(fieldSampleLocationID, locations)
+	message.packedField(fieldSampleValue, []uint64{uint64(sample.count), uint64(sample.time)})
+	builder.profile.messageField(fieldProfileSample, message)
+}
+
+// WriteProfile write the samples recorded as a pprof profile, which has
+// the count of statements executed and the time taken by them.
+func (profiler *Profiler) WriteProfile(writer io.Writer) error {
+	builder := newPprofBuilder()
+	builder.valueType(fieldProfileSampleType, "statements", "count")
+	builder.valueType(fieldProfileSampleType, "time", "nanoseconds")
+	for _, key := range profiler.sampleKeys() {
+		builder.sample(profiler.samples[key])
+	}
+
+	_, total := profiler.total()
+	if !profiler.start.IsZero() {
+		builder.profile.int64Field(fieldProfileTimeNanos, profiler.start.UnixNano())
+	}
+	builder.profile.int64Field(fieldProfileDurationNanos, int64(total))
+	builder.valueType(fieldProfilePeriodType, "time", "nanoseconds")
+	builder.profile.int64Field(fieldProfilePeriod, 1)
+	builder.profile.int64Field(fieldProfileDefaultSampleType, builder.stringID("time"))
+
+	gzipWriter := gzip.NewWriter(writer)
+	if _, err := gzipWriter.Write(builder.profile.data); err != nil {
+		return err
+	}
+	return gzipWriter.Close()
+}
@@ -0,0 +1,273 @@
+package interpreter
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+)
+
+//
+// Profiler: an observer which records the call stack of each statement
+// executed, and the time until the next one. Statements of native
+// functions are accounted to the statements calling them.
+//
+
+// A frame of the call stack, function runs the statement at line of file.
+type profileFrame struct {
+	function string
+	file     string
+	line     int
+}
+
+func (frame profileFrame) functionKey() profileFrame {
+	return profileFrame{function: frame.function, file: frame.file}
+}
+
+func (frame profileFrame) lineKey() profileFrame {
+	return profileFrame{file: frame.file, line: frame.line}
+}
+
+// Statements executed with the same call stack.
+type profileSample struct {
+	frames []profileFrame // from the innermost one
+	count  int64
+	time   time.Duration
+}
+
+type Profiler struct {
+	samples map[string]*profileSample
+	calls   map[profileFrame]int64 // by function key
+
+	current *profileSample     // sample of the statement running
+	envs    []*ast.Environment // call stack of the statement running
+	start   time.Time
+	last    time.Time // when the statement running started
+}
+
+func NewProfiler() *Profiler {
+	return &Profiler{
+		samples: map[string]*profileSample{},
+		calls:   map[profileFrame]int64{},
+	}
+}
+
+// Profile execute the file, whose statements and functions are recorded
+// by profiler.
+func (interpreter *Interpreter) Profile(file string, profiler *Profiler) []gerror.Error {
+	previous := interpreter.env.GetObserver()
+	interpreter.SetObserver(profiler)
+	defer interpreter.SetObserver(previous)
+
+	defer profiler.stop()
+	return interpreter.Interpret(file)
+}
+
+func (profiler *Profiler) OnStep(location *common.Location, env *ast.Environment) gerror.Error {
+	if location == nil {
+		return nil
+	}
+	now := time.Now()
+	if profiler.current == nil {
+		profiler.start = now
+	} else {
+		profiler.current.time += now.Sub(profiler.last)
+	}
+
+	frames, envs := profiler.callStack(location, env)
+	profiler.countCalls(frames, envs)
+	key := sampleKey(frames)
+	sample := profiler.samples[key]
+	if sample == nil {
+		sample = &profileSample{frames: frames}
+		profiler.samples[key] = sample
+	}
+	sample.count++
+
+	profiler.current, profiler.envs = sample, envs
+	// leave the time taken by the profiler out
+	profiler.last = time.Now()
+	return nil
+}
+
+// Executed returns false if no statement is profiled, like the execution
+// never starts because of syntax errors.
+func (profiler *Profiler) Executed() bool {
+	return !profiler.start.IsZero()
+}
+
+// Account the time of the last statement.
+func (profiler *Profiler) stop() {
+	if profiler.current != nil {
+		profiler.current.time += time.Since(profiler.last)
+		profiler.current, profiler.envs = nil, nil
+	}
+}
+
+// Returns the frames and scopes of the statement at location in env,
+// the stack ends at a call from a host.
+func (profiler *Profiler) callStack(location *common.Location,
+	env *ast.Environment) ([]profileFrame, []*ast.Environment) {
+	frames := []profileFrame{}
+	envs := []*ast.Environment{}
+	for env != nil && location != nil {
+		name := env.GetFunctionName()
+		if env.GetDepth() == 0 {
+			name = "<global>"
+		}
+		frames = append(frames, profileFrame{
+			function: name,
+			file:     location.GetFileName(),
+			line:     location.GetLine(),
+		})
+		envs = append(envs, env)
+		location = env.GetCallLocation()
+		env = env.GetCaller()
+	}
+	return frames, envs
+}
+
+// Each scope not in the previous call stack is a function called.
+func (profiler *Profiler) countCalls(frames []profileFrame, envs []*ast.Environment) {
+	for i, env := range envs {
+		if env.GetDepth() == 0 || containsEnv(profiler.envs, env) {
+			break
+		}
+		profiler.calls[frames[i].functionKey()]++
+	}
+}
+
+func containsEnv(envs []*ast.Environment, env *ast.Environment) bool {
+	for _, e := range envs {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+// Keys of samples in order, so that the profile is stable.
+func (profiler *Profiler) sampleKeys() []string {
+	keys := make([]string, 0, len(profiler.samples))
+	for key := range profiler.samples {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sampleKey(frames []profileFrame) string {
+	parts := make([]string, len(frames))
+	for i, frame := range frames {
+		parts[i] = fmt.Sprintf("%s@%s:%d", frame.function, frame.file, frame.line)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+//
+// Report
+//
+
+type profileStat struct {
+	name  string
+	count int64 // statements executed for lines, calls for functions
+	flat  time.Duration
+	cum   time.Duration // including the functions called
+}
+
+// Statistics of keys of each sample, keyOf maps a frame to its key, the
+// innermost one takes the count and flat time of the sample.
+func (profiler *Profiler) stats(keyOf func(profileFrame) profileFrame) map[profileFrame]*profileStat {
+	stats := map[profileFrame]*profileStat{}
+	stat := func(key profileFrame) *profileStat {
+		if stats[key] == nil {
+			stats[key] = &profileStat{}
+		}
+		return stats[key]
+	}
+	for _, sample := range profiler.samples {
+		leaf := stat(keyOf(sample.frames[0]))
+		leaf.count += sample.count
+		leaf.flat += sample.time
+
+		// a recursive function is accounted once
+		seen := map[profileFrame]bool{}
+		for _, frame := range sample.frames {
+			key := keyOf(frame)
+			if !seen[key] {
+				seen[key] = true
+				stat(key).cum += sample.time
+			}
+		}
+	}
+	return stats
+}
+
+func (profiler *Profiler) lineStats() []*profileStat {
+	result := []*profileStat{}
+	for key, stat := range profiler.stats(profileFrame.lineKey) {
+		stat.name = fmt.Sprintf("%s:%d", key.file, key.line)
+		result = append(result, stat)
+	}
+	return sortStats(result)
+}
+
+// Statistics of script functions, statements in global scope are left out.
+func (profiler *Profiler) functionStats() []*profileStat {
+	result := []*profileStat{}
+	for key, stat := range profiler.stats(profileFrame.functionKey) {
+		if key.function == "<global>" {
+			continue
+		}
+		stat.name = fmt.Sprintf("%s %s", key.function, key.file)
+		stat.count = profiler.calls[key]
+		result = append(result, stat)
+	}
+	return sortStats(result)
+}
+
+// Sort by cumulative time, the most expensive first.
+func sortStats(stats []*profileStat) []*profileStat {
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].cum != stats[j].cum {
+			return stats[i].cum > stats[j].cum
+		}
+		if stats[i].flat != stats[j].flat {
+			return stats[i].flat > stats[j].flat
+		}
+		return stats[i].name < stats[j].name
+	})
+	return stats
+}
+
+func (profiler *Profiler) total() (int64, time.Duration) {
+	var count int64
+	var total time.Duration
+	for _, sample := range profiler.samples {
+		count += sample.count
+		total += sample.time
+	}
+	return count, total
+}
+
+// WriteReport write the statistics of lines and functions to writer.
+func (profiler *Profiler) WriteReport(writer io.Writer) error {
+	count, total := profiler.total()
+	report := &strings.Builder{}
+	fmt.Fprintf(report, "Total: %d statements in %s\n", count, total)
+	fmt.Fprintf(report, "\n%10s %12s %12s  %s\n", "count", "flat", "cum", "line")
+	for _, stat := range profiler.lineStats() {
+		fmt.Fprintf(report, "%10d %12s %12s  %s\n", stat.count, stat.flat, stat.cum, stat.name)
+	}
+	fmt.Fprintf(report, "\n%10s %12s %12s  %s\n", "calls", "flat", "cum", "function")
+	for _, stat := range profiler.functionStats() {
+		fmt.Fprintf(report, "%10d %12s %12s  %s\n", stat.count, stat.flat, stat.cum, stat.name)
+	}
+	_, err := io.WriteString(writer, report.String())
+	return err
+}
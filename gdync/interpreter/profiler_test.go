@@ -0,0 +1,110 @@
+package interpreter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Returns the counts of the report by the line or function of each row.
+func parseReport(report string) map[string]int64 {
+	counts := map[string]int64{}
+	for _, row := range strings.Split(report, "\n") {
+		fields := strings.Fields(row)
+		if len(fields) < 4 {
+			continue
+		}
+		count, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		counts[strings.Join(fields[3:], " ")] = count
+	}
+	return counts
+}
+
+func TestProfiler(t *testing.T) {
+	fileName := writeScript(strings.Join(debuggedScript, "\n"), t)
+	base := filepath.Base(fileName)
+	target := map[string]int64{
+		fileName + ":2":   5,
+		fileName + ":3":   4,
+		fileName + ":5":   1,
+		fileName + ":7":   1,
+		fileName + ":8":   5, // the loop, then each check of its condition
+		fileName + ":9":   3,
+		fileName + ":11":  1,
+		"fib " + fileName: 5,
+	}
+
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: profiler, vm %v ...", vm)
+
+		profiler := NewProfiler()
+		inter := NewInterpreter()
+		inter.UseVM(vm)
+		inter.SetStdout(&bytes.Buffer{})
+		if errs := inter.Profile(fileName, profiler); len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+
+		if !profiler.Executed() {
+			t.Fatalf("Execution should be profiled")
+		}
+		report := &bytes.Buffer{}
+		if err := profiler.WriteReport(report); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+		counts := parseReport(report.String())
+		if len(counts) != len(target) {
+			t.Fatalf("Wrong report: %s", report.String())
+		}
+		for name, count := range target {
+			if counts[name] != count {
+				t.Fatalf("Wrong count of %s: Wanted %d, got %d", name, count, counts[name])
+			}
+		}
+		if !strings.HasPrefix(report.String(), "Total: 20 statements in ") {
+			t.Fatalf("Wrong total: %s", report.String())
+		}
+
+		profile := &bytes.Buffer{}
+		if err := profiler.WriteProfile(profile); err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+		reader, err := gzip.NewReader(profile)
+		if err != nil {
+			t.Fatalf("Invalid profile: %s", err.Error())
+		}
+		content, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatalf("Invalid profile: %s", err.Error())
+		}
+		for _, s := range []string{"fib", "global", base, "statements", "nanoseconds"} {
+			if !bytes.Contains(content, []byte(s)) {
+				t.Fatalf("%s isn't in profile", s)
+			}
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestProfilerNotExecuted(t *testing.T) {
+	t.Log("Test: profiler of a file with syntax errors ...")
+
+	fileName := writeScript("x = 1\ny = (x", t)
+	profiler := NewProfiler()
+	if errs := NewInterpreter().Profile(fileName, profiler); len(errs) == 0 {
+		t.Fatalf("There should be a syntax error")
+	}
+	if profiler.Executed() {
+		t.Fatalf("Execution shouldn't start")
+	}
+
+	t.Log("Passed")
+}
@@ -0,0 +1,115 @@
+package interpreter
+
+import (
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/token"
+)
+
+//
+// Error recovery: a compile error abandons the statement being created,
+// tokens are skipped until next statement boundary and creation goes on,
+// so that all syntax errors of a file can be reported at once.
+//
+
+// bailout is raised by compileError and recovered by recoverStatement.
+type bailout struct{}
+
+// Record a compile error and abandon current statement.
+func (interpreter *Interpreter) compileError(err gerror.Error) {
+	interpreter.errors = append(interpreter.errors, err)
+	panic(bailout{})
+}
+
+// Should be deferred by the creation of a statement. inBlock means the
+// statement is surrounded by large parentheses, whose right one must be
+// kept to finish the block.
+func (interpreter *Interpreter) recoverStatement(inBlock bool) {
+	if r := recover(); r != nil {
+		if _, ok := r.(bailout); !ok {
+			panic(r)
+		}
+		interpreter.synchronize(inBlock)
+	}
+}
+
+// Skip tokens until a semicolon, the end of a block, a keyword starting a
+// statement or a token in the line after the error, parentheses opened
+// after the error are skipped together.
+func (interpreter *Interpreter) synchronize(inBlock bool) {
+	parser := interpreter.parser
+
+	line := -1
+	if location := interpreter.errors[len(interpreter.errors)-1].GetLocation(); location != nil {
+		line = location.GetLine()
+	}
+
+	depth := 0
+	for {
+		tok, err := parser.Next()
+		if err != nil {
+			// parser has skipped the unsupported syntax
+			interpreter.errors = append(interpreter.errors, err)
+			continue
+		}
+
+		switch tok.GetType() {
+		case token.FINISHED_ID:
+			parser.RollBack(tok)
+			return
+		case token.SEMICOLON_ID:
+			if depth == 0 {
+				return
+			}
+		case token.ELIF_ID, token.ELSE_ID, token.CATCH_ID, token.FINALLY_ID:
+			// belong to the abandoned if or try statement
+		case token.LLP_ID:
+			depth++
+		case token.RLP_ID:
+			if depth == 0 {
+				if inBlock {
+					parser.RollBack(tok)
+				}
+				return
+			}
+			depth--
+			if depth == 0 && !interpreter.followedByElse() {
+				return
+			}
+		default:
+			if depth == 0 && (tok.GetLocation().GetLine() > line ||
+				startsStatement(tok.GetType())) {
+				parser.RollBack(tok)
+				return
+			}
+		}
+	}
+}
+
+// An abandoned if statement's elif and else blocks should be skipped too,
+// so are an abandoned try statement's catch and finally blocks.
+func (interpreter *Interpreter) followedByElse() bool {
+	tok, err := interpreter.parser.Next()
+	if err != nil {
+		interpreter.errors = append(interpreter.errors, err)
+		return false
+	}
+	interpreter.parser.RollBack(tok)
+	switch tok.GetType() {
+	case token.ELIF_ID, token.ELSE_ID, token.CATCH_ID, token.FINALLY_ID:
+		return true
+	default:
+		return false
+	}
+}
+
+func startsStatement(typ int) bool {
+	switch typ {
+	case token.FUNCTION_DEFINITION_ID, token.GLOBAL_ID, token.IF_ID, token.WHILE_ID,
+		token.FOR_ID, token.RETURN_ID, token.BREAK_ID, token.CONTINUE_ID,
+		token.TRY_ID, token.THROW_ID, token.IMPORT_ID, token.SWITCH_ID,
+		token.STRUCT_ID:
+		return true
+	default:
+		return false
+	}
+}
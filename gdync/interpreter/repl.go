@@ -0,0 +1,181 @@
+package interpreter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/clog"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+	"github.com/mlmhl/compiler/gdync/token"
+)
+
+//
+// Interactive session: read statements line by line, a statement which
+// opens a block is continued until all of its braces are closed, and while
+// the next line continues it by else, elif, catch or finally.
+//
+
+const (
+	replFileName = "<stdin>"
+
+	replPrompt         = ">>> "
+	replContinuePrompt = "... "
+)
+
+var continuationPattern *regexp.Regexp = regexp.MustCompile(`^\s*(else|elif|catch|finally)\b`)
+
+// Lines of an interactive session, a line read ahead is kept for the next input.
+type replInput struct {
+	scanner *bufio.Scanner
+	next    *string
+}
+
+func (input *replInput) scan() (string, bool) {
+	if line := input.next; line != nil {
+		input.next = nil
+		return *line, true
+	}
+	if !input.scanner.Scan() {
+		return "", false
+	}
+	return input.scanner.Text(), true
+}
+
+// Repl run an interactive session, global variables and functions are
+// kept across inputs. Output of scripts is redirected to output.
+func (interpreter *Interpreter) Repl(input io.Reader, output io.Writer) {
+	logger := clog.NewWriterLogger(output)
+	interpreter.SetStdout(output)
+
+	lines := &replInput{scanner: bufio.NewScanner(input)}
+	for {
+		source, ok := readInput(lines, output)
+		if !ok {
+			break
+		}
+		interpreter.evaluate(source, output)
+		logger.Errors(interpreter.errors)
+	}
+}
+
+// Read lines until all opened braces are closed, a block is continued if
+// the next line starts with else, elif, catch or finally. Returns false if
+// there is no more input.
+func readInput(input *replInput, output io.Writer) (string, bool) {
+	lines := []string{}
+	depth := 0
+	block := false
+
+	if input.next == nil {
+		io.WriteString(output, replPrompt)
+	}
+	for {
+		line, ok := input.scan()
+		if !ok {
+			break
+		}
+		lines = append(lines, line)
+
+		depth += braceDepth(line)
+		block = block || strings.Contains(stripLine(line), "{")
+		if depth > 0 {
+			io.WriteString(output, replContinuePrompt)
+		} else if !block || !continued(input, output) {
+			break
+		}
+	}
+
+	if len(lines) == 0 {
+		// input finished
+		io.WriteString(output, "\n")
+		return "", false
+	}
+	return strings.Join(lines, "\n"), true
+}
+
+// Read the next line after a closed block, returns true if it continues
+// the block. Otherwise it's kept for the next input, an empty line ends the
+// block and is dropped.
+func continued(input *replInput, output io.Writer) bool {
+	io.WriteString(output, replContinuePrompt)
+	line, ok := input.scan()
+	if !ok {
+		return false
+	}
+	if continuationPattern.MatchString(line) {
+		input.next = &line
+		return true
+	}
+	if strings.TrimSpace(line) != "" {
+		input.next = &line
+	}
+	return false
+}
+
+// Count of left large parentheses minus right large parentheses in line,
+// ignore the parentheses in comment and string.
+func braceDepth(line string) int {
+	code := stripLine(line)
+	return strings.Count(code, "{") - strings.Count(code, "}")
+}
+
+// Returns line without its comment and string literals.
+func stripLine(line string) string {
+	code := []byte{}
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch {
+		case inString && line[i] == '\\':
+			// skip the escaped character
+			i++
+		case line[i] == '"':
+			inString = !inString
+		case inString:
+		case strings.HasPrefix(line[i:], token.COMMENT):
+			// comment till the end of line
+			return string(code)
+		default:
+			code = append(code, line[i])
+		}
+	}
+	return string(code)
+}
+
+func (interpreter *Interpreter) evaluate(source string, output io.Writer) {
+	interpreter.parser.ParseReader(replFileName, strings.NewReader(source))
+	if !interpreter.prepare() {
+		return
+	}
+
+	for _, statement := range interpreter.statements {
+		value, err := interpreter.executeStatement(statement)
+		if err != nil {
+			interpreter.errors = append(interpreter.errors, err)
+			return
+		}
+		if value = echoValue(statement, value); value != nil {
+			fmt.Fprintln(output, value.String())
+		}
+	}
+}
+
+// Only the value of a bare expression statement should be echoed,
+// assignments and null values keep silent.
+func echoValue(statement ast.Statement, value types.Value) types.Value {
+	expressionStatement, ok := statement.(*ast.ExpressionStatement)
+	if !ok {
+		return nil
+	}
+	if _, ok = expressionStatement.GetExpression().(*ast.AssignExpression); ok {
+		return nil
+	}
+
+	if value.GetType() == types.NULL_TYPE {
+		return nil
+	}
+	return value
+}
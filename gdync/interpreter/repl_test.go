@@ -0,0 +1,81 @@
+package interpreter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRepl(t *testing.T) {
+	t.Log("Test: Repl ...")
+
+	input := strings.Join([]string{
+		"x = 3",
+		"x * 2",
+		"def add(a, b) {",
+		"    return a + b",
+		"}",
+		"add(x, 4)",
+		")",
+		"undefined",
+		"if (x > 1) {",
+		"    z = \"{\"",
+		"}",
+		"\"a\" + x",
+		"if (x > 5) { // {",
+		"    Printf(\"big\\n\")",
+		"}",
+		"else {",
+		"    Printf(\"small\\n\")",
+		"}",
+		"",
+		"try { throw \"e\" }",
+		"  catch (e) { Printf(\"caught %s\\n\", e) }",
+		"finally { Printf(\"finally\\n\") }",
+		"x",
+	}, "\n")
+	output := &bytes.Buffer{}
+
+	NewInterpreter().Repl(strings.NewReader(input), output)
+
+	target := strings.Join([]string{
+		">>> >>> 6",
+		">>> ... ... ... 7",
+		">>> <stdin>,1,0: Unexpected right small parentheses, should be a statement",
+		">>> <stdin>,1,0: Undefined variable undefined",
+		">>> ... ... ... a3",
+		">>> ... ... ... ... ... ... small",
+		">>> ... ... ... caught e",
+		"finally",
+		"3",
+		">>> ",
+		"",
+	}, "\n")
+	if output.String() != target {
+		t.Fatalf("Wrong output: Wanted\n%s\ngot\n%s", target, output.String())
+	}
+
+	t.Log("Passed")
+}
+
+func TestBraceDepth(t *testing.T) {
+	t.Log("Test: braceDepth ...")
+
+	lines := map[string]int{
+		"while (i < 3) {":      1,
+		"}":                    -1,
+		"} elif (x) {":         0,
+		"Printf(\"{\\\"{\")":   0,
+		"// if (x) {":          0,
+		"x = 1 // {":           0,
+		"if (s == \"//\") {":   1,
+		"def f() { if (a) { {": 3,
+	}
+	for line, target := range lines {
+		if depth := braceDepth(line); depth != target {
+			t.Fatalf("Wrong depth of %s: Wanted %d, got %d", line, target, depth)
+		}
+	}
+
+	t.Log("Passed")
+}
@@ -2,6 +2,7 @@ package interpreter
 
 import (
 	"fmt"
+	"math/big"
 
 	"github.com/mlmhl/compiler/common"
 	gerror "github.com/mlmhl/compiler/gdync/errors"
@@ -14,7 +15,9 @@ func (interpreter *Interpreter) compileUnit() {
 	for {
 		tok, err := interpreter.parser.Next()
 		if err != nil {
-			interpreter.logger.CompileError(err)
+			// parser has skipped the unsupported syntax
+			interpreter.errors = append(interpreter.errors, err)
+			continue
 		}
 		if tok.GetType() == token.FINISHED_ID {
 			break
@@ -27,9 +30,11 @@ func (interpreter *Interpreter) compileUnit() {
 }
 
 func (interpreter *Interpreter) definitionOrStatement() {
+	defer interpreter.recoverStatement(false)
+
 	tok, err := interpreter.parser.Next()
 	if err != nil {
-		interpreter.logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 
 	typ := tok.GetType()
@@ -37,16 +42,23 @@ func (interpreter *Interpreter) definitionOrStatement() {
 
 	if typ == token.FINISHED_ID {
 		return
+	} else if typ == token.IMPORT_ID {
+		// modules are compiled while creating, so that their members can be
+		// resolved, and executed by the import statements
+		interpreter.importStatement()
+	} else if typ == token.STRUCT_ID {
+		// structs are added to env after all passes succeed
+		interpreter.structs = append(interpreter.structs, interpreter.structDefinition())
 	} else if typ == token.FUNCTION_DEFINITION_ID {
-		interpreter.env.AddFunction(interpreter.functionDefinition())
+		// functions are added to env after all passes succeed
+		interpreter.functions = append(interpreter.functions, interpreter.functionDefinition())
 	} else {
 		interpreter.statements = append(interpreter.statements, interpreter.statement())
 	}
 }
 
-func (interpreter *Interpreter) functionDefinition() ast.Function {
+func (interpreter *Interpreter) functionDefinition() *ast.CustomFunction {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
@@ -56,10 +68,11 @@ func (interpreter *Interpreter) functionDefinition() ast.Function {
 	parser.Next()
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 	if tok.GetType() != token.IDENTIFIER_ID {
-		logger.CompileError(gerror.NewSyntaxError(
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
 			fmt.Sprintf("%s should followed by function identifier",
 				token.GetDescription(token.FUNCTION_DEFINITION_ID)), tok.GetLocation()))
 	}
@@ -72,18 +85,95 @@ func (interpreter *Interpreter) functionDefinition() ast.Function {
 	return ast.NewCustomFunction(identifier, parameters, block)
 }
 
+func (interpreter *Interpreter) structDefinition() *ast.StructDefinition {
+	parser := interpreter.parser
+
+	// Next token's type must be STRUCT_ID
+	parser.Next()
+
+	tok := interpreter.nextToken()
+	if tok.GetType() != token.IDENTIFIER_ID {
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("%s should followed by struct identifier",
+				token.GetDescription(token.STRUCT_ID)), tok.GetLocation()))
+	}
+	identifier := types.NewIdentifier(tok.GetValue().(string), tok.GetLocation())
+
+	return ast.NewStructDefinition(identifier, interpreter.fieldList(identifier))
+}
+
+// Fields separated by comma in large parentheses, a duplicated
+// field is reported, but the creation goes on.
+func (interpreter *Interpreter) fieldList(identifier *types.Identifier) []*types.Identifier {
+	parser := interpreter.parser
+
+	tok := interpreter.nextToken()
+	if tok.GetType() != token.LLP_ID {
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Struct fields should start with %s, not %s",
+				token.GetDescription(token.LLP_ID), token.GetDescription(tok.GetType())),
+			tok.GetLocation()))
+	}
+
+	fields := []*types.Identifier{}
+	if tok = interpreter.nextToken(); tok.GetType() == token.RLP_ID {
+		// no fields
+		return fields
+	}
+	parser.RollBack(tok)
+
+	for {
+		tok = interpreter.nextToken()
+		if tok.GetType() != token.IDENTIFIER_ID {
+			parser.RollBack(tok)
+			interpreter.compileError(gerror.NewSyntaxError(
+				fmt.Sprintf("%s can't be used as struct field",
+					token.GetDescription(tok.GetType())), tok.GetLocation()))
+		}
+		field := types.NewIdentifier(tok.GetValue().(string), tok.GetLocation())
+
+		duplicated := false
+		for _, f := range fields {
+			if f.GetName() == field.GetName() {
+				interpreter.errors = append(interpreter.errors,
+					gerror.NewFieldDuplicateDefinitionError(identifier.GetName(),
+						field.GetName(), f.GetLocation(), field.GetLocation()))
+				duplicated = true
+			}
+		}
+		if !duplicated {
+			fields = append(fields, field)
+		}
+
+		tok = interpreter.nextToken()
+		if tok.GetType() == token.RLP_ID {
+			break
+		} else if tok.GetType() != token.COMMA_ID {
+			parser.RollBack(tok)
+			interpreter.compileError(gerror.NewSyntaxError(
+				fmt.Sprintf("Struct fields should be separated by %s, not %s",
+					token.GetDescription(token.COMMA_ID), token.GetDescription(tok.GetType())),
+				tok.GetLocation()))
+		}
+	}
+
+	return fields
+}
+
 func (interpreter *Interpreter) parameterList() []*ast.Parameter {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 	if tok.GetType() != token.LSP_ID {
-		logger.CompileError(gerror.NewSyntaxError(
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
 			fmt.Sprintf("Function parameters should start by %s",
 				token.GetDescription(token.LSP_ID)), tok.GetLocation()))
 	}
@@ -91,7 +181,7 @@ func (interpreter *Interpreter) parameterList() []*ast.Parameter {
 	parameters := []*ast.Parameter{}
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	} else {
 		if tok.GetType() == token.RSP_ID {
 			// no parameters
@@ -103,7 +193,7 @@ func (interpreter *Interpreter) parameterList() []*ast.Parameter {
 
 	for {
 		if tok, err = parser.Next(); err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 
 		// token's type won't be RSP_ID
@@ -111,25 +201,28 @@ func (interpreter *Interpreter) parameterList() []*ast.Parameter {
 			parameters = append(parameters, ast.NewParameter(
 				types.NewIdentifier(tok.GetValue().(string), tok.GetLocation())))
 		} else {
-			logger.CompileError(gerror.NewSyntaxError(
+			parser.RollBack(tok)
+			interpreter.compileError(gerror.NewSyntaxError(
 				fmt.Sprintf("%s can't be used as function parameter",
 					token.GetDescription(tok.GetType())), tok.GetLocation()))
 		}
 
 		if tok, err = parser.Next(); err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		} else if tok.GetType() == token.RSP_ID {
 			// parameters definition finished
 			break
-		} else if tok.GetType() != token.FINISHED_ID {
+		} else if tok.GetType() == token.FINISHED_ID {
 			// no RSP_ID found to finish parameters definition
-			logger.CompileError(gerror.NewSyntaxError(
+			parser.RollBack(tok)
+			interpreter.compileError(gerror.NewSyntaxError(
 				fmt.Sprintf("Function parameter should ended by %s",
-					token.GetDescription(tok.GetType())), tok.GetLocation(),
+					token.GetDescription(token.RSP_ID)), tok.GetLocation(),
 			))
 		} else if tok.GetType() != token.COMMA_ID {
 			// skip comma
-			logger.CompileError(gerror.NewSyntaxError(
+			parser.RollBack(tok)
+			interpreter.compileError(gerror.NewSyntaxError(
 				fmt.Sprintf("%s can't be used as function paramter",
 					token.GetDescription(tok.GetType())), tok.GetLocation()))
 		}
@@ -143,20 +236,20 @@ func (interpreter *Interpreter) block() *ast.Block {
 	var err gerror.Error
 
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 
 	if tok.GetType() != token.LLP_ID {
-		logger.CompileError(gerror.NewSyntaxError(
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
 			fmt.Sprintf("Block should start with "+
 				token.GetDescription(token.LLP_ID)), tok.GetLocation()))
 	}
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 	if tok.GetType() == token.RLP_ID {
 		return ast.NewBlock([]ast.Statement{})
@@ -166,15 +259,21 @@ func (interpreter *Interpreter) block() *ast.Block {
 
 	block := ast.NewBlock(interpreter.statementList())
 
-	// Next token's type must be RLP_ID, skip it
-	parser.Next()
+	if tok, err = parser.Next(); err != nil {
+		interpreter.compileError(err)
+	}
+	if tok.GetType() != token.RLP_ID {
+		// source finished before the block
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			"Block should stop with "+token.GetDescription(token.RLP_ID), tok.GetLocation()))
+	}
 
 	return block
 }
 
 func (interpreter *Interpreter) statementList() []ast.Statement {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
@@ -182,15 +281,17 @@ func (interpreter *Interpreter) statementList() []ast.Statement {
 	statements := []ast.Statement{}
 
 	for {
-		statements = append(statements, interpreter.statement())
+		if statement := interpreter.blockStatement(); statement != nil {
+			statements = append(statements, statement)
+		}
 
 		if tok, err = parser.Next(); err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		typ := tok.GetType()
 		parser.RollBack(tok)
 
-		if typ == token.RLP_ID {
+		if typ == token.RLP_ID || typ == token.FINISHED_ID {
 			break
 		}
 	}
@@ -198,21 +299,42 @@ func (interpreter *Interpreter) statementList() []ast.Statement {
 	return statements
 }
 
+// Create a statement inside a block, returns nil if it's abandoned.
+func (interpreter *Interpreter) blockStatement() (statement ast.Statement) {
+	defer interpreter.recoverStatement(true)
+	return interpreter.statement()
+}
+
 func (interpreter *Interpreter) statement() ast.Statement {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 
 	typ := tok.GetType()
 	parser.RollBack(tok)
 
 	switch typ {
+	case token.FUNCTION_DEFINITION_ID:
+		// skip the keyword, so that it won't be seen while recovering
+		parser.Next()
+		interpreter.compileError(gerror.NewSyntaxError(
+			"Function can only be defined in top level", tok.GetLocation()))
+		return nil
+	case token.STRUCT_ID:
+		parser.Next()
+		interpreter.compileError(gerror.NewSyntaxError(
+			"Struct can only be defined in top level", tok.GetLocation()))
+		return nil
+	case token.IMPORT_ID:
+		parser.Next()
+		interpreter.compileError(gerror.NewSyntaxError(
+			"Module can only be imported in top level", tok.GetLocation()))
+		return nil
 	case token.GLOBAL_ID:
 		return interpreter.globalStatement()
 	case token.IF_ID:
@@ -221,12 +343,18 @@ func (interpreter *Interpreter) statement() ast.Statement {
 		return interpreter.whileStatement()
 	case token.FOR_ID:
 		return interpreter.forStatement()
+	case token.SWITCH_ID:
+		return interpreter.switchStatement()
 	case token.RETURN_ID:
 		return interpreter.returnStatement()
 	case token.BREAK_ID:
 		return interpreter.breakStatement()
 	case token.CONTINUE_ID:
 		return interpreter.continueStatement()
+	case token.TRY_ID:
+		return interpreter.tryStatement()
+	case token.THROW_ID:
+		return interpreter.throwStatement()
 	default:
 		return interpreter.expressionStatement()
 	}
@@ -242,20 +370,20 @@ func (interpreter *Interpreter) globalStatement() *ast.GlobalStatement {
 
 	tok, err := parser.Next()
 	if err != nil {
-		interpreter.logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 	if tok.GetType() == token.SEMICOLON_ID {
 		return statement
 	}
+	parser.RollBack(tok)
 
 	statement.SetIdentifiers(interpreter.identifierList())
 
 	// If next token's type is SEMICOLON_ID, skip it
 	if tok, err = parser.Next(); err != nil {
-		interpreter.logger.CompileError(err)
-	} else {
-		if tok.GetType() != token.SEMICOLON_ID {}
-		interpreter.parser.RollBack(tok)
+		interpreter.compileError(err)
+	} else if tok.GetType() != token.SEMICOLON_ID {
+		parser.RollBack(tok)
 	}
 
 	return statement
@@ -263,7 +391,6 @@ func (interpreter *Interpreter) globalStatement() *ast.GlobalStatement {
 
 func (interpreter *Interpreter) identifierList() []*types.Identifier {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
@@ -272,25 +399,26 @@ func (interpreter *Interpreter) identifierList() []*types.Identifier {
 
 	for {
 		if tok, err = parser.Next(); err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if tok.GetType() == token.IDENTIFIER_ID {
 			identifiers = append(identifiers, types.NewIdentifier(
 				tok.GetValue().(string), tok.GetLocation()))
 		} else {
-			logger.CompileError(gerror.NewSyntaxError(
+			parser.RollBack(tok)
+			interpreter.compileError(gerror.NewSyntaxError(
 				fmt.Sprintf("In global statement, should be %s, not %s",
 					token.GetDescription(token.IDENTIFIER_ID),
 					token.GetDescription(tok.GetType())), tok.GetLocation()))
 		}
 
+		// identifiers are separated by comma
 		if tok, err = parser.Next(); err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if tok.GetType() != token.COMMA_ID {
 			parser.RollBack(tok)
-			logger.CompileError(gerror.NewSyntaxError(
-				"Invalid syntax in global statement", tok.GetLocation()))
+			break
 		}
 	}
 
@@ -316,14 +444,13 @@ func (interpreter *Interpreter) ifStatement() *ast.IfStatement {
 
 func (interpreter *Interpreter) elifStatement(statement *ast.IfStatement) {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
 
 	for {
 		if tok, err = parser.Next(); err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 		if tok.GetType() != token.ELIF_ID {
 			parser.RollBack(tok)
@@ -337,17 +464,17 @@ func (interpreter *Interpreter) elifStatement(statement *ast.IfStatement) {
 
 func (interpreter *Interpreter) elseStatement() (*ast.Block, *common.Location) {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 	if tok.GetType() == token.ELSE_ID {
 		return interpreter.block(), tok.GetLocation()
 	} else {
+		parser.RollBack(tok)
 		return nil, nil
 	}
 }
@@ -362,16 +489,16 @@ func (interpreter *Interpreter) whileStatement() *ast.WhileStatement {
 
 func (interpreter *Interpreter) conditionExpression() ast.Expression {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 	if tok.GetType() != token.LSP_ID {
-		logger.CompileError(gerror.NewSyntaxError(
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
 			fmt.Sprintf("Condition expression should start with %s, not %s",
 				token.GetDescription(token.LSP_ID), token.GetDescription(tok.GetType())),
 			tok.GetLocation()))
@@ -380,10 +507,11 @@ func (interpreter *Interpreter) conditionExpression() ast.Expression {
 	expression := interpreter.expression()
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 	if tok.GetType() != token.RSP_ID {
-		logger.CompileError(gerror.NewSyntaxError(
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
 			fmt.Sprintf("Condition expression should stopped with %s, not %s",
 				token.GetDescription(token.RSP_ID), token.GetDescription(tok.GetType())),
 			tok.GetLocation()))
@@ -392,9 +520,16 @@ func (interpreter *Interpreter) conditionExpression() ast.Expression {
 	return expression
 }
 
-func (interpreter *Interpreter) forStatement() *ast.ForStatement {
+// Create a for statement, or a foreach statement if the loop variable
+// is followed by in.
+func (interpreter *Interpreter) forStatement() ast.Statement {
 	// Next token's type must be FOR_ID
 	tok, _ := interpreter.parser.Next()
+	if identifier, ok := interpreter.foreachVariable(); ok {
+		collection := interpreter.foreachCollection()
+		return ast.NewForeachStatement(tok.GetLocation(), identifier,
+			collection, interpreter.block())
+	}
 	statement := ast.NewForStatement(tok.GetLocation())
 
 	interpreter.forExpression(statement)
@@ -404,18 +539,77 @@ func (interpreter *Interpreter) forStatement() *ast.ForStatement {
 	return statement
 }
 
+// Consume the left small parentheses, loop variable and in if they start
+// a foreach expression, otherwise nothing is consumed.
+func (interpreter *Interpreter) foreachVariable() (*types.Identifier, bool) {
+	parser := interpreter.parser
+
+	consumed := []*token.Token{}
+	rollback := func() (*types.Identifier, bool) {
+		for i := len(consumed) - 1; i >= 0; i-- {
+			parser.RollBack(consumed[i])
+		}
+		return nil, false
+	}
+
+	for _, typ := range []int{token.LSP_ID, token.IDENTIFIER_ID, token.IN_ID} {
+		tok, err := parser.Next()
+		if err != nil {
+			rollback()
+			interpreter.compileError(err)
+		}
+		consumed = append(consumed, tok)
+		if tok.GetType() != typ {
+			return rollback()
+		}
+	}
+
+	return types.NewIdentifier(consumed[1].GetValue().(string), consumed[1].GetLocation()), true
+}
+
+func (interpreter *Interpreter) foreachCollection() ast.Expression {
+	parser := interpreter.parser
+
+	tok, err := parser.Next()
+	if err != nil {
+		interpreter.compileError(err)
+	}
+	parser.RollBack(tok)
+
+	collection := interpreter.expression()
+	if collection == nil {
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("%s should followed by a collection, not %s",
+				token.GetDescription(token.IN_ID), token.GetDescription(tok.GetType())),
+			tok.GetLocation()))
+	}
+
+	if tok, err = parser.Next(); err != nil {
+		interpreter.compileError(err)
+	}
+	if tok.GetType() != token.RSP_ID {
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Foreach expression should stopped with %s, not %s",
+				token.GetDescription(token.RSP_ID), token.GetDescription(tok.GetType())),
+			tok.GetLocation()))
+	}
+
+	return collection
+}
+
 func (interpreter *Interpreter) forExpression(statement *ast.ForStatement) {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 	if tok.GetType() != token.LSP_ID {
-		logger.CompileError(gerror.NewSyntaxError(
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
 			fmt.Sprintf("For expression  must started by %s, bot %s",
 				token.GetDescription(token.LSP_ID), token.GetDescription(tok.GetType())),
 			tok.GetLocation()))
@@ -425,19 +619,21 @@ func (interpreter *Interpreter) forExpression(statement *ast.ForStatement) {
 
 	for {
 		if tok, err = parser.Next(); err != nil {
-			logger.CompileError(err)
+			interpreter.compileError(err)
 		}
 
 		if tok.GetType() == token.RSP_ID {
 			if len(expressions) != 3 {
-				logger.CompileError(gerror.NewSyntaxError(
+				parser.RollBack(tok)
+				interpreter.compileError(gerror.NewSyntaxError(
 					fmt.Sprintf("For expression need init, condition and post, " +
 					"only found %d expressions", len(expressions)), tok.GetLocation()))
 			}
 			break
 		} else {
 			if len(expressions) >= 3 {
-				logger.CompileError(gerror.NewSyntaxError(
+				parser.RollBack(tok)
+				interpreter.compileError(gerror.NewSyntaxError(
 					fmt.Sprintf("For expression only need init, condition and post, " +
 					"but found %d expressions", len(expressions) + 1), tok.GetLocation()))
 			}
@@ -451,6 +647,182 @@ func (interpreter *Interpreter) forExpression(statement *ast.ForStatement) {
 	statement.SetPost(expressions[2])
 }
 
+func (interpreter *Interpreter) switchStatement() *ast.SwitchStatement {
+	parser := interpreter.parser
+
+	// Next token's type must be SWITCH_ID
+	tok, _ := parser.Next()
+	statement := ast.NewSwitchStatement(interpreter.conditionExpression(), tok.GetLocation())
+
+	if tok = interpreter.nextToken(); tok.GetType() != token.LLP_ID {
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Switch body should start with %s, not %s",
+				token.GetDescription(token.LLP_ID), token.GetDescription(tok.GetType())),
+			tok.GetLocation()))
+	}
+
+	clauses := 0
+	for {
+		tok = interpreter.nextToken()
+		parser.RollBack(tok)
+
+		switch tok.GetType() {
+		case token.RLP_ID:
+			parser.Next()
+			return statement
+		case token.FINISHED_ID:
+			interpreter.compileError(gerror.NewSyntaxError(
+				fmt.Sprintf("Switch body should ended with %s",
+					token.GetDescription(token.RLP_ID)), tok.GetLocation()))
+		case token.CASE_ID, token.DEFAULT_ID:
+			interpreter.switchClause(statement)
+			clauses++
+		default:
+			if clauses == 0 {
+				interpreter.compileError(gerror.NewSyntaxError(
+					fmt.Sprintf("Switch body should start with %s or %s, not %s",
+						token.GetDescription(token.CASE_ID), token.GetDescription(token.DEFAULT_ID),
+						token.GetDescription(tok.GetType())), tok.GetLocation()))
+			}
+			// statements of an abandoned clause
+			interpreter.blockStatement()
+		}
+	}
+}
+
+// Create a case or default clause, whose statements are ended by next
+// clause or the end of switch statement.
+func (interpreter *Interpreter) switchClause(statement *ast.SwitchStatement) {
+	defer interpreter.recoverStatement(true)
+
+	parser := interpreter.parser
+
+	// Next token's type must be CASE_ID or DEFAULT_ID
+	tok, _ := parser.Next()
+	location := tok.GetLocation()
+
+	var values []types.Value
+	var locations []*common.Location
+	if tok.GetType() == token.CASE_ID {
+		values, locations = interpreter.caseValues(statement)
+	} else if first := statement.GetDefaultLocation(); first != nil {
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Duplicated default in switch, at %s, %d, %d", first.GetFileName(),
+				first.GetLine(), first.GetPosition()), location))
+	}
+
+	if tok = interpreter.nextToken(); tok.GetType() != token.COLON_ID {
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Case should followed by %s, not %s",
+				token.GetDescription(token.COLON_ID), token.GetDescription(tok.GetType())),
+			tok.GetLocation()))
+	}
+
+	statements := []ast.Statement{}
+	for {
+		tok = interpreter.nextToken()
+		parser.RollBack(tok)
+		typ := tok.GetType()
+		if typ == token.CASE_ID || typ == token.DEFAULT_ID ||
+			typ == token.RLP_ID || typ == token.FINISHED_ID {
+			break
+		}
+		if s := interpreter.blockStatement(); s != nil {
+			statements = append(statements, s)
+		}
+	}
+
+	if values == nil {
+		statement.SetDefaultBlock(ast.NewBlock(statements), location)
+	} else {
+		statement.AddCase(values, locations, ast.NewBlock(statements))
+	}
+}
+
+// Constants separated by comma, a constant used by previous cases is
+// reported, but the creation goes on.
+func (interpreter *Interpreter) caseValues(statement *ast.SwitchStatement) (
+	[]types.Value, []*common.Location) {
+	values := []types.Value{}
+	locations := []*common.Location{}
+
+	for {
+		tok := interpreter.nextToken()
+		value := interpreter.caseValue(tok)
+
+		first := statement.GetCaseLocation(value)
+		for i, v := range values {
+			if ast.MatchCase(value, v) {
+				first = locations[i]
+			}
+		}
+		if first != nil {
+			interpreter.errors = append(interpreter.errors, gerror.NewCaseDuplicateDefinitionError(
+				value.String(), first, tok.GetLocation()))
+		} else {
+			values = append(values, value)
+			locations = append(locations, tok.GetLocation())
+		}
+
+		if tok = interpreter.nextToken(); tok.GetType() != token.COMMA_ID {
+			interpreter.parser.RollBack(tok)
+			return values, locations
+		}
+	}
+}
+
+// A case constant is a string, a number, a bool or null started by tok.
+func (interpreter *Interpreter) caseValue(tok *token.Token) types.Value {
+	negative := tok.GetType() == token.SUBTRACT_ID
+	if negative {
+		tok = interpreter.nextToken()
+	}
+
+	switch tok.GetType() {
+	case token.INTEGER_ID:
+		var value types.Value
+		if b, ok := tok.GetValue().(*big.Int); ok {
+			value = types.NewBigInteger(b)
+		} else {
+			value = types.NewValue(types.INTEGER_TYPE, tok.GetValue())
+		}
+		if negative {
+			return types.NegateInteger(value)
+		}
+		return value
+	case token.FLOAT_ID:
+		if negative {
+			return types.NewValue(types.FLOAT_TYPE, -tok.GetValue().(float64))
+		}
+		return types.NewValue(types.FLOAT_TYPE, tok.GetValue().(float64))
+	}
+
+	if !negative {
+		switch tok.GetType() {
+		case token.STRING_ID:
+			expression, err := ast.NewStringExpression(tok.GetValue().(string))
+			if err != nil {
+				err.SetLocation(tok.GetLocation())
+				interpreter.compileError(err)
+			}
+			value, _ := expression.Evaluate(nil)
+			return value
+		case token.TRUE_ID, token.FALSE_ID:
+			return types.NewValue(types.BOOL_TYPE, tok.GetValue().(bool))
+		case token.NULL_ID:
+			return types.NewValue(types.NULL_TYPE, nil)
+		}
+	}
+
+	interpreter.parser.RollBack(tok)
+	interpreter.compileError(gerror.NewSyntaxError(
+		fmt.Sprintf("Case value should be a constant, not %s",
+			token.GetDescription(tok.GetType())), tok.GetLocation()))
+	return nil
+}
+
 func (interpreter *Interpreter) returnStatement() *ast.ReturnStatement {
 	// Next token's type must be RETURN_ID
 	tok, _ := interpreter.parser.Next()
@@ -467,7 +839,6 @@ func (interpreter *Interpreter) continueStatement() *ast.ContinueStatement {
 
 func (interpreter *Interpreter) jumpStatement() *common.Location {
 	parser := interpreter.parser
-	logger := interpreter.logger
 
 	var tok *token.Token
 	var err gerror.Error
@@ -479,7 +850,7 @@ func (interpreter *Interpreter) jumpStatement() *common.Location {
 	location = tok.GetLocation()
 
 	if tok, err = parser.Next(); err != nil {
-		logger.CompileError(err)
+		interpreter.compileError(err)
 	}
 	if tok.GetType() != token.SEMICOLON_ID {
 		// skip the semicolon if exist
@@ -489,6 +860,128 @@ func (interpreter *Interpreter) jumpStatement() *common.Location {
 	return location
  }
 
+func (interpreter *Interpreter) tryStatement() *ast.TryStatement {
+	parser := interpreter.parser
+
+	var tok *token.Token
+	var err gerror.Error
+
+	// Next token's type must be TRY_ID
+	tok, _ = parser.Next()
+	statement := ast.NewTryStatement(interpreter.block(), tok.GetLocation())
+
+	if tok, err = parser.Next(); err != nil {
+		interpreter.compileError(err)
+	}
+	caught := tok.GetType() == token.CATCH_ID
+	if caught {
+		identifier := interpreter.catchVariable()
+		statement.SetCatchBlock(identifier, interpreter.block())
+
+		if tok, err = parser.Next(); err != nil {
+			interpreter.compileError(err)
+		}
+	}
+
+	if tok.GetType() == token.FINALLY_ID {
+		statement.SetFinallyBlock(interpreter.block())
+	} else {
+		parser.RollBack(tok)
+		if !caught {
+			interpreter.compileError(gerror.NewSyntaxError(
+				fmt.Sprintf("Try block should followed by %s or %s, not %s",
+					token.GetDescription(token.CATCH_ID), token.GetDescription(token.FINALLY_ID),
+					token.GetDescription(tok.GetType())), tok.GetLocation()))
+		}
+	}
+
+	return statement
+}
+
+// The variable holding the caught value, surrounded by small parentheses.
+func (interpreter *Interpreter) catchVariable() *types.Identifier {
+	parser := interpreter.parser
+
+	var tok *token.Token
+	var err gerror.Error
+
+	if tok, err = parser.Next(); err != nil {
+		interpreter.compileError(err)
+	}
+	if tok.GetType() != token.LSP_ID {
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Catch variable should start with %s, not %s",
+				token.GetDescription(token.LSP_ID), token.GetDescription(tok.GetType())),
+			tok.GetLocation()))
+	}
+
+	if tok, err = parser.Next(); err != nil {
+		interpreter.compileError(err)
+	}
+	if tok.GetType() != token.IDENTIFIER_ID {
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Catch variable should be %s, not %s",
+				token.GetDescription(token.IDENTIFIER_ID), token.GetDescription(tok.GetType())),
+			tok.GetLocation()))
+	}
+	identifier := types.NewIdentifier(tok.GetValue().(string), tok.GetLocation())
+
+	if tok, err = parser.Next(); err != nil {
+		interpreter.compileError(err)
+	}
+	if tok.GetType() != token.RSP_ID {
+		parser.RollBack(tok)
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Catch variable should stopped with %s, not %s",
+				token.GetDescription(token.RSP_ID), token.GetDescription(tok.GetType())),
+			tok.GetLocation()))
+	}
+
+	return identifier
+}
+
+func (interpreter *Interpreter) throwStatement() *ast.ThrowStatement {
+	parser := interpreter.parser
+
+	// Next token's type must be THROW_ID
+	tok, _ := parser.Next()
+
+	next, err := parser.Next()
+	if err != nil {
+		interpreter.compileError(err)
+	}
+	parser.RollBack(next)
+
+	value := interpreter.expression()
+	if value == nil {
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("%s should followed by a value, not %s",
+				token.GetDescription(token.THROW_ID), token.GetDescription(next.GetType())),
+			next.GetLocation()))
+	}
+
+	return ast.NewThrowStatement(value, tok.GetLocation())
+}
+
 func (interpreter *Interpreter) expressionStatement() *ast.ExpressionStatement {
-	return ast.NewExpressionStatement(interpreter.expression())
+	parser := interpreter.parser
+
+	tok, err := parser.Next()
+	if err != nil {
+		interpreter.compileError(err)
+	}
+	parser.RollBack(tok)
+
+	expression := interpreter.expression()
+	if expression == nil {
+		interpreter.compileError(gerror.NewSyntaxError(
+			fmt.Sprintf("Unexpected %s, should be a statement",
+				token.GetDescription(tok.GetType())), tok.GetLocation()))
+	}
+
+	statement := ast.NewExpressionStatement(expression)
+	statement.SetLocation(tok.GetLocation())
+	return statement
 }
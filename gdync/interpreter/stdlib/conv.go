@@ -0,0 +1,99 @@
+package stdlib
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// conv module
+//
+
+var convModule []ast.Function = []ast.Function{
+	newBuiltin("toInt", []parameter{anyParameter}, toInt),
+	newBuiltin("toFloat", []parameter{anyParameter}, toFloat),
+	newBuiltin("toString", []parameter{anyParameter}, toString),
+}
+
+// toInt(v) convert a string, float or bool to integer, floats are truncated
+// toward zero.
+func toInt(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	value := arguments[0]
+
+	var result int64
+	switch value.GetType() {
+	case types.INTEGER_TYPE, types.BIG_INTEGER_TYPE:
+		return value, nil
+	case types.FLOAT_TYPE:
+		f := value.GetValue().(float64)
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return nil, gerror.NewNativeFunctionError("toInt",
+				"can't convert infinity or NaN to integer", nil)
+		}
+		// truncated exactly, out of range of int64 becomes a BigInteger
+		b, _ := big.NewFloat(f).Int(nil)
+		return types.NewBigInteger(b), nil
+	case types.BOOL_TYPE:
+		if value.GetValue().(bool) {
+			result = 1
+		}
+	case types.STRING_TYPE:
+		var err error
+		str := strings.TrimSpace(value.GetValue().(string))
+		if result, err = strconv.ParseInt(str, 10, 64); err != nil {
+			if b, ok := new(big.Int).SetString(str, 10); ok {
+				return types.NewBigInteger(b), nil
+			}
+			return nil, gerror.NewNativeFunctionError("toInt",
+				fmt.Sprintf("invalid integer \"%s\"", value.GetValue()), nil)
+		}
+	default:
+		return nil, gerror.NewTypeMismatchError("String, Integer, Float or Bool",
+			value.GetType().String(), value.GetValue(), nil)
+	}
+
+	return types.NewValue(types.INTEGER_TYPE, result), nil
+}
+
+// toFloat(v) convert a string, integer or bool to float.
+func toFloat(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	value := arguments[0]
+
+	var result float64
+	switch value.GetType() {
+	case types.FLOAT_TYPE:
+		return value, nil
+	case types.INTEGER_TYPE:
+		result = float64(value.GetValue().(int64))
+	case types.BIG_INTEGER_TYPE:
+		result, _ = new(big.Float).SetInt(value.GetValue().(*big.Int)).Float64()
+	case types.BOOL_TYPE:
+		if value.GetValue().(bool) {
+			result = 1
+		}
+	case types.STRING_TYPE:
+		var err error
+		str := strings.TrimSpace(value.GetValue().(string))
+		if result, err = strconv.ParseFloat(str, 64); err != nil {
+			return nil, gerror.NewNativeFunctionError("toFloat",
+				fmt.Sprintf("invalid float \"%s\"", value.GetValue()), nil)
+		}
+	default:
+		return nil, gerror.NewTypeMismatchError("String, Integer, Float or Bool",
+			value.GetType().String(), value.GetValue(), nil)
+	}
+
+	return types.NewValue(types.FLOAT_TYPE, result), nil
+}
+
+// toString(v) return the printable form of any value.
+func toString(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	return types.NewValue(types.STRING_TYPE, arguments[0].String()), nil
+}
@@ -0,0 +1,36 @@
+package stdlib
+
+import (
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// error module, inspects runtime errors caught by try statements
+//
+
+var errorModule []ast.Function = []ast.Function{
+	newBuiltin("errorMessage", []parameter{errorParameter}, errorMessage),
+	newBuiltin("errorLocation", []parameter{errorParameter}, errorLocation),
+}
+
+// errorMessage(e) return the message of a runtime error.
+func errorMessage(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	err := arguments[0].GetValue().(*types.RuntimeError)
+	return types.NewValue(types.STRING_TYPE, err.GetMessage()), nil
+}
+
+// errorLocation(e) return [file, line, position] where a runtime error
+// occurred, null if it's unknown.
+func errorLocation(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	location := arguments[0].GetValue().(*types.RuntimeError).GetLocation()
+	if location == nil {
+		return types.NewValue(types.NULL_TYPE, nil), nil
+	}
+	return types.NewValue(types.ARRAY_TYPE, []types.Value{
+		types.NewValue(types.STRING_TYPE, location.GetFileName()),
+		types.NewValue(types.INTEGER_TYPE, int64(location.GetLine())),
+		types.NewValue(types.INTEGER_TYPE, int64(location.GetPosition())),
+	}), nil
+}
@@ -0,0 +1,109 @@
+package stdlib
+
+import (
+	"fmt"
+	"os"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// io module
+//
+
+var fileParameter = parameter{types.FILE_TYPE}
+
+var ioModule []ast.Function = []ast.Function{
+	newBuiltin("open", []parameter{stringParameter, stringParameter}, openFile),
+	newBuiltin("read", []parameter{fileParameter}, readFile),
+	newBuiltin("readLine", []parameter{fileParameter}, readLineFile),
+	newBuiltin("write", []parameter{fileParameter, anyParameter}, writeFile),
+	newBuiltin("close", []parameter{{types.FILE_TYPE, types.CHANNEL_TYPE}}, closeFile),
+	newBuiltin("input", []parameter{}, input),
+}
+
+var openFlags map[string]int = map[string]int{
+	"r": os.O_RDONLY,
+	"w": os.O_WRONLY | os.O_CREATE | os.O_TRUNC,
+	"a": os.O_WRONLY | os.O_CREATE | os.O_APPEND,
+}
+
+// open(path, mode) open a file for reading("r"), writing("w") or appending("a").
+func openFile(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	path := arguments[0].GetValue().(string)
+	mode := arguments[1].GetValue().(string)
+
+	flag, ok := openFlags[mode]
+	if !ok {
+		return nil, gerror.NewNativeFunctionError("open",
+			fmt.Sprintf("invalid mode \"%s\", should be r, w or a", mode), nil)
+	}
+	f, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		return nil, gerror.NewNativeFunctionError("open", err.Error(), nil)
+	}
+
+	var file *types.File
+	if mode == "r" {
+		file = types.NewFile(path, f, nil, f)
+	} else {
+		file = types.NewFile(path, nil, f, f)
+	}
+	return types.NewValue(types.FILE_TYPE, file), nil
+}
+
+// read(f) return all remaining content of f.
+func readFile(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	content, err := arguments[0].GetValue().(*types.File).Read()
+	if err != nil {
+		return nil, gerror.NewNativeFunctionError("read", err.Error(), nil)
+	}
+	return types.NewValue(types.STRING_TYPE, content), nil
+}
+
+// readLine(f) return next line of f, null at the end of file.
+func readLineFile(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	return readLineFrom("readLine", arguments[0].GetValue().(*types.File))
+}
+
+// write(f, v) write the printable form of v to f.
+func writeFile(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	if err := arguments[0].GetValue().(*types.File).Write(arguments[1].String()); err != nil {
+		return nil, gerror.NewNativeFunctionError("write", err.Error(), nil)
+	}
+	return types.NewValue(types.NULL_TYPE, nil), nil
+}
+
+// close(f) close f, a closed file can't be used anymore. A channel is
+// closed by close too, see the task module.
+func closeFile(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	if arguments[0].GetType() == types.CHANNEL_TYPE {
+		return closeChannel(arguments, env)
+	}
+	if err := arguments[0].GetValue().(*types.File).Close(); err != nil {
+		return nil, gerror.NewNativeFunctionError("close", err.Error(), nil)
+	}
+	return types.NewValue(types.NULL_TYPE, nil), nil
+}
+
+// input() return next line of stdin, null at the end of input.
+func input(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	stdin, err := ast.GetStandardFile(types.STDIN, env)
+	if err != nil {
+		return nil, err
+	}
+	return readLineFrom("input", stdin)
+}
+
+func readLineFrom(name string, file *types.File) (types.Value, gerror.Error) {
+	line, ok, err := file.ReadLine()
+	if err != nil {
+		return nil, gerror.NewNativeFunctionError(name, err.Error(), nil)
+	}
+	if !ok {
+		return types.NewValue(types.NULL_TYPE, nil), nil
+	}
+	return types.NewValue(types.STRING_TYPE, line), nil
+}
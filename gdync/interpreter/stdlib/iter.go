@@ -0,0 +1,36 @@
+package stdlib
+
+import (
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// iter module, collections iterated by foreach loops
+//
+
+var iterModule []ast.Function = []ast.Function{
+	newBuiltin("range", []parameter{integerParameter, integerParameter,
+		integerParameter}, rangeOf).optional(2),
+}
+
+// range(stop), range(start, stop) or range(start, stop, step) return
+// integers from start(0 by default) up to but not including stop, by
+// step(1 by default).
+func rangeOf(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	integers := []int64{0, 0, 1}
+	if len(arguments) == 1 {
+		integers[1] = arguments[0].GetValue().(int64)
+	} else {
+		for i, argument := range arguments {
+			integers[i] = argument.GetValue().(int64)
+		}
+	}
+
+	r := types.NewRange(integers[0], integers[1], integers[2])
+	if r == nil {
+		return nil, gerror.NewNativeFunctionError("range", "step can't be zero", nil)
+	}
+	return types.NewValue(types.RANGE_TYPE, r), nil
+}
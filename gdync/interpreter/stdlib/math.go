@@ -0,0 +1,75 @@
+package stdlib
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// math module
+//
+
+var mathModule []ast.Function = []ast.Function{
+	newBuiltin("sqrt", []parameter{numberParameter}, sqrt),
+	newBuiltin("abs", []parameter{numberParameter}, abs),
+	newBuiltin("floor", []parameter{numberParameter}, floor),
+	newBuiltin("random", []parameter{}, random),
+}
+
+func toFloat64(value types.Value) float64 {
+	switch v := value.GetValue().(type) {
+	case int64:
+		return float64(v)
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f
+	}
+	return value.GetValue().(float64)
+}
+
+// sqrt(x) return the square root of x as a float.
+func sqrt(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	x := toFloat64(arguments[0])
+	if x < 0 {
+		return nil, gerror.NewNativeFunctionError("sqrt",
+			"square root of negative number", nil)
+	}
+	return types.NewValue(types.FLOAT_TYPE, math.Sqrt(x)), nil
+}
+
+// abs(x) return the absolute value of x, in the same type of x.
+func abs(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	if arguments[0].GetType() == types.INTEGER_TYPE || arguments[0].GetType() == types.BIG_INTEGER_TYPE {
+		negative, _ := types.RelationalOperation(types.LT, arguments[0],
+			types.NewValue(types.INTEGER_TYPE, int64(0)))
+		if negative.GetValue().(bool) {
+			return types.NegateInteger(arguments[0]), nil
+		}
+		return arguments[0], nil
+	}
+	return types.NewValue(types.FLOAT_TYPE, math.Abs(arguments[0].GetValue().(float64))), nil
+}
+
+// floor(x) return the greatest integer less than or equal to x.
+func floor(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	if arguments[0].GetType() == types.INTEGER_TYPE || arguments[0].GetType() == types.BIG_INTEGER_TYPE {
+		return arguments[0], nil
+	}
+	x := math.Floor(arguments[0].GetValue().(float64))
+	if math.IsInf(x, 0) || math.IsNaN(x) {
+		return nil, gerror.NewNativeFunctionError("floor",
+			"can't convert infinity or NaN to integer", nil)
+	}
+	result, _ := big.NewFloat(x).Int(nil)
+	return types.NewBigInteger(result), nil
+}
+
+// random() return a float in [0, 1).
+func random(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	return types.NewValue(types.FLOAT_TYPE, rand.Float64()), nil
+}
@@ -0,0 +1,122 @@
+package stdlib
+
+import (
+	"strings"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Standard library: native functions grouped into modules.
+//
+
+var modules map[string][]ast.Function = map[string][]ast.Function{
+	"string": stringModule,
+	"math":   mathModule,
+	"conv":   convModule,
+	"io":     ioModule,
+	"error":  errorModule,
+	"iter":   iterModule,
+	"task":   taskModule,
+}
+
+// GetModule return functions of the module name, nil if it doesn't exist.
+func GetModule(name string) []ast.Function {
+	return modules[name]
+}
+
+// GetFunctions return functions of all modules.
+func GetFunctions() []ast.Function {
+	functions := []ast.Function{}
+	for _, name := range []string{"string", "math", "conv", "io", "error", "iter", "task"} {
+		functions = append(functions, modules[name]...)
+	}
+	return functions
+}
+
+// parameter lists types an argument can be, empty means any type.
+type parameter []types.ValueType
+
+var (
+	anyParameter     = parameter{}
+	stringParameter  = parameter{types.STRING_TYPE}
+	integerParameter = parameter{types.INTEGER_TYPE}
+	numberParameter  = parameter{types.INTEGER_TYPE, types.BIG_INTEGER_TYPE, types.FLOAT_TYPE}
+	errorParameter   = parameter{types.ERROR_TYPE}
+)
+
+func (p parameter) accept(value types.Value) bool {
+	if len(p) == 0 {
+		return true
+	}
+	for _, typ := range p {
+		if value.GetType() == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func (p parameter) String() string {
+	names := []string{}
+	for _, typ := range p {
+		names = append(names, typ.String())
+	}
+	return strings.Join(names, " or ")
+}
+
+// builtin is a native function whose arguments are checked
+// before the implementation is called.
+type builtin struct {
+	name       string
+	parameters []parameter
+	required   int // the rest parameters are optional
+	function   func(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error)
+}
+
+func newBuiltin(name string, parameters []parameter,
+	function func(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error)) *builtin {
+	return &builtin{
+		name:       name,
+		parameters: parameters,
+		required:   len(parameters),
+		function:   function,
+	}
+}
+
+// Make the last count parameters optional.
+func (f *builtin) optional(count int) *builtin {
+	f.required = len(f.parameters) - count
+	return f
+}
+
+func (f *builtin) GetName() string {
+	return f.name
+}
+
+func (f *builtin) GetLocation() *common.Location {
+	return nil
+}
+
+func (f *builtin) Evaluate(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	if len(arguments) < f.required {
+		return nil, gerror.NewArgumentTooFewError(
+			f.name, f.required, len(arguments), nil)
+	}
+	if len(arguments) > len(f.parameters) {
+		return nil, gerror.NewArgumentTooManyError(
+			f.name, len(f.parameters), len(arguments), nil)
+	}
+
+	for i, argument := range arguments {
+		if !f.parameters[i].accept(argument) {
+			return nil, gerror.NewTypeMismatchError(f.parameters[i].String(),
+				argument.GetType().String(), argument.GetValue(), nil)
+		}
+	}
+
+	return f.function(arguments, env)
+}
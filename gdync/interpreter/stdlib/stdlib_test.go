@@ -0,0 +1,187 @@
+package stdlib
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+func getFunction(name string, t *testing.T) ast.Function {
+	for _, function := range GetFunctions() {
+		if function.GetName() == name {
+			return function
+		}
+	}
+	t.Fatalf("Function %s not found", name)
+	return nil
+}
+
+func builtinTest(name string, arguments []types.Value, target types.Value, t *testing.T) {
+	t.Logf("Test: %s ...", name)
+
+	res, err := getFunction(name, t).Evaluate(arguments, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.GetMessage())
+	}
+	if res.GetType() != target.GetType() {
+		t.Fatalf("Wrong result type: Wanted %s, got %s",
+			target.GetType().String(), res.GetType().String())
+	}
+	if res.String() != target.String() {
+		t.Fatalf("Wrong result value: Wanted %s, got %s", target.String(), res.String())
+	}
+
+	t.Log("Passed")
+}
+
+func builtinErrorTest(name string, arguments []types.Value, target string, t *testing.T) {
+	t.Logf("Test: %s error ...", name)
+
+	_, err := getFunction(name, t).Evaluate(arguments, nil)
+	if err == nil {
+		t.Fatalf("There should be an error, but found nil")
+	}
+	if err.GetMessage() != target {
+		t.Fatalf("Wrong error message: Wanted (%s), got (%s)", target, err.GetMessage())
+	}
+
+	t.Log("Passed")
+}
+
+func TestStringModule(t *testing.T) {
+	s := types.NewValue(types.STRING_TYPE, "a,b,c")
+
+	builtinTest("len", []types.Value{s}, types.NewValue(types.INTEGER_TYPE, int64(5)), t)
+	builtinTest("len", []types.Value{types.NewValue(types.STRING_TYPE, "你好")},
+		types.NewValue(types.INTEGER_TYPE, int64(2)), t)
+	builtinTest("substr", []types.Value{s, types.NewValue(types.INTEGER_TYPE, int64(2)),
+		types.NewValue(types.INTEGER_TYPE, int64(3))}, types.NewValue(types.STRING_TYPE, "b,c"), t)
+
+	parts := []types.Value{
+		types.NewValue(types.STRING_TYPE, "a"),
+		types.NewValue(types.STRING_TYPE, "b"),
+		types.NewValue(types.STRING_TYPE, "c"),
+	}
+	builtinTest("split", []types.Value{s, types.NewValue(types.STRING_TYPE, ",")},
+		types.NewValue(types.ARRAY_TYPE, parts), t)
+	builtinTest("len", []types.Value{types.NewValue(types.ARRAY_TYPE, parts)},
+		types.NewValue(types.INTEGER_TYPE, int64(3)), t)
+
+	builtinErrorTest("len", []types.Value{}, gerror.NewArgumentTooFewError(
+		"len", 1, 0, nil).GetMessage(), t)
+	builtinErrorTest("len", []types.Value{types.NewValue(types.INTEGER_TYPE, int64(1))},
+		gerror.NewTypeMismatchError("String or Array", "Integer", int64(1), nil).GetMessage(), t)
+	builtinErrorTest("substr", []types.Value{s, types.NewValue(types.INTEGER_TYPE, int64(4)),
+		types.NewValue(types.INTEGER_TYPE, int64(2))},
+		"Error in native function substr: range [4, 6) out of string length 5", t)
+	builtinErrorTest("substr", []types.Value{types.NewValue(types.STRING_TYPE, "abc"),
+		types.NewValue(types.INTEGER_TYPE, int64(math.MaxInt64)), types.NewValue(types.INTEGER_TYPE, int64(1))},
+		"Error in native function substr: range [9223372036854775807, 9223372036854775808) out of string length 3", t)
+}
+
+func TestMathModule(t *testing.T) {
+	builtinTest("sqrt", []types.Value{types.NewValue(types.INTEGER_TYPE, int64(16))},
+		types.NewValue(types.FLOAT_TYPE, float64(4)), t)
+	builtinTest("abs", []types.Value{types.NewValue(types.INTEGER_TYPE, int64(-3))},
+		types.NewValue(types.INTEGER_TYPE, int64(3)), t)
+	builtinTest("abs", []types.Value{types.NewValue(types.FLOAT_TYPE, float64(-2.5))},
+		types.NewValue(types.FLOAT_TYPE, float64(2.5)), t)
+	builtinTest("floor", []types.Value{types.NewValue(types.FLOAT_TYPE, float64(-2.5))},
+		types.NewValue(types.INTEGER_TYPE, int64(-3)), t)
+	builtinTest("abs", []types.Value{types.NewValue(types.INTEGER_TYPE, int64(math.MinInt64))},
+		types.NewBigInteger(new(big.Int).Lsh(big.NewInt(1), 63)), t)
+	builtinTest("floor", []types.Value{types.NewValue(types.FLOAT_TYPE, float64(1e19))},
+		types.NewBigInteger(new(big.Int).Exp(big.NewInt(10), big.NewInt(19), nil)), t)
+
+	t.Log("Test: random ...")
+	for i := 0; i < 100; i++ {
+		res, err := getFunction("random", t).Evaluate([]types.Value{}, nil)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.GetMessage())
+		}
+		if x := res.GetValue().(float64); x < 0 || x >= 1 {
+			t.Fatalf("Random number out of range: %f", x)
+		}
+	}
+	t.Log("Passed")
+
+	builtinErrorTest("sqrt", []types.Value{types.NewValue(types.STRING_TYPE, "4")},
+		gerror.NewTypeMismatchError("Integer or BigInteger or Float", "String", "4", nil).GetMessage(), t)
+	builtinErrorTest("random", []types.Value{types.NewValue(types.INTEGER_TYPE, int64(1))},
+		gerror.NewArgumentTooManyError("random", 0, 1, nil).GetMessage(), t)
+}
+
+func TestConvModule(t *testing.T) {
+	builtinTest("toInt", []types.Value{types.NewValue(types.STRING_TYPE, " 42 ")},
+		types.NewValue(types.INTEGER_TYPE, int64(42)), t)
+	builtinTest("toInt", []types.Value{types.NewValue(types.FLOAT_TYPE, float64(3.9))},
+		types.NewValue(types.INTEGER_TYPE, int64(3)), t)
+	builtinTest("toFloat", []types.Value{types.NewValue(types.STRING_TYPE, "2.5")},
+		types.NewValue(types.FLOAT_TYPE, float64(2.5)), t)
+	builtinTest("toFloat", []types.Value{types.NewValue(types.BOOL_TYPE, true)},
+		types.NewValue(types.FLOAT_TYPE, float64(1)), t)
+	builtinTest("toString", []types.Value{types.NewValue(types.INTEGER_TYPE, int64(7))},
+		types.NewValue(types.STRING_TYPE, "7"), t)
+	builtinTest("toInt", []types.Value{types.NewValue(types.STRING_TYPE, "100000000000000000000")},
+		types.NewBigInteger(new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)), t)
+	builtinTest("toFloat", []types.Value{types.NewBigInteger(new(big.Int).Lsh(big.NewInt(1), 64))},
+		types.NewValue(types.FLOAT_TYPE, float64(1<<64)), t)
+
+	builtinTest("toInt", []types.Value{types.NewValue(types.FLOAT_TYPE, float64(-3.9))},
+		types.NewValue(types.INTEGER_TYPE, int64(-3)), t)
+	builtinTest("toInt", []types.Value{types.NewValue(types.FLOAT_TYPE, float64(1e20))},
+		types.NewBigInteger(new(big.Int).Exp(big.NewInt(10), big.NewInt(20), nil)), t)
+
+	builtinErrorTest("toInt", []types.Value{types.NewValue(types.STRING_TYPE, "abc")},
+		"Error in native function toInt: invalid integer \"abc\"", t)
+	for _, f := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		builtinErrorTest("toInt", []types.Value{types.NewValue(types.FLOAT_TYPE, f)},
+			"Error in native function toInt: can't convert infinity or NaN to integer", t)
+	}
+	builtinErrorTest("toFloat", []types.Value{types.NewValue(types.NULL_TYPE, nil)},
+		gerror.NewTypeMismatchError("String, Integer, Float or Bool",
+			"Null", nil, nil).GetMessage(), t)
+}
+
+func TestErrorModule(t *testing.T) {
+	located := types.NewValue(types.ERROR_TYPE, types.NewRuntimeError(
+		"Division by zero", common.NewLocation(3, 7, "script")))
+	unknown := types.NewValue(types.ERROR_TYPE, types.NewRuntimeError("Unknown", nil))
+
+	builtinTest("errorMessage", []types.Value{located},
+		types.NewValue(types.STRING_TYPE, "Division by zero"), t)
+	builtinTest("errorLocation", []types.Value{located},
+		types.NewValue(types.ARRAY_TYPE, []types.Value{
+			types.NewValue(types.STRING_TYPE, "script"),
+			types.NewValue(types.INTEGER_TYPE, int64(3)),
+			types.NewValue(types.INTEGER_TYPE, int64(7)),
+		}), t)
+	builtinTest("errorLocation", []types.Value{unknown},
+		types.NewValue(types.NULL_TYPE, nil), t)
+
+	builtinErrorTest("errorMessage", []types.Value{types.NewValue(types.STRING_TYPE, "e")},
+		gerror.NewTypeMismatchError("Error", "String", "e", nil).GetMessage(), t)
+}
+
+func TestIterModule(t *testing.T) {
+	integer := func(i int64) types.Value {
+		return types.NewValue(types.INTEGER_TYPE, i)
+	}
+
+	builtinTest("range", []types.Value{integer(5)},
+		types.NewValue(types.RANGE_TYPE, types.NewRange(0, 5, 1)), t)
+	builtinTest("range", []types.Value{integer(2), integer(5)},
+		types.NewValue(types.RANGE_TYPE, types.NewRange(2, 5, 1)), t)
+	builtinTest("range", []types.Value{integer(5), integer(0), integer(-2)},
+		types.NewValue(types.RANGE_TYPE, types.NewRange(5, 0, -2)), t)
+
+	builtinErrorTest("range", []types.Value{integer(0), integer(5), integer(0)},
+		gerror.NewNativeFunctionError("range", "step can't be zero", nil).GetMessage(), t)
+	builtinErrorTest("range", []types.Value{},
+		gerror.NewArgumentTooFewError("range", 1, 0, nil).GetMessage(), t)
+}
@@ -0,0 +1,57 @@
+package stdlib
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// string module
+//
+
+var stringModule []ast.Function = []ast.Function{
+	newBuiltin("len", []parameter{{types.STRING_TYPE, types.ARRAY_TYPE}}, length),
+	newBuiltin("substr", []parameter{stringParameter, integerParameter, integerParameter}, substr),
+	newBuiltin("split", []parameter{stringParameter, stringParameter}, split),
+}
+
+// len(s) return the number of characters of a string or elements of an array.
+func length(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	var size int
+	if arguments[0].GetType() == types.ARRAY_TYPE {
+		size = len(arguments[0].GetValue().([]types.Value))
+	} else {
+		size = len([]rune(arguments[0].GetValue().(string)))
+	}
+	return types.NewValue(types.INTEGER_TYPE, int64(size)), nil
+}
+
+// substr(s, start, length) return length characters of s from start.
+func substr(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	str := []rune(arguments[0].GetValue().(string))
+	start := arguments[1].GetValue().(int64)
+	size := arguments[2].GetValue().(int64)
+
+	// start+size may overflow
+	if start < 0 || size < 0 || start > int64(len(str)) || size > int64(len(str))-start {
+		end := new(big.Int).Add(big.NewInt(start), big.NewInt(size))
+		return nil, gerror.NewNativeFunctionError("substr", fmt.Sprintf(
+			"range [%d, %s) out of string length %d", start, end, len(str)), nil)
+	}
+	return types.NewValue(types.STRING_TYPE, string(str[start:start+size])), nil
+}
+
+// split(s, sep) return an array of substrings of s separated by sep.
+func split(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	elements := []types.Value{}
+	for _, element := range strings.Split(arguments[0].GetValue().(string),
+		arguments[1].GetValue().(string)) {
+		elements = append(elements, types.NewValue(types.STRING_TYPE, element))
+	}
+	return types.NewValue(types.ARRAY_TYPE, elements), nil
+}
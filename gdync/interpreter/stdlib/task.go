@@ -0,0 +1,74 @@
+package stdlib
+
+import (
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// task module, channels and tasks started by spawn
+//
+
+var channelParameter = parameter{types.CHANNEL_TYPE}
+
+var taskModule []ast.Function = []ast.Function{
+	newBuiltin("channel", []parameter{integerParameter}, newChannel).optional(1),
+	newBuiltin("send", []parameter{channelParameter, anyParameter}, send),
+	newBuiltin("recv", []parameter{channelParameter}, recv),
+	newBuiltin("wait", []parameter{{types.TASK_TYPE}}, wait),
+}
+
+// channel() or channel(capacity) return a channel buffering capacity(0 by
+// default) values.
+func newChannel(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	var capacity int64
+	if len(arguments) > 0 {
+		capacity = arguments[0].GetValue().(int64)
+	}
+	if capacity < 0 {
+		return nil, gerror.NewNativeFunctionError("channel", "capacity can't be negative", nil)
+	}
+	channel := env.GetScheduler().NewChannel(int(capacity))
+	return types.NewValue(types.CHANNEL_TYPE, channel), nil
+}
+
+// send(ch, v) send v to ch, blocked until v is received or buffered.
+func send(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	channel := arguments[0].GetValue().(*ast.Channel)
+	ok, err := channel.Send(arguments[1], env)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, gerror.NewNativeFunctionError("send", "channel is closed", nil)
+	}
+	return types.NewValue(types.NULL_TYPE, nil), nil
+}
+
+// recv(ch) return next value sent to ch, null once ch is closed and
+// all values are received.
+func recv(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	value, ok, err := arguments[0].GetValue().(*ast.Channel).Receive(env)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return types.NewValue(types.NULL_TYPE, nil), nil
+	}
+	return value, nil
+}
+
+// close(ch) wake all tasks blocked by ch, a closed channel can't be sent to.
+func closeChannel(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	if !arguments[0].GetValue().(*ast.Channel).Close() {
+		return nil, gerror.NewNativeFunctionError("close", "channel is closed already", nil)
+	}
+	return types.NewValue(types.NULL_TYPE, nil), nil
+}
+
+// wait(t) return the value returned by the function of task t, the error
+// of t is thrown again.
+func wait(arguments []types.Value, env *ast.Environment) (types.Value, gerror.Error) {
+	return arguments[0].GetValue().(*ast.Task).Wait(env)
+}
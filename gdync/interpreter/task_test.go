@@ -0,0 +1,207 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+)
+
+var taskScripts = []struct {
+	name   string
+	lines  []string
+	output string
+}{
+	{"producer and consumer", []string{
+		"def produce(ch, n) {",
+		"    for (i = 1; i <= n; i++) {",
+		"        send(ch, i)",
+		"    }",
+		"    close(ch)",
+		"    return n",
+		"}",
+		"def consume(ch) {",
+		"    total = 0",
+		"    v = recv(ch)",
+		"    while (v != null) {",
+		"        total = total + v",
+		"        v = recv(ch)",
+		"    }",
+		"    return total",
+		"}",
+		"ch = channel()",
+		"p = spawn produce(ch, 100)",
+		"c = spawn consume(ch)",
+		"Printf(\"%d %d\\n\", wait(p), wait(c))",
+	}, "100 5050\n"},
+	{"buffered channel", []string{
+		"ch = channel(2)",
+		"send(ch, 1)",
+		"send(ch, \"two\")",
+		"close(ch)",
+		"Printf(\"%d %s %v\\n\", recv(ch), recv(ch), recv(ch) == null)",
+	}, "1 two true\n"},
+	{"fan in", []string{
+		"def square(ch, x) {",
+		"    send(ch, x * x)",
+		"}",
+		"ch = channel()",
+		"for (i = 1; i <= 4; i++) {",
+		"    spawn square(ch, i)",
+		"}",
+		"total = 0",
+		"for (i = 0; i < 4; i++) {",
+		"    total = total + recv(ch)",
+		"}",
+		"Printf(\"%d\\n\", total)",
+	}, "30\n"},
+	{"shared globals", []string{
+		"count = 0",
+		"def work(ch) {",
+		"    global count",
+		"    for (i = 0; i < 50; i++) {",
+		"        count = i",
+		"    }",
+		"    send(ch, true)",
+		"}",
+		"ch = channel()",
+		"for (i = 0; i < 4; i++) {",
+		"    spawn work(ch)",
+		"}",
+		"for (i = 0; i < 4; i++) {",
+		"    recv(ch)",
+		"}",
+		"Printf(\"%d\\n\", count)",
+	}, "49\n"},
+	{"task error caught", []string{
+		"def divide(x) {",
+		"    return 1 / x",
+		"}",
+		"t = spawn divide(0)",
+		"try {",
+		"    wait(t)",
+		"} catch (e) {",
+		"    Printf(\"caught %s\\n\", e)",
+		"}",
+		"Printf(\"%s\\n\", toString(t))",
+	}, "caught Division by zero\n<Task 1 divide>\n"},
+	{"not waited", []string{
+		"def hello(ch) {",
+		"    Printf(\"hello\\n\")",
+		"}",
+		"spawn hello(null)",
+	}, "hello\n"},
+}
+
+func TestTasks(t *testing.T) {
+	for _, script := range taskScripts {
+		for _, vm := range []bool{false, true} {
+			t.Logf("Test: tasks of %s, vm %v ...", script.name, vm)
+
+			output, errs := runScript(strings.Join(script.lines, "\n"), vm)
+			if len(errs) != 0 {
+				t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+			}
+			if output != script.output {
+				t.Fatalf("Wrong output: Wanted (%s), got (%s)", script.output, output)
+			}
+
+			t.Log("Passed")
+		}
+	}
+}
+
+var taskErrorScripts = []struct {
+	name    string
+	lines   []string
+	message string
+	line    int
+}{
+	{"not waited", []string{
+		"def divide(x) {",
+		"    return 1 / x",
+		"}",
+		"spawn divide(0)",
+	}, "Division by zero", 2},
+	{"main blocked", []string{
+		"ch = channel()",
+		"recv(ch)",
+	}, "All tasks are blocked, deadlock", 2},
+	{"tasks blocked", []string{
+		"def receive(ch) {",
+		"    return recv(ch)",
+		"}",
+		"spawn receive(channel())",
+	}, "All tasks are blocked, deadlock", 2},
+	{"wait each other", []string{
+		"def receive(ch) {",
+		"    return recv(ch)",
+		"}",
+		"ch = channel()",
+		"t = spawn receive(ch)",
+		"wait(t)",
+		"send(ch, 1)",
+	}, "All tasks are blocked, deadlock", 6},
+	{"deadlock not caught", []string{
+		"try {",
+		"    recv(channel())",
+		"} catch (e) {",
+		"    Printf(\"caught\\n\")",
+		"}",
+	}, "All tasks are blocked, deadlock", 2},
+	{"send to closed", []string{
+		"ch = channel(1)",
+		"close(ch)",
+		"send(ch, 1)",
+	}, "Error in native function send: channel is closed", 3},
+	{"close twice", []string{
+		"ch = channel()",
+		"close(ch)",
+		"close(ch)",
+	}, "Error in native function close: channel is closed already", 3},
+	{"spawn without call", []string{
+		"t = spawn 1 + 2",
+	}, "spawn should be followed by a function call", 1},
+}
+
+func TestTaskErrors(t *testing.T) {
+	for _, script := range taskErrorScripts {
+		for _, vm := range []bool{false, true} {
+			t.Logf("Test: task error of %s, vm %v ...", script.name, vm)
+
+			output, errs := runScript(strings.Join(script.lines, "\n"), vm)
+			if output != "" {
+				t.Fatalf("Unexpected output: %s", output)
+			}
+			if len(errs) != 1 {
+				t.Fatalf("Wrong error count: Wanted 1, got %d", len(errs))
+			}
+			if errs[0].GetMessage() != script.message {
+				t.Fatalf("Wrong message: Wanted (%s), got (%s)", script.message, errs[0].GetMessage())
+			}
+			if errs[0].GetLocation().GetLine() != script.line {
+				t.Fatalf("Wrong line: Wanted %d, got %d", script.line, errs[0].GetLocation().GetLine())
+			}
+
+			t.Log("Passed")
+		}
+	}
+}
+
+func TestTaskLimits(t *testing.T) {
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: limit of a task, vm %v ...", vm)
+
+		// the main task finishes, the one spinning is aborted by the limit
+		_, errs := runScript(strings.Join([]string{
+			"def spin() {",
+			"    while (true) {}",
+			"}",
+			"spawn spin()",
+		}, "\n"), vm, withLimits(ast.Limits{Time: 10 * time.Millisecond}))
+		checkLimitError(errs, ast.TIME_LIMIT, "Execution exceeds the time limit of 10ms", t)
+
+		t.Log("Passed")
+	}
+}
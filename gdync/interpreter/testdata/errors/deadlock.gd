@@ -0,0 +1,5 @@
+def receive(ch) {
+    return recv(ch) // ERROR "deadlock"
+}
+
+spawn receive(channel())
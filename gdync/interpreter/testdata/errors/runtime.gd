@@ -0,0 +1,8 @@
+// a runtime error aborts the execution
+def divide(a, b) {
+    return a / b // ERROR "Division by zero"
+}
+
+Printf("before\n")
+divide(1, 0)
+Printf("after\n")
@@ -0,0 +1,6 @@
+// syntax errors are all reported before execution
+def f(a b) { // ERROR "identifier can't be used as function paramter"
+    return a
+}
+if (x > 0 { // ERROR "^Condition expression should stopped with right small parentheses"
+}
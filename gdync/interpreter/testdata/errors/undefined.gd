@@ -0,0 +1,2 @@
+x = 1
+y = z + x // ERROR "Undefined variable z"
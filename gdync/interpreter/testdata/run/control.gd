@@ -0,0 +1,25 @@
+// switch, while and exceptions
+def describe(x) {
+    switch (x) {
+    case 1, 2:
+        return "small"
+    case 3:
+        return "three"
+    default:
+        return "large"
+    }
+}
+
+i = 0
+while (i < 5) {
+    i++
+    Printf("%d %s\n", i, describe(i))
+}
+
+try {
+    x = 1 / 0
+} catch (e) {
+    Printf("caught %s\n", e)
+} finally {
+    Printf("finally\n")
+}
@@ -0,0 +1,12 @@
+// recursion and loops
+def fib(n) {
+    if (n < 2) {
+        return n
+    }
+    return fib(n - 1) + fib(n - 2)
+}
+
+for (i = 0; i < 10; i++) {
+    Printf("%d ", fib(i))
+}
+Printf("\n")
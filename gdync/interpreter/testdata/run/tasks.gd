@@ -0,0 +1,15 @@
+// tasks sending squares over a buffered channel
+def square(ch, x) {
+    send(ch, x * x)
+    return x
+}
+
+ch = channel(4)
+for (i = 1; i <= 4; i++) {
+    spawn square(ch, i)
+}
+total = 0
+for (i = 0; i < 4; i++) {
+    total = total + recv(ch)
+}
+Printf("%d\n", total)
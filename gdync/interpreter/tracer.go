@@ -0,0 +1,155 @@
+package interpreter
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/mlmhl/compiler/common"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/types"
+)
+
+//
+// Tracer: an observer which writes each statement executed, function
+// call and return, and variable assigned to a sink as an event.
+//
+
+const (
+	TRACE_STATEMENT = "statement"
+	TRACE_CALL      = "call"
+	TRACE_RETURN    = "return"
+	TRACE_ASSIGN    = "assign"
+)
+
+// TraceEvent happens at line of file, values are formatted like the
+// debugger does. A call or return happens in the caller at the call.
+type TraceEvent struct {
+	Event     string   `json:"event"`
+	File      string   `json:"file"`
+	Line      int      `json:"line"`
+	Depth     int      `json:"depth"`              // number of nested calls
+	Function  string   `json:"function,omitempty"` // called, returning or running
+	Variable  string   `json:"variable,omitempty"` // assigned
+	Arguments []string `json:"arguments,omitempty"`
+	Value     string   `json:"value,omitempty"` // returned or assigned
+}
+
+// TraceSink receives events of a tracer.
+type TraceSink interface {
+	Write(event *TraceEvent) error
+}
+
+type textTraceSink struct {
+	writer io.Writer
+}
+
+// NewTextTraceSink writes an event per line, indented by its depth.
+func NewTextTraceSink(writer io.Writer) TraceSink {
+	return &textTraceSink{writer: writer}
+}
+
+func (sink *textTraceSink) Write(event *TraceEvent) error {
+	line := fmt.Sprintf("%s%s:%d %s", strings.Repeat("  ", event.Depth),
+		event.File, event.Line, event.Event)
+	switch event.Event {
+	case TRACE_CALL:
+		line += fmt.Sprintf(" %s(%s)", event.Function, strings.Join(event.Arguments, ", "))
+	case TRACE_RETURN:
+		line += fmt.Sprintf(" %s = %s", event.Function, event.Value)
+	case TRACE_ASSIGN:
+		line += fmt.Sprintf(" %s = %s", event.Variable, event.Value)
+	}
+	_, err := io.WriteString(sink.writer, line+"\n")
+	return err
+}
+
+type jsonTraceSink struct {
+	encoder *json.Encoder
+}
+
+// NewJSONTraceSink writes an event per line as a JSON object.
+func NewJSONTraceSink(writer io.Writer) TraceSink {
+	return &jsonTraceSink{encoder: json.NewEncoder(writer)}
+}
+
+func (sink *jsonTraceSink) Write(event *TraceEvent) error {
+	return sink.encoder.Encode(event)
+}
+
+type Tracer struct {
+	sink TraceSink
+	err  error // the first error of sink, no more event is written then
+}
+
+func NewTracer(sink TraceSink) *Tracer {
+	return &Tracer{sink: sink}
+}
+
+// Trace execute the file, events of which are written to sink. Returns
+// the error of sink too if there is any.
+func (interpreter *Interpreter) Trace(file string, sink TraceSink) ([]gerror.Error, error) {
+	tracer := NewTracer(sink)
+	previous := interpreter.env.GetObserver()
+	interpreter.SetObserver(tracer)
+	defer interpreter.SetObserver(previous)
+
+	errs := interpreter.Interpret(file)
+	return errs, tracer.Err()
+}
+
+// Err returns the first error of the sink.
+func (tracer *Tracer) Err() error {
+	return tracer.err
+}
+
+func (tracer *Tracer) write(event *TraceEvent, location *common.Location) {
+	if tracer.err != nil || location == nil {
+		return
+	}
+	event.File, event.Line = location.GetFileName(), location.GetLine()
+	tracer.err = tracer.sink.Write(event)
+}
+
+func (tracer *Tracer) OnStep(location *common.Location, env *ast.Environment) gerror.Error {
+	tracer.write(&TraceEvent{
+		Event:    TRACE_STATEMENT,
+		Depth:    env.GetDepth(),
+		Function: env.GetFunctionName(),
+	}, location)
+	return nil
+}
+
+func (tracer *Tracer) OnCall(arguments []types.Value, env *ast.Environment) {
+	values := make([]string, len(arguments))
+	for i, argument := range arguments {
+		values[i] = FormatValue(argument)
+	}
+	tracer.write(&TraceEvent{
+		Event:     TRACE_CALL,
+		Depth:     env.GetDepth() - 1,
+		Function:  env.GetFunctionName(),
+		Arguments: values,
+	}, env.GetCallLocation())
+}
+
+func (tracer *Tracer) OnReturn(value types.Value, env *ast.Environment) {
+	tracer.write(&TraceEvent{
+		Event:    TRACE_RETURN,
+		Depth:    env.GetDepth() - 1,
+		Function: env.GetFunctionName(),
+		Value:    FormatValue(value),
+	}, env.GetCallLocation())
+}
+
+func (tracer *Tracer) OnAssign(identifier *types.Identifier, value types.Value, env *ast.Environment) {
+	tracer.write(&TraceEvent{
+		Event:    TRACE_ASSIGN,
+		Depth:    env.GetDepth(),
+		Function: env.GetFunctionName(),
+		Variable: identifier.GetName(),
+		Value:    FormatValue(value),
+	}, identifier.GetLocation())
+}
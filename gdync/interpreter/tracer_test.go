@@ -0,0 +1,141 @@
+package interpreter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+var tracedScript = []string{
+	"def add(a, b) {",
+	"    c = a + b",
+	"    return c",
+	"}",
+	"s = \"x\"",
+	"for (i = 0; i < 2; i++) {",
+	"    s = s + toString(add(i, 1))",
+	"}",
+}
+
+func TestTextTrace(t *testing.T) {
+	fileName := writeScript(strings.Join(tracedScript, "\n"), t)
+	target := strings.Join([]string{
+		"FILE:5 statement",
+		"FILE:5 assign s = \"x\"",
+		"FILE:6 statement",
+		"FILE:6 assign i = 0",
+		"FILE:6 statement",
+		"FILE:7 statement",
+		"FILE:7 call add(0, 1)",
+		"  FILE:2 statement",
+		"  FILE:2 assign c = 1",
+		"  FILE:3 statement",
+		"FILE:7 return add = 1",
+		"FILE:7 call toString(1)",
+		"FILE:7 return toString = \"1\"",
+		"FILE:7 assign s = \"x1\"",
+		"FILE:6 assign i = 1",
+		"FILE:6 statement",
+		"FILE:7 statement",
+		"FILE:7 call add(1, 1)",
+		"  FILE:2 statement",
+		"  FILE:2 assign c = 2",
+		"  FILE:3 statement",
+		"FILE:7 return add = 2",
+		"FILE:7 call toString(2)",
+		"FILE:7 return toString = \"2\"",
+		"FILE:7 assign s = \"x12\"",
+		"FILE:6 assign i = 2",
+		"FILE:6 statement",
+		"",
+	}, "\n")
+	target = strings.Replace(target, "FILE", fileName, -1)
+
+	for _, vm := range []bool{false, true} {
+		t.Logf("Test: text trace, vm %v ...", vm)
+
+		output := &bytes.Buffer{}
+		inter := NewInterpreter()
+		inter.UseVM(vm)
+		errs, err := inter.Trace(fileName, NewTextTraceSink(output))
+		if len(errs) != 0 {
+			t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+		}
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err.Error())
+		}
+		if output.String() != target {
+			t.Fatalf("Wrong trace: Wanted\n%s\ngot\n%s", target, output.String())
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestJSONTrace(t *testing.T) {
+	t.Log("Test: json trace ...")
+
+	fileName := writeScript(strings.Join(tracedScript, "\n"), t)
+	output := &bytes.Buffer{}
+	inter := NewInterpreter()
+	if _, err := inter.Trace(fileName, NewJSONTraceSink(output)); err != nil {
+		t.Fatalf("Unexpected error: %s", err.Error())
+	}
+
+	events := []*TraceEvent{}
+	scanner := bufio.NewScanner(output)
+	for scanner.Scan() {
+		event := &TraceEvent{}
+		if err := json.Unmarshal(scanner.Bytes(), event); err != nil {
+			t.Fatalf("Invalid event %s: %s", scanner.Text(), err.Error())
+		}
+		events = append(events, event)
+	}
+	if len(events) != 27 {
+		t.Fatalf("Wrong event count: Wanted 27, got %d", len(events))
+	}
+	call := events[6]
+	if call.Event != TRACE_CALL || call.Function != "add" || call.Line != 7 ||
+		strings.Join(call.Arguments, ",") != "0,1" {
+		t.Fatalf("Wrong call event: %v", call)
+	}
+	assign := events[8]
+	if assign.Event != TRACE_ASSIGN || assign.Variable != "c" || assign.Value != "1" ||
+		assign.Depth != 1 || assign.Function != "add" {
+		t.Fatalf("Wrong assign event: %v", assign)
+	}
+
+	t.Log("Passed")
+}
+
+type failedSink struct {
+	count int
+}
+
+func (sink *failedSink) Write(event *TraceEvent) error {
+	sink.count++
+	return errors.New("sink is closed")
+}
+
+func TestTraceSinkError(t *testing.T) {
+	t.Log("Test: trace sink error ...")
+
+	fileName := writeScript(strings.Join(tracedScript, "\n"), t)
+	sink := &failedSink{}
+	errs, err := NewInterpreter().Trace(fileName, sink)
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+	if err == nil || err.Error() != "sink is closed" {
+		t.Fatalf("Wrong error: %v", err)
+	}
+	// no more event is written after the error
+	if sink.count != 1 {
+		t.Fatalf("Wrong write count: Wanted 1, got %d", sink.count)
+	}
+
+	t.Log("Passed")
+}
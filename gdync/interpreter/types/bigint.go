@@ -0,0 +1,170 @@
+package types
+
+import (
+	"math"
+	"math/big"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+)
+
+//
+// Arbitrary-precision integers: an integer operation which overflows int64
+// is computed by big.Int, the result is a BigInteger value. A big result
+// which fits in int64 is an Integer again, so an integer has only one form.
+//
+
+// NewBigInteger returns an Integer value if v fits in int64, otherwise a
+// BigInteger value. v shouldn't be modified later.
+func NewBigInteger(v *big.Int) Value {
+	if v.IsInt64() {
+		return NewValue(INTEGER_TYPE, v.Int64())
+	}
+	return NewValue(BIG_INTEGER_TYPE, v)
+}
+
+// Integer or BigInteger value as big.Int, which shouldn't be modified.
+func toBig(value Value) *big.Int {
+	if v, ok := value.GetValue().(*big.Int); ok {
+		return v
+	}
+	return big.NewInt(value.GetValue().(int64))
+}
+
+func addInteger(l, r int64) Value {
+	if s := l + r; (s > l) == (r > 0) {
+		return NewValue(INTEGER_TYPE, s)
+	}
+	return NewBigInteger(new(big.Int).Add(big.NewInt(l), big.NewInt(r)))
+}
+
+func subtractInteger(l, r int64) Value {
+	if s := l - r; (s < l) == (r > 0) {
+		return NewValue(INTEGER_TYPE, s)
+	}
+	return NewBigInteger(new(big.Int).Sub(big.NewInt(l), big.NewInt(r)))
+}
+
+func multiplyInteger(l, r int64) Value {
+	if l == 0 || r == 0 {
+		return NewValue(INTEGER_TYPE, int64(0))
+	}
+	if p := l * r; p/r == l && !(l == -1 && r == math.MinInt64) && !(r == -1 && l == math.MinInt64) {
+		return NewValue(INTEGER_TYPE, p)
+	}
+	return NewBigInteger(new(big.Int).Mul(big.NewInt(l), big.NewInt(r)))
+}
+
+// The caller must check if r is 0.
+func divideInteger(l, r int64) Value {
+	if l == math.MinInt64 && r == -1 {
+		return NewBigInteger(new(big.Int).Neg(big.NewInt(l)))
+	}
+	return NewValue(INTEGER_TYPE, l/r)
+}
+
+// Minus of an Integer or BigInteger value.
+func NegateInteger(value Value) Value {
+	if v, ok := value.GetValue().(int64); ok && v != math.MinInt64 {
+		return NewValue(INTEGER_TYPE, -v)
+	}
+	return NewBigInteger(new(big.Int).Neg(toBig(value)))
+}
+
+// Compare an Integer, BigInteger or Float value with a Float value exactly,
+// ok is false if either one is NaN.
+func compareFloat(left, right Value) (result int, ok bool) {
+	l, lok := exactFloat(left)
+	r, rok := exactFloat(right)
+	if !lok || !rok {
+		return 0, false
+	}
+	return l.Cmp(r), true
+}
+
+func exactFloat(value Value) (*big.Float, bool) {
+	switch v := value.GetValue().(type) {
+	case float64:
+		if math.IsNaN(v) {
+			return nil, false
+		}
+		return new(big.Float).SetFloat64(v), true
+	case int64:
+		return new(big.Float).SetInt64(v), true
+	default:
+		return new(big.Float).SetInt(v.(*big.Int)), true
+	}
+}
+
+func init() {
+	RegisterPromotion(INTEGER_TYPE, BIG_INTEGER_TYPE, func(value Value) Value {
+		return NewValue(BIG_INTEGER_TYPE, big.NewInt(value.GetValue().(int64)))
+	})
+	RegisterPromotion(BIG_INTEGER_TYPE, FLOAT_TYPE, func(value Value) Value {
+		f, _ := new(big.Float).SetInt(value.GetValue().(*big.Int)).Float64()
+		return NewValue(FLOAT_TYPE, f)
+	})
+
+	RegisterOperator(ADD, BIG_INTEGER_TYPE, STRING_TYPE, concatenate)
+	RegisterOperator(ADD, STRING_TYPE, BIG_INTEGER_TYPE, concatenate)
+
+	RegisterOperator(ADD, BIG_INTEGER_TYPE, BIG_INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return NewBigInteger(new(big.Int).Add(toBig(left), toBig(right))), nil
+	})
+	RegisterOperator(SUBTRACT, BIG_INTEGER_TYPE, BIG_INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return NewBigInteger(new(big.Int).Sub(toBig(left), toBig(right))), nil
+	})
+	RegisterOperator(MULTIPLY, BIG_INTEGER_TYPE, BIG_INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return NewBigInteger(new(big.Int).Mul(toBig(left), toBig(right))), nil
+	})
+	// division and mod truncate toward zero like int64
+	RegisterOperator(DIVIDE, BIG_INTEGER_TYPE, BIG_INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		if toBig(right).Sign() == 0 {
+			return nil, gerror.NewDivisionByZeroError(nil)
+		}
+		return NewBigInteger(new(big.Int).Quo(toBig(left), toBig(right))), nil
+	})
+	RegisterOperator(MOD, BIG_INTEGER_TYPE, BIG_INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		if toBig(right).Sign() == 0 {
+			return nil, gerror.NewDivisionByZeroError(nil)
+		}
+		return NewBigInteger(new(big.Int).Rem(toBig(left), toBig(right))), nil
+	})
+
+	RegisterOperator(EQUAL, BIG_INTEGER_TYPE, BIG_INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return NewValue(BOOL_TYPE, toBig(left).Cmp(toBig(right)) == 0), nil
+	})
+	RegisterOperator(NOT_EQUAL, BIG_INTEGER_TYPE, BIG_INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return NewValue(BOOL_TYPE, toBig(left).Cmp(toBig(right)) != 0), nil
+	})
+	registerComparison(BIG_INTEGER_TYPE, func(left, right Value) int {
+		return toBig(left).Cmp(toBig(right))
+	})
+
+	// integers are compared with floats exactly, instead of by promotion
+	for _, typ := range []ValueType{INTEGER_TYPE, BIG_INTEGER_TYPE} {
+		registerFloatComparison(typ, FLOAT_TYPE)
+		registerFloatComparison(FLOAT_TYPE, typ)
+	}
+}
+
+func registerFloatComparison(left, right ValueType) {
+	relations := map[string]func(int) bool{
+		EQUAL:     func(c int) bool { return c == 0 },
+		NOT_EQUAL: func(c int) bool { return c != 0 },
+		GT:        func(c int) bool { return c > 0 },
+		GTE:       func(c int) bool { return c >= 0 },
+		LT:        func(c int) bool { return c < 0 },
+		LTE:       func(c int) bool { return c <= 0 },
+	}
+	for op, relation := range relations {
+		op, relation := op, relation
+		RegisterOperator(op, left, right, func(l, r Value) (Value, gerror.Error) {
+			c, ok := compareFloat(l, r)
+			if !ok {
+				// NaN is unequal to everything
+				return NewValue(BOOL_TYPE, op == NOT_EQUAL), nil
+			}
+			return NewValue(BOOL_TYPE, relation(c)), nil
+		})
+	}
+}
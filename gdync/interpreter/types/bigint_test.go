@@ -0,0 +1,97 @@
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func TestIntegerOverflow(t *testing.T) {
+	t.Log("Test: integer overflow ...")
+
+	max := NewValue(INTEGER_TYPE, int64(math.MaxInt64))
+	min := NewValue(INTEGER_TYPE, int64(math.MinInt64))
+	one := NewValue(INTEGER_TYPE, int64(1))
+	minusOne := NewValue(INTEGER_TYPE, int64(-1))
+
+	operations := []struct {
+		op     string
+		left   Value
+		right  Value
+		target string
+	}{
+		{ADD, max, one, "BigInteger 9223372036854775808"},
+		{ADD, max, minusOne, "Integer 9223372036854775806"},
+		{SUBTRACT, min, one, "BigInteger -9223372036854775809"},
+		{SUBTRACT, min, minusOne, "Integer -9223372036854775807"},
+		{MULTIPLY, max, max, "BigInteger 85070591730234615847396907784232501249"},
+		{MULTIPLY, min, minusOne, "BigInteger 9223372036854775808"},
+		{MULTIPLY, minusOne, min, "BigInteger 9223372036854775808"},
+		{DIVIDE, min, minusOne, "BigInteger 9223372036854775808"},
+		{MOD, min, minusOne, "Integer 0"},
+	}
+	for _, operation := range operations {
+		res, err := ArithmeticOperation(operation.op, operation.left, operation.right)
+		if err != nil {
+			t.Fatal("Unexpected error: " + err.GetMessage())
+		}
+		if result := res.GetType().String() + " " + res.String(); result != operation.target {
+			t.Fatalf("Wrong result of %s %s %s: Wanted (%s), got (%s)", operation.left,
+				operation.op, operation.right, operation.target, result)
+		}
+	}
+
+	t.Log("Passed")
+
+	t.Log("Test: big integer back to integer ...")
+
+	big, _ := ArithmeticOperation(ADD, max, one)
+	res, _ := ArithmeticOperation(SUBTRACT, big, one)
+	if res.GetType() != INTEGER_TYPE || res.GetValue() != int64(math.MaxInt64) {
+		t.Fatalf("Wrong result: Wanted Integer %d, got %s %s",
+			int64(math.MaxInt64), res.GetType(), res)
+	}
+	if res := NegateInteger(min); res.GetType() != BIG_INTEGER_TYPE {
+		t.Fatalf("Wrong result type: Wanted BigInteger, got %s", res.GetType())
+	}
+	if res := NegateInteger(NegateInteger(min)); res.GetType() != INTEGER_TYPE {
+		t.Fatalf("Wrong result type: Wanted Integer, got %s", res.GetType())
+	}
+
+	t.Log("Passed")
+}
+
+func TestExactComparison(t *testing.T) {
+	t.Log("Test: compare integers with floats exactly ...")
+
+	// 2^53 + 1 can't be represented by float64
+	i := NewValue(INTEGER_TYPE, int64(1<<53+1))
+	f := NewValue(FLOAT_TYPE, float64(1<<53))
+	nan := NewValue(FLOAT_TYPE, math.NaN())
+
+	operations := []struct {
+		op     string
+		left   Value
+		right  Value
+		target bool
+	}{
+		{EQUAL, i, f, false},
+		{GT, i, f, true},
+		{LT, f, i, true},
+		{EQUAL, NewValue(INTEGER_TYPE, int64(2)), NewValue(FLOAT_TYPE, 2.0), true},
+		{EQUAL, i, nan, false},
+		{NOT_EQUAL, nan, i, true},
+		{LTE, nan, i, false},
+	}
+	for _, operation := range operations {
+		res, err := RelationalOperation(operation.op, operation.left, operation.right)
+		if err != nil {
+			t.Fatal("Unexpected error: " + err.GetMessage())
+		}
+		if res.GetValue() != operation.target {
+			t.Fatalf("Wrong result of %s %s %s: Wanted %v, got %v", operation.left,
+				operation.op, operation.right, operation.target, res)
+		}
+	}
+
+	t.Log("Passed")
+}
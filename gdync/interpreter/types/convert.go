@@ -0,0 +1,170 @@
+package types
+
+import (
+	"math/big"
+	"reflect"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+)
+
+//
+// Conversion between Go values and script values, used by the host
+// program which embeds the interpreter.
+//
+
+var valueInterface = reflect.TypeOf((*Value)(nil)).Elem()
+var filePointer = reflect.TypeOf((*File)(nil))
+var errorPointer = reflect.TypeOf((*RuntimeError)(nil))
+var rangePointer = reflect.TypeOf((*Range)(nil))
+var structPointer = reflect.TypeOf((*Struct)(nil))
+var bigPointer = reflect.TypeOf((*big.Int)(nil))
+
+// FromGo convert a Go value to script value, nil is converted to null,
+// a slice or array to array and a Value is used as it is.
+func FromGo(value interface{}) (Value, gerror.Error) {
+	if value == nil {
+		return NewValue(NULL_TYPE, nil), nil
+	}
+	if v, ok := value.(Value); ok {
+		return v, nil
+	}
+	if file, ok := value.(*File); ok {
+		return NewValue(FILE_TYPE, file), nil
+	}
+	if err, ok := value.(*RuntimeError); ok {
+		return NewValue(ERROR_TYPE, err), nil
+	}
+	if r, ok := value.(*Range); ok {
+		return NewValue(RANGE_TYPE, r), nil
+	}
+	if s, ok := value.(*Struct); ok {
+		return NewValue(STRUCT_TYPE, s), nil
+	}
+	if b, ok := value.(*big.Int); ok {
+		return NewBigInteger(new(big.Int).Set(b)), nil
+	}
+
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Bool:
+		return NewValue(BOOL_TYPE, v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewValue(INTEGER_TYPE, v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return NewBigInteger(new(big.Int).SetUint64(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return NewValue(FLOAT_TYPE, v.Float()), nil
+	case reflect.String:
+		return NewValue(STRING_TYPE, v.String()), nil
+	case reflect.Slice, reflect.Array:
+		elements := []Value{}
+		for i := 0; i < v.Len(); i++ {
+			element, err := FromGo(v.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+		}
+		return NewValue(ARRAY_TYPE, elements), nil
+	}
+
+	return nil, gerror.NewTypeMismatchError("script value",
+		v.Type().String(), value, nil)
+}
+
+// ToGo convert a script value to the Go value it holds, an array is
+// converted to []interface{}.
+func ToGo(value Value) interface{} {
+	if value == nil {
+		return nil
+	}
+	if value.GetType() == ARRAY_TYPE {
+		elements := []interface{}{}
+		for _, element := range value.GetValue().([]Value) {
+			elements = append(elements, ToGo(element))
+		}
+		return elements
+	}
+	return value.GetValue()
+}
+
+// ToGoType convert a script value to a Go value of typ, an integer can be
+// used as float, Value and interface{} accept all values. A number out of
+// range of typ is a type mismatch.
+func ToGoType(value Value, typ reflect.Type) (reflect.Value, gerror.Error) {
+	if value == nil {
+		value = NewValue(NULL_TYPE, nil)
+	}
+	if typ == valueInterface {
+		return reflect.ValueOf(&value).Elem(), nil
+	}
+	if typ == filePointer && value.GetType() == FILE_TYPE {
+		return reflect.ValueOf(value.GetValue()), nil
+	}
+	if typ == errorPointer && value.GetType() == ERROR_TYPE {
+		return reflect.ValueOf(value.GetValue()), nil
+	}
+	if typ == rangePointer && value.GetType() == RANGE_TYPE {
+		return reflect.ValueOf(value.GetValue()), nil
+	}
+	if typ == structPointer && value.GetType() == STRUCT_TYPE {
+		return reflect.ValueOf(value.GetValue()), nil
+	}
+	if typ == bigPointer && (value.GetType() == INTEGER_TYPE || value.GetType() == BIG_INTEGER_TYPE) {
+		return reflect.ValueOf(new(big.Int).Set(toBig(value))), nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Interface:
+		if typ.NumMethod() == 0 {
+			result := reflect.New(typ).Elem()
+			if v := ToGo(value); v != nil {
+				result.Set(reflect.ValueOf(v))
+			}
+			return result, nil
+		}
+	case reflect.Bool:
+		if value.GetType() == BOOL_TYPE {
+			return reflect.ValueOf(value.GetValue()).Convert(typ), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.GetType() == INTEGER_TYPE || value.GetType() == BIG_INTEGER_TYPE {
+			result := reflect.New(typ).Elem()
+			if b := toBig(value); b.IsInt64() && !result.OverflowInt(b.Int64()) {
+				result.SetInt(b.Int64())
+				return result, nil
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if value.GetType() == INTEGER_TYPE || value.GetType() == BIG_INTEGER_TYPE {
+			result := reflect.New(typ).Elem()
+			if b := toBig(value); b.IsUint64() && !result.OverflowUint(b.Uint64()) {
+				result.SetUint(b.Uint64())
+				return result, nil
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch value.GetType() {
+		case INTEGER_TYPE, BIG_INTEGER_TYPE:
+			f, _ = new(big.Float).SetInt(toBig(value)).Float64()
+		case FLOAT_TYPE:
+			f = value.GetValue().(float64)
+		default:
+			return reflect.Value{}, gerror.NewTypeMismatchError(typ.String(),
+				value.GetType().String(), value.GetValue(), nil)
+		}
+		result := reflect.New(typ).Elem()
+		if !result.OverflowFloat(f) {
+			result.SetFloat(f)
+			return result, nil
+		}
+	case reflect.String:
+		if value.GetType() == STRING_TYPE {
+			return reflect.ValueOf(value.GetValue()).Convert(typ), nil
+		}
+	}
+
+	return reflect.Value{}, gerror.NewTypeMismatchError(typ.String(),
+		value.GetType().String(), value.GetValue(), nil)
+}
@@ -0,0 +1,34 @@
+package types
+
+import (
+	"github.com/mlmhl/compiler/common"
+)
+
+//
+// Runtime error caught by a try statement, the value of Error type.
+//
+
+type RuntimeError struct {
+	message  string
+	location *common.Location
+}
+
+func NewRuntimeError(message string, location *common.Location) *RuntimeError {
+	return &RuntimeError{
+		message:  message,
+		location: location,
+	}
+}
+
+func (err *RuntimeError) GetMessage() string {
+	return err.message
+}
+
+// GetLocation returns where the error occurred, nil if it's unknown.
+func (err *RuntimeError) GetLocation() *common.Location {
+	return err.location
+}
+
+func (err *RuntimeError) String() string {
+	return err.message
+}
@@ -0,0 +1,133 @@
+package types
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+//
+// File handle, the value of File type.
+//
+
+const (
+	STDIN  = "stdin"
+	STDOUT = "stdout"
+	STDERR = "stderr"
+)
+
+type File struct {
+	name string
+
+	reader *bufio.Reader
+	writer io.Writer
+	closer io.Closer
+
+	closed bool
+	lock   sync.Mutex // files are shared by tasks
+}
+
+// reader or writer is nil if the file can't be read or written, the file
+// is closed by closer, which may be nil too, like the standard files.
+func NewFile(name string, reader io.Reader, writer io.Writer, closer io.Closer) *File {
+	file := &File{
+		name:   name,
+		writer: writer,
+		closer: closer,
+	}
+	if reader != nil {
+		file.reader = bufio.NewReader(reader)
+	}
+	return file
+}
+
+// Files of the process, used when a function is called without interpreter.
+func NewStandardFile(name string) *File {
+	switch name {
+	case STDIN:
+		return NewFile(STDIN, os.Stdin, nil, nil)
+	case STDOUT:
+		return NewFile(STDOUT, nil, os.Stdout, nil)
+	case STDERR:
+		return NewFile(STDERR, nil, os.Stderr, nil)
+	}
+	panic("Invalid standard file: " + name)
+}
+
+func (file *File) GetName() string {
+	return file.name
+}
+
+// Read all remaining content.
+func (file *File) Read() (string, error) {
+	file.lock.Lock()
+	defer file.lock.Unlock()
+
+	if err := file.check(file.reader != nil, "readable"); err != nil {
+		return "", err
+	}
+
+	builder := strings.Builder{}
+	if _, err := io.Copy(&builder, file.reader); err != nil {
+		return "", err
+	}
+	return builder.String(), nil
+}
+
+// Read a line without the line break, returns false at the end of file.
+func (file *File) ReadLine() (string, bool, error) {
+	file.lock.Lock()
+	defer file.lock.Unlock()
+
+	if err := file.check(file.reader != nil, "readable"); err != nil {
+		return "", false, err
+	}
+
+	line, err := file.reader.ReadString('\n')
+	if err == io.EOF {
+		return line, len(line) > 0, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	line = strings.TrimSuffix(line[:len(line)-1], "\r")
+	return line, true, nil
+}
+
+func (file *File) Write(content string) error {
+	file.lock.Lock()
+	defer file.lock.Unlock()
+
+	if err := file.check(file.writer != nil, "writable"); err != nil {
+		return err
+	}
+	_, err := io.WriteString(file.writer, content)
+	return err
+}
+
+func (file *File) Close() error {
+	file.lock.Lock()
+	defer file.lock.Unlock()
+
+	if err := file.check(true, ""); err != nil {
+		return err
+	}
+	file.closed = true
+	if file.closer != nil {
+		return file.closer.Close()
+	}
+	return nil
+}
+
+func (file *File) check(ok bool, mode string) error {
+	if file.closed {
+		return errors.New("file " + file.name + " is closed")
+	}
+	if !ok {
+		return errors.New("file " + file.name + " is not " + mode)
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package types
+
+import (
+	"fmt"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+)
+
+//
+// Iteration over collections by foreach loop: a string is iterated by
+// character, an array by element and a range by integer.
+//
+
+const ITERATE = "Iterate"
+
+// Iterator produces elements of a collection one by one.
+type Iterator interface {
+	// Next returns the next element, ok is false if there is no more.
+	Next() (value Value, ok bool)
+}
+
+// collections implement iterable
+type iterable interface {
+	iterator() Iterator
+}
+
+// Iterate returns an iterator over value, which must be a collection.
+func Iterate(value Value) (Iterator, gerror.Error) {
+	if collection, ok := value.(iterable); ok {
+		return collection.iterator(), nil
+	}
+	return nil, gerror.NewInvalidOperationError(nil, ITERATE, value.GetType().String())
+}
+
+type stringIterator struct {
+	characters []rune
+	index      int
+}
+
+func (iterator *stringIterator) Next() (Value, bool) {
+	if iterator.index >= len(iterator.characters) {
+		return nil, false
+	}
+	iterator.index++
+	return NewValue(STRING_TYPE, string(iterator.characters[iterator.index-1])), true
+}
+
+func (value *stringValue) iterator() Iterator {
+	return &stringIterator{characters: []rune(value.value.(string))}
+}
+
+type arrayIterator struct {
+	elements []Value
+	index    int
+}
+
+func (iterator *arrayIterator) Next() (Value, bool) {
+	if iterator.index >= len(iterator.elements) {
+		return nil, false
+	}
+	iterator.index++
+	return iterator.elements[iterator.index-1], true
+}
+
+func (value *arrayValue) iterator() Iterator {
+	return &arrayIterator{elements: value.value.([]Value)}
+}
+
+// Range is integers from start up to but not including stop, by step,
+// which is negative for a descending range.
+type Range struct {
+	start int64
+	stop  int64
+	step  int64
+}
+
+// NewRange returns nil if step is 0.
+func NewRange(start, stop, step int64) *Range {
+	if step == 0 {
+		return nil
+	}
+	return &Range{
+		start: start,
+		stop:  stop,
+		step:  step,
+	}
+}
+
+func (r *Range) String() string {
+	return fmt.Sprintf("range(%d, %d, %d)", r.start, r.stop, r.step)
+}
+
+type rangeIterator struct {
+	r    *Range
+	next int64
+}
+
+func (iterator *rangeIterator) Next() (Value, bool) {
+	r := iterator.r
+	if (r.step > 0 && iterator.next >= r.stop) || (r.step < 0 && iterator.next <= r.stop) {
+		return nil, false
+	}
+	value := iterator.next
+	iterator.next += r.step
+	if (iterator.next < value) != (r.step < 0) {
+		// overflow, value is the last one
+		iterator.next = r.stop
+	}
+	return NewValue(INTEGER_TYPE, value), true
+}
+
+func (value *rangeValue) iterator() Iterator {
+	r := value.value.(*Range)
+	return &rangeIterator{r: r, next: r.start}
+}
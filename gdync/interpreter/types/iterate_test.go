@@ -0,0 +1,43 @@
+package types
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func testIterate(value Value, target string, t *testing.T) {
+	t.Logf("Test: iterate %s ...", value.String())
+
+	iterator, err := Iterate(value)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.GetMessage())
+	}
+	elements := []string{}
+	for element, ok := iterator.Next(); ok; element, ok = iterator.Next() {
+		elements = append(elements, element.String())
+	}
+	if strings.Join(elements, " ") != target {
+		t.Fatalf("Wrong elements: Wanted (%s), got (%s)", target, strings.Join(elements, " "))
+	}
+
+	t.Log("Passed")
+}
+
+func TestIterate(t *testing.T) {
+	testIterate(NewValue(STRING_TYPE, "a你b"), "a 你 b", t)
+	testIterate(NewValue(STRING_TYPE, ""), "", t)
+	testIterate(NewValue(ARRAY_TYPE, []Value{NewValue(INTEGER_TYPE, int64(1)),
+		NewValue(STRING_TYPE, "x"), NewValue(NULL_TYPE, nil)}), "1 x null", t)
+	testIterate(NewValue(RANGE_TYPE, NewRange(0, 5, 2)), "0 2 4", t)
+	testIterate(NewValue(RANGE_TYPE, NewRange(3, 0, -1)), "3 2 1", t)
+	testIterate(NewValue(RANGE_TYPE, NewRange(3, 3, 1)), "", t)
+	testIterate(NewValue(RANGE_TYPE, NewRange(math.MaxInt64-1, math.MaxInt64, 5)),
+		"9223372036854775806", t)
+
+	t.Log("Test: iterate integer ...")
+	if _, err := Iterate(NewValue(INTEGER_TYPE, int64(1))); err == nil {
+		t.Fatalf("There should be an error, but found nil")
+	}
+	t.Log("Passed")
+}
@@ -1,8 +1,6 @@
 package types
 
 import (
-	"reflect"
-
 	gerror "github.com/mlmhl/compiler/gdync/errors"
 )
 
@@ -16,63 +14,98 @@ const (
 	DIVIDE = "Divide"
 	MOD = "Mod"
 	MINUS = "Minus"
+	INCREMENT = "Increment"
+	DECREMENT = "Decrement"
 
 	EQUAL = "Equal"
 	NOT_EQUAL = "NotEqual"
 	GT = "GreaterThan"
 	LT = "LessThan"
-	GTE = "GreateThanOrEqual"
+	GTE = "GreaterThanOrEqual"
 	LTE = "LessThanOrEqual"
-
-	AND = "And"
-	Or = "Or"
-	NOT = "Not"
 )
 
-func ArithmeticOperation(op string, left, right Value) (Value, gerror.Error) {
-	return defaultBinaryOperation(op, left, right)
+//
+// Operator registry: a binary operation is dispatched by the operator and
+// types of both operands. If no operator is registered for the types, an
+// operand which can be promoted to the type of the other one is promoted,
+// like integer to float.
+//
+
+// Operator computes a binary operation, operands have the registered types.
+type Operator func(left, right Value) (Value, gerror.Error)
+
+// Promotion converts a value to the type it's promoted to.
+type Promotion func(value Value) Value
+
+type operatorKey struct {
+	op    string
+	left  ValueType
+	right ValueType
 }
 
-func RelationalOperation(op string, left, right Value) (Value, gerror.Error) {
-	return defaultBinaryOperation(op, left, right)
+type promotionKey struct {
+	from ValueType
+	to   ValueType
 }
 
-type logicalOperation func(left, right Value) bool
+var operators map[operatorKey]Operator = map[operatorKey]Operator{}
+var promotions map[promotionKey]Promotion = map[promotionKey]Promotion{}
 
-var logicalOperations map[string]logicalOperation = map[string]logicalOperation{
-	AND: func(left, right Value) bool {
-		return left.GetValue().(bool) && right.GetValue().(bool)
-	},
-	Or: func(left, right Value) bool {
-		return left.GetValue().(bool) || right.GetValue().(bool)
-	},
+// RegisterOperator registers operator for op on left and right types,
+// which replaces the registered one.
+func RegisterOperator(op string, left, right ValueType, operator Operator) {
+	operators[operatorKey{op, left, right}] = operator
 }
 
-func LogicalOperation(op string, left, right Value) (Value, gerror.Error) {
-	if left.GetType() != BOOL_TYPE {
-		return nil, gerror.NewInvalidOperationError(
-			nil, op, left.GetType().String())
-	}
+// RegisterPromotion registers that values of type from are promoted to
+// type to by promotion, if an operation is undefined on them.
+func RegisterPromotion(from, to ValueType, promotion Promotion) {
+	promotions[promotionKey{from, to}] = promotion
+}
 
-	if right.GetType() != BOOL_TYPE {
-		return nil, gerror.NewInvalidOperationError(
-			nil, op, right.GetType().String())
+// LookupOperator returns the operator for op on left and right types,
+// ok is false if there is none even after promotion.
+func LookupOperator(op string, left, right ValueType) (Operator, bool) {
+	if operator, ok := operators[operatorKey{op, left, right}]; ok {
+		return operator, true
 	}
-
-	return NewValue(BOOL_TYPE, logicalOperations[op](left, right)), nil
+	if promote, ok := promotions[promotionKey{left, right}]; ok {
+		if operator, ok := operators[operatorKey{op, right, right}]; ok {
+			return func(l, r Value) (Value, gerror.Error) {
+				return operator(promote(l), r)
+			}, true
+		}
+	}
+	if promote, ok := promotions[promotionKey{right, left}]; ok {
+		if operator, ok := operators[operatorKey{op, left, left}]; ok {
+			return func(l, r Value) (Value, gerror.Error) {
+				return operator(l, promote(r))
+			}, true
+		}
+	}
+	return nil, false
 }
 
-func defaultBinaryOperation(op string, left, right Value) (Value, gerror.Error) {
-	args := []reflect.Value{reflect.ValueOf(right)}
-	method := reflect.ValueOf(left).MethodByName(op + right.GetType().String())
+func ArithmeticOperation(op string, left, right Value) (Value, gerror.Error) {
+	return binaryOperation(op, left, right)
+}
 
-	res := method.Call(args)
-	if res == nil || len(res) != 2 {
-		panic("Invalid operation result of " + op)
+func RelationalOperation(op string, left, right Value) (Value, gerror.Error) {
+	// any value can be compared with null, which is only equal to itself
+	if (op == EQUAL || op == NOT_EQUAL) &&
+		(left.GetType() == NULL_TYPE || right.GetType() == NULL_TYPE) {
+		equal := left.GetType() == right.GetType()
+		return NewValue(BOOL_TYPE, equal == (op == EQUAL)), nil
 	}
-	if res[1].Interface() != nil {
-		return nil, res[1].Interface().(gerror.Error)
-	} else {
-		return res[0].Interface().(Value), nil
+	return binaryOperation(op, left, right)
+}
+
+func binaryOperation(op string, left, right Value) (Value, gerror.Error) {
+	operator, ok := LookupOperator(op, left.GetType(), right.GetType())
+	if !ok {
+		return nil, gerror.NewInvalidOperationError(nil, op,
+			left.GetType().String(), right.GetType().String())
 	}
-}
\ No newline at end of file
+	return operator(left, right)
+}
@@ -0,0 +1,334 @@
+package types
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+)
+
+var binaryOperators []string = []string{
+	ADD, SUBTRACT, MULTIPLY, DIVIDE, MOD,
+	EQUAL, NOT_EQUAL, GT, GTE, LT, LTE,
+}
+
+var sampleStruct *StructType = NewStructType("P", []string{"x"})
+
+// A value of each type.
+func sampleValues() []Value {
+	return []Value{
+		NewValue(STRING_TYPE, "ab"),
+		NewValue(INTEGER_TYPE, int64(3)),
+		NewValue(FLOAT_TYPE, float64(1.5)),
+		NewValue(BOOL_TYPE, true),
+		NewValue(NULL_TYPE, nil),
+		NewValue(ARRAY_TYPE, []Value{NewValue(INTEGER_TYPE, int64(1))}),
+		NewValue(FILE_TYPE, NewFile("f", nil, nil, nil)),
+		NewValue(ERROR_TYPE, NewRuntimeError("e", nil)),
+		NewValue(RANGE_TYPE, NewRange(0, 3, 1)),
+		NewValue(STRUCT_TYPE, sampleStruct.New(
+			[]Value{NewValue(INTEGER_TYPE, int64(1))})),
+		NewBigInteger(new(big.Int).Lsh(big.NewInt(1), 64)),
+	}
+}
+
+// Results of supported operations on sample values, keyed by "op left right",
+// all other operations are invalid.
+var operationResults map[string]string = map[string]string{
+	"Add String String":   "String abab",
+	"Add String Integer":  "String ab3",
+	"Add String Float":    "String ab1.500000",
+	"Add String Bool":     "String abtrue",
+	"Add String Null":     "String abnull",
+	"Add Integer String":  "String 3ab",
+	"Add Float String":    "String 1.500000ab",
+	"Add Bool String":     "String trueab",
+	"Add Null String":     "String nullab",
+	"Add Integer Integer": "Integer 6",
+	"Add Integer Float":   "Float 4.500000",
+	"Add Float Integer":   "Float 4.500000",
+	"Add Float Float":     "Float 3.000000",
+
+	"Add String BigInteger":     "String ab18446744073709551616",
+	"Add BigInteger String":     "String 18446744073709551616ab",
+	"Add Integer BigInteger":    "BigInteger 18446744073709551619",
+	"Add BigInteger Integer":    "BigInteger 18446744073709551619",
+	"Add BigInteger BigInteger": "BigInteger 36893488147419103232",
+	"Add BigInteger Float":      "Float 18446744073709551616.000000",
+	"Add Float BigInteger":      "Float 18446744073709551616.000000",
+
+	"Subtract Integer Integer": "Integer 0",
+	"Subtract Integer Float":   "Float 1.500000",
+	"Subtract Float Integer":   "Float -1.500000",
+	"Subtract Float Float":     "Float 0.000000",
+
+	"Subtract Integer BigInteger":    "BigInteger -18446744073709551613",
+	"Subtract BigInteger Integer":    "BigInteger 18446744073709551613",
+	"Subtract BigInteger BigInteger": "Integer 0",
+	"Subtract BigInteger Float":      "Float 18446744073709551616.000000",
+	"Subtract Float BigInteger":      "Float -18446744073709551616.000000",
+
+	"Multiply String Integer":  "String ababab",
+	"Multiply Integer String":  "String ababab",
+	"Multiply Integer Integer": "Integer 9",
+	"Multiply Integer Float":   "Float 4.500000",
+	"Multiply Float Integer":   "Float 4.500000",
+	"Multiply Float Float":     "Float 2.250000",
+
+	"Multiply Integer BigInteger":    "BigInteger 55340232221128654848",
+	"Multiply BigInteger Integer":    "BigInteger 55340232221128654848",
+	"Multiply BigInteger BigInteger": "BigInteger 340282366920938463463374607431768211456",
+	"Multiply BigInteger Float":      "Float 27670116110564327424.000000",
+	"Multiply Float BigInteger":      "Float 27670116110564327424.000000",
+
+	"Divide Integer Integer": "Integer 1",
+	"Divide Integer Float":   "Float 2.000000",
+	"Divide Float Integer":   "Float 0.500000",
+	"Divide Float Float":     "Float 1.000000",
+
+	"Divide Integer BigInteger":    "Integer 0",
+	"Divide BigInteger Integer":    "Integer 6148914691236517205",
+	"Divide BigInteger BigInteger": "Integer 1",
+	"Divide BigInteger Float":      "Float 12297829382473033728.000000",
+	"Divide Float BigInteger":      "Float 0.000000",
+
+	"Mod Integer Integer":       "Integer 0",
+	"Mod Integer BigInteger":    "Integer 3",
+	"Mod BigInteger Integer":    "Integer 1",
+	"Mod BigInteger BigInteger": "Integer 0",
+
+	"Equal String String":   "Bool true",
+	"Equal Integer Integer": "Bool true",
+	"Equal Integer Float":   "Bool false",
+	"Equal Float Integer":   "Bool false",
+	"Equal Float Float":     "Bool true",
+	"Equal Bool Bool":       "Bool true",
+	"Equal Struct Struct":   "Bool true",
+
+	"Equal Integer BigInteger":    "Bool false",
+	"Equal BigInteger Integer":    "Bool false",
+	"Equal BigInteger BigInteger": "Bool true",
+	"Equal BigInteger Float":      "Bool false",
+	"Equal Float BigInteger":      "Bool false",
+
+	"NotEqual String String":   "Bool false",
+	"NotEqual Integer Integer": "Bool false",
+	"NotEqual Integer Float":   "Bool true",
+	"NotEqual Float Integer":   "Bool true",
+	"NotEqual Float Float":     "Bool false",
+	"NotEqual Bool Bool":       "Bool false",
+	"NotEqual Struct Struct":   "Bool false",
+
+	"NotEqual Integer BigInteger":    "Bool true",
+	"NotEqual BigInteger Integer":    "Bool true",
+	"NotEqual BigInteger BigInteger": "Bool false",
+	"NotEqual BigInteger Float":      "Bool true",
+	"NotEqual Float BigInteger":      "Bool true",
+
+	"GreaterThan String String":   "Bool false",
+	"GreaterThan Integer Integer": "Bool false",
+	"GreaterThan Integer Float":   "Bool true",
+	"GreaterThan Float Integer":   "Bool false",
+	"GreaterThan Float Float":     "Bool false",
+
+	"GreaterThan Integer BigInteger":    "Bool false",
+	"GreaterThan BigInteger Integer":    "Bool true",
+	"GreaterThan BigInteger BigInteger": "Bool false",
+	"GreaterThan BigInteger Float":      "Bool true",
+	"GreaterThan Float BigInteger":      "Bool false",
+
+	"GreaterThanOrEqual String String":   "Bool true",
+	"GreaterThanOrEqual Integer Integer": "Bool true",
+	"GreaterThanOrEqual Integer Float":   "Bool true",
+	"GreaterThanOrEqual Float Integer":   "Bool false",
+	"GreaterThanOrEqual Float Float":     "Bool true",
+
+	"GreaterThanOrEqual Integer BigInteger":    "Bool false",
+	"GreaterThanOrEqual BigInteger Integer":    "Bool true",
+	"GreaterThanOrEqual BigInteger BigInteger": "Bool true",
+	"GreaterThanOrEqual BigInteger Float":      "Bool true",
+	"GreaterThanOrEqual Float BigInteger":      "Bool false",
+
+	"LessThan String String":   "Bool false",
+	"LessThan Integer Integer": "Bool false",
+	"LessThan Integer Float":   "Bool false",
+	"LessThan Float Integer":   "Bool true",
+	"LessThan Float Float":     "Bool false",
+
+	"LessThan Integer BigInteger":    "Bool true",
+	"LessThan BigInteger Integer":    "Bool false",
+	"LessThan BigInteger BigInteger": "Bool false",
+	"LessThan BigInteger Float":      "Bool false",
+	"LessThan Float BigInteger":      "Bool true",
+
+	"LessThanOrEqual String String":   "Bool true",
+	"LessThanOrEqual Integer Integer": "Bool true",
+	"LessThanOrEqual Integer Float":   "Bool false",
+	"LessThanOrEqual Float Integer":   "Bool true",
+	"LessThanOrEqual Float Float":     "Bool true",
+
+	"LessThanOrEqual Integer BigInteger":    "Bool true",
+	"LessThanOrEqual BigInteger Integer":    "Bool false",
+	"LessThanOrEqual BigInteger BigInteger": "Bool true",
+	"LessThanOrEqual BigInteger Float":      "Bool false",
+	"LessThanOrEqual Float BigInteger":      "Bool true",
+}
+
+func TestOperationMatrix(t *testing.T) {
+	t.Log("Test: all operators on all types ...")
+
+	for _, op := range binaryOperators {
+		for _, left := range sampleValues() {
+			for _, right := range sampleValues() {
+				key := op + " " + left.GetType().String() + " " + right.GetType().String()
+
+				var res Value
+				var err gerror.Error
+				if op == ADD || op == SUBTRACT || op == MULTIPLY || op == DIVIDE || op == MOD {
+					res, err = ArithmeticOperation(op, left, right)
+				} else {
+					res, err = RelationalOperation(op, left, right)
+				}
+
+				target, ok := operationResults[key]
+				if !ok && (op == EQUAL || op == NOT_EQUAL) &&
+					(left.GetType() == NULL_TYPE || right.GetType() == NULL_TYPE) {
+					// only null is equal to null
+					equal := left.GetType() == right.GetType()
+					target, ok = fmt.Sprintf("Bool %v", equal == (op == EQUAL)), true
+				}
+
+				if !ok {
+					message := gerror.NewInvalidOperationError(nil, op,
+						left.GetType().String(), right.GetType().String()).GetMessage()
+					if err == nil {
+						t.Fatalf("%s: There should be an error, but got %v", key, res)
+					}
+					if err.GetMessage() != message {
+						t.Fatalf("%s: Wrong error message: Wanted (%s), got (%s)",
+							key, message, err.GetMessage())
+					}
+					continue
+				}
+
+				if err != nil {
+					t.Fatalf("%s: Unexpected error: %s", key, err.GetMessage())
+				}
+				if result := res.GetType().String() + " " + res.String(); result != target {
+					t.Fatalf("%s: Wrong result: Wanted (%s), got (%s)", key, target, result)
+				}
+			}
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestStringComparison(t *testing.T) {
+	t.Log("Test: compare strings ...")
+
+	a := NewValue(STRING_TYPE, "ab")
+	b := NewValue(STRING_TYPE, "b")
+	c := NewValue(STRING_TYPE, "abc")
+
+	operations := []struct {
+		op     string
+		left   Value
+		right  Value
+		target bool
+	}{
+		{LT, a, b, true},
+		{GT, b, a, true},
+		{LT, a, c, true},
+		{GTE, a, c, false},
+		{LTE, c, b, true},
+	}
+	for _, operation := range operations {
+		res, err := RelationalOperation(operation.op, operation.left, operation.right)
+		if err != nil {
+			t.Fatal("Unexpected error: " + err.GetMessage())
+		}
+		if res.GetValue() != operation.target {
+			t.Fatalf("Wrong result of %s %s %s: Wanted %v, got %v", operation.left,
+				operation.op, operation.right, operation.target, res)
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestDivisionByZero(t *testing.T) {
+	t.Log("Test: division by zero ...")
+
+	zero := NewValue(INTEGER_TYPE, int64(0))
+	for _, left := range []Value{NewValue(INTEGER_TYPE, int64(1)), NewValue(FLOAT_TYPE, 1.0)} {
+		for _, op := range []string{DIVIDE, MOD} {
+			if op == MOD && left.GetType() == FLOAT_TYPE {
+				continue
+			}
+			_, err := ArithmeticOperation(op, left, zero)
+			if err == nil || err.GetMessage() != gerror.NewDivisionByZeroError(nil).GetMessage() {
+				t.Fatalf("Wrong error of %s %s 0: %v", left, op, err)
+			}
+		}
+	}
+
+	t.Log("Passed")
+}
+
+type testType string
+
+func (typ testType) String() string {
+	return string(typ)
+}
+
+func TestRegisterOperator(t *testing.T) {
+	t.Log("Test: register operator of a new type ...")
+
+	typ := testType("Test")
+	RegisterPromotion(INTEGER_TYPE, typ, func(value Value) Value {
+		return &baseValue{typ: typ, value: fmt.Sprintf("<%d>", value.GetValue().(int64))}
+	})
+	RegisterOperator(ADD, typ, typ, func(left, right Value) (Value, gerror.Error) {
+		return &baseValue{typ: typ, value: left.String() + right.String()}, nil
+	})
+
+	value := &baseValue{typ: typ, value: "t"}
+	for _, operation := range []struct {
+		left   Value
+		right  Value
+		target string
+	}{
+		{value, value, "tt"},
+		{value, NewValue(INTEGER_TYPE, int64(1)), "t<1>"},
+		{NewValue(INTEGER_TYPE, int64(2)), value, "<2>t"},
+	} {
+		res, err := ArithmeticOperation(ADD, operation.left, operation.right)
+		if err != nil {
+			t.Fatal("Unexpected error: " + err.GetMessage())
+		}
+		if res.GetType() != typ || res.String() != operation.target {
+			t.Fatalf("Wrong result: Wanted %s, got %s", operation.target, res)
+		}
+	}
+
+	if _, err := ArithmeticOperation(SUBTRACT, value, value); err == nil {
+		t.Fatal("There should be an error, but found nil")
+	}
+
+	t.Log("Passed")
+}
+
+func TestCyclicStructString(t *testing.T) {
+	t.Log("Test: string of a struct in an array of itself ...")
+
+	s := sampleStruct.New(nil)
+	value := NewValue(STRUCT_TYPE, s)
+	s.SetField("x", NewValue(ARRAY_TYPE, []Value{value, NewValue(INTEGER_TYPE, int64(2))}))
+	if target := "P{x: [P{...}, 2]}"; value.String() != target {
+		t.Fatalf("Wrong string: Wanted %s, got %s", target, value.String())
+	}
+
+	t.Log("Passed")
+}
@@ -0,0 +1,174 @@
+package types
+
+import (
+	"strings"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+)
+
+//
+// Struct defined by script, the value of Struct type. Struct values are
+// shared by assignment, a field assigned through one variable is seen
+// through all others.
+//
+
+type StructType struct {
+	name   string
+	fields []string
+	index  map[string]int
+}
+
+// The caller must check if any field is duplicated.
+func NewStructType(name string, fields []string) *StructType {
+	index := map[string]int{}
+	for i, field := range fields {
+		index[field] = i
+	}
+	return &StructType{
+		name:   name,
+		fields: fields,
+		index:  index,
+	}
+}
+
+func (typ *StructType) GetName() string {
+	return typ.name
+}
+
+func (typ *StructType) GetFields() []string {
+	return typ.fields
+}
+
+// Create a struct, fields without value are null.
+func (typ *StructType) New(values []Value) *Struct {
+	fields := make([]Value, len(typ.fields))
+	for i := range fields {
+		if i < len(values) {
+			fields[i] = values[i]
+		} else {
+			fields[i] = NewValue(NULL_TYPE, nil)
+		}
+	}
+	return &Struct{
+		typ:    typ,
+		fields: fields,
+	}
+}
+
+type Struct struct {
+	typ    *StructType
+	fields []Value
+}
+
+func (s *Struct) GetType() *StructType {
+	return s.typ
+}
+
+// GetField returns false if the struct has no field name.
+func (s *Struct) GetField(name string) (Value, bool) {
+	if i, ok := s.typ.index[name]; ok {
+		return s.fields[i], true
+	}
+	return nil, false
+}
+
+// SetField returns false if the struct has no field name.
+func (s *Struct) SetField(name string, value Value) bool {
+	if i, ok := s.typ.index[name]; ok {
+		s.fields[i] = value
+		return true
+	}
+	return false
+}
+
+func (s *Struct) String() string {
+	return s.format(map[*Struct]bool{})
+}
+
+// A struct reached again through its own fields is printed as Name{...}.
+func (s *Struct) format(visited map[*Struct]bool) string {
+	if visited[s] {
+		return s.typ.name + "{...}"
+	}
+	visited[s] = true
+	defer delete(visited, s)
+
+	fields := []string{}
+	for i, field := range s.typ.fields {
+		fields = append(fields, field+": "+formatValue(s.fields[i], visited))
+	}
+	return s.typ.name + "{" + strings.Join(fields, ", ") + "}"
+}
+
+func formatValue(value Value, visited map[*Struct]bool) string {
+	switch value.GetType() {
+	case STRUCT_TYPE:
+		return value.GetValue().(*Struct).format(visited)
+	case ARRAY_TYPE:
+		elements := []string{}
+		for _, element := range value.GetValue().([]Value) {
+			elements = append(elements, formatValue(element, visited))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	}
+	return value.String()
+}
+
+// Structs are equal if they have the same struct type and all fields are
+// equal, fields can't be compared are unequal.
+func init() {
+	RegisterOperator(EQUAL, STRUCT_TYPE, STRUCT_TYPE, func(left, right Value) (Value, gerror.Error) {
+		equal, err := equalStruct(left.GetValue().(*Struct), right.GetValue().(*Struct), map[[2]*Struct]bool{})
+		if err != nil {
+			return nil, err
+		}
+		return NewValue(BOOL_TYPE, equal), nil
+	})
+	RegisterOperator(NOT_EQUAL, STRUCT_TYPE, STRUCT_TYPE, func(left, right Value) (Value, gerror.Error) {
+		equal, err := equalStruct(left.GetValue().(*Struct), right.GetValue().(*Struct), map[[2]*Struct]bool{})
+		if err != nil {
+			return nil, err
+		}
+		return NewValue(BOOL_TYPE, !equal), nil
+	})
+}
+
+// A pair of structs being compared already is taken as equal, so cyclic
+// structs are equal if they are unequal nowhere.
+func equalStruct(left, right *Struct, comparing map[[2]*Struct]bool) (bool, gerror.Error) {
+	if left == right {
+		return true, nil
+	}
+	if left.typ != right.typ {
+		return false, nil
+	}
+	pair := [2]*Struct{left, right}
+	if comparing[pair] {
+		return true, nil
+	}
+	comparing[pair] = true
+
+	for i, field := range left.fields {
+		other := right.fields[i]
+		if field.GetType() == STRUCT_TYPE && other.GetType() == STRUCT_TYPE {
+			equal, err := equalStruct(field.GetValue().(*Struct), other.GetValue().(*Struct), comparing)
+			if err != nil || !equal {
+				return false, err
+			}
+			continue
+		}
+		if field.GetType() != NULL_TYPE && other.GetType() != NULL_TYPE {
+			if _, ok := LookupOperator(EQUAL, field.GetType(), other.GetType()); !ok {
+				return false, nil
+			}
+		}
+		equal, err := RelationalOperation(EQUAL, field, other)
+		if err != nil {
+			return false, err
+		}
+		if !equal.GetValue().(bool) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -102,3 +102,41 @@ func TestAddOperation(t *testing.T) {
 	testArithmeticOperation(MULTIPLY, s1, s2, gerror.NewInvalidOperationError(nil,
 		MULTIPLY, s1.GetType().String(), s2.GetType().String()), t)
 }
+
+func TestEqualOperation(t *testing.T) {
+	i := NewValue(INTEGER_TYPE, int64(3))
+	s := NewValue(STRING_TYPE, "null")
+	b1 := NewValue(BOOL_TYPE, true)
+	b2 := NewValue(BOOL_TYPE, false)
+	n := NewValue(NULL_TYPE, nil)
+
+	operations := []struct {
+		op     string
+		left   Value
+		right  Value
+		target bool
+	}{
+		{EQUAL, n, n, true},
+		{NOT_EQUAL, n, n, false},
+		{EQUAL, i, n, false},
+		{NOT_EQUAL, n, s, true},
+		{EQUAL, b1, b1, true},
+		{EQUAL, b1, b2, false},
+		{NOT_EQUAL, b1, b2, true},
+	}
+
+	for _, operation := range operations {
+		t.Logf("Test: %s on %s, %s ...", operation.op,
+			operation.left.GetType().String(), operation.right.GetType().String())
+
+		res, err := RelationalOperation(operation.op, operation.left, operation.right)
+		if err != nil {
+			t.Fatal("Unexpected error: " + err.GetMessage())
+		}
+		if res.GetType() != BOOL_TYPE || res.GetValue() != operation.target {
+			t.Fatalf("Wrong result: Wanted %v, got %v", operation.target, res)
+		}
+
+		t.Log("Passed ...")
+	}
+}
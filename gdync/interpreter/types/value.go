@@ -2,9 +2,10 @@ package types
 
 import (
 	"fmt"
+	"math/big"
+	"strings"
 
 	gerror "github.com/mlmhl/compiler/gdync/errors"
-	"github.com/mlmhl/goutil/math"
 )
 
 //
@@ -17,6 +18,14 @@ const (
 	FLOAT_TYPE = floatType("Float")
 	BOOL_TYPE = boolType("Bool")
 	NULL_TYPE = nullType("Null")
+	ARRAY_TYPE = arrayType("Array")
+	FILE_TYPE = fileType("File")
+	ERROR_TYPE = errorType("Error")
+	RANGE_TYPE = rangeType("Range")
+	STRUCT_TYPE = structType("Struct")
+	BIG_INTEGER_TYPE = bigIntegerType("BigInteger")
+	CHANNEL_TYPE = channelType("Channel")
+	TASK_TYPE = taskType("Task")
 )
 
 //
@@ -57,6 +66,54 @@ func (typ nullType) String() string {
 	return string(typ)
 }
 
+type arrayType string
+
+func (typ arrayType) String() string {
+	return string(typ)
+}
+
+type fileType string
+
+func (typ fileType) String() string {
+	return string(typ)
+}
+
+type errorType string
+
+func (typ errorType) String() string {
+	return string(typ)
+}
+
+type rangeType string
+
+func (typ rangeType) String() string {
+	return string(typ)
+}
+
+type structType string
+
+func (typ structType) String() string {
+	return string(typ)
+}
+
+type bigIntegerType string
+
+func (typ bigIntegerType) String() string {
+	return string(typ)
+}
+
+type channelType string
+
+func (typ channelType) String() string {
+	return string(typ)
+}
+
+type taskType string
+
+func (typ taskType) String() string {
+	return string(typ)
+}
+
 //
 // value
 //
@@ -66,6 +123,9 @@ type Value interface {
 	GetValue() interface{}
 
 	SetValue(value interface{})
+
+	// String returns the printable form of the value
+	String() string
 }
 
 func NewValue(typ ValueType, value interface{}) Value {
@@ -94,6 +154,30 @@ func NewValue(typ ValueType, value interface{}) Value {
 			},
 		}
 	}
+	if typ == ARRAY_TYPE {
+		return &arrayValue{base}
+	}
+	if typ == FILE_TYPE {
+		return &fileValue{base}
+	}
+	if typ == ERROR_TYPE {
+		return &errorValue{base}
+	}
+	if typ == RANGE_TYPE {
+		return &rangeValue{base}
+	}
+	if typ == STRUCT_TYPE {
+		return &structValue{base}
+	}
+	if typ == BIG_INTEGER_TYPE {
+		return &bigIntegerValue{base}
+	}
+	if typ == CHANNEL_TYPE {
+		return &channelValue{base}
+	}
+	if typ == TASK_TYPE {
+		return &taskValue{base}
+	}
 	panic("Invalid value type: " + typ.String())
 }
 
@@ -114,943 +198,244 @@ func (value *baseValue) SetValue(v interface{}) {
 	value.value = v
 }
 
-//
-// default implements
-//
-
-func defaultOperation(op string, left, right Value) (Value, gerror.Error) {
-	return nil, gerror.NewInvalidOperationError(nil, op,
-		left.GetType().String(), right.GetType().String())
-}
-
-func (value *baseValue) AddString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(ADD, value, other)
-}
-
-func (value *baseValue) AddInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(ADD, value, other)
-}
-
-func (value *baseValue) AddFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(ADD, value, other)
-}
-
-func (value *baseValue) AddBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(ADD, value, other)
-}
-
-func (value *baseValue) AddNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(ADD, value, other)
-}
-
-func (value *baseValue) SubtractString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(SUBTRACT, value, other)
-}
-
-func (value *baseValue) SubtractInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(SUBTRACT, value, other)
-}
-
-func (value *baseValue) SubtractFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(SUBTRACT, value, other)
-}
-
-func (value *baseValue) SubtractBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(SUBTRACT, value, other)
-}
-
-func (value *baseValue) SubtractNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(SUBTRACT, value, other)
-}
-
-func (value *baseValue) MultiplyString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(MULTIPLY, value, other)
-}
-
-func (value *baseValue) MultiplyInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(MULTIPLY, value, other)
-}
-
-func (value *baseValue) MultiplyFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(MULTIPLY, value, other)
-}
-
-func (value *baseValue) MultiplyBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(MULTIPLY, value, other)
-}
-
-func (value *baseValue) MultiplyNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(MULTIPLY, value, other)
-}
-
-func (value *baseValue) DivideString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(DIVIDE, value, other)
-}
-
-func (value *baseValue) DivideInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(DIVIDE, value, other)
-}
-
-func (value *baseValue) DivideFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(DIVIDE, value, other)
-}
-
-func (value *baseValue) DivideBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(DIVIDE, value, other)
-}
-
-func (value *baseValue) DivideNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(DIVIDE, value, other)
-}
-
-func (value *baseValue) ModString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(MOD, value, other)
-}
-
-func (value *baseValue) ModInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(MOD, value, other)
-}
-
-func (value *baseValue) ModFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(MOD, value, other)
-}
-
-func (value *baseValue) ModBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(MOD, value, other)
-}
-
-func (value *baseValue) ModNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(MOD, value, other)
-}
-
-func (value *baseValue) EqualString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) EqualInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) EqualFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) EqualBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) EqualNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) NotEqualString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) NotEqualInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) NotEqualFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) NotEqualBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) NotEqualNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) GreaterThanString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) GreaterThanInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) GreaterThanFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) GreaterThanBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) GreaterThanNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) GreaterThanOrEqualString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) GreaterThanOrEqualInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) GreaterThanOrEqualFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) GreaterThanOrEqualBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) GreaterThanOrEqualNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) LessThanString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) LessThanInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) LessThanFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) LessThanBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) LessThanNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) LessThanOrEqualString(other *stringValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) LessThanOrEqualInteger(other *integerValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) LessThanOrEqualFloat(other *floatValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) LessThanOrEqualBool(other *boolValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
-}
-
-func (value *baseValue) LessThanOrEqualNull(other *nullValue) (Value, gerror.Error) {
-	return defaultOperation(GT, value, other)
+func (value *baseValue) String() string {
+	return fmt.Sprintf("%v", value.value)
 }
 
 type stringValue struct {
 	baseValue
 }
 
-//
-// Add operation for stringValue
-//
-
-func (value *stringValue) AddString(other *stringValue) (Value, gerror.Error) {
-	return &stringValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: value.value.(string) + other.value.(string),
-		},
-	}, nil
-}
-
-func (value *stringValue) AddInteger(other *integerValue) (Value, gerror.Error) {
-	return &stringValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: value.value.(string) + fmt.Sprintf("%d", other.value.(int64)),
-		},
-	}, nil
-}
-
-func (value *stringValue) AddFloat(other *floatValue) (Value, gerror.Error) {
-	return &stringValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: value.value.(string) + fmt.Sprintf("%f", other.value.(float64)),
-		},
-	}, nil
-}
-
-func (value *stringValue) AddBool(other *boolValue) (Value, gerror.Error) {
-	var str string
-	if other.value.(bool) {
-		str = "true"
-	} else {
-		str = "false"
-	}
-
-	return &stringValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: value.value.(string) + str,
-		},
-	}, nil
-}
-
-func (value *stringValue) AddNull(other *nullValue) (Value, gerror.Error) {
-	return &stringValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: value.value.(string) + "null",
-		},
-	}, nil
-}
-
-//
-// Multiply operation for stringValue
-//
-
-func (value *stringValue) MultiplyInteger(other *integerValue) (Value, gerror.Error) {
-	cnt := int(other.value.(int64))
-	if cnt < 0 {
-		return nil, gerror.NewInvalidOperationError(nil, MULTIPLY,
-			value.GetType().String(), "negtive integer")
-	}
-
-	buffer := []byte{}
-	unit := []byte(value.value.(string))
-
-	for i := 0; i < cnt; i++ {
-		buffer = append(buffer, unit...)
-	}
-
-	return &stringValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: (string)(buffer),
-		},
-	}, nil
+func (value *stringValue) String() string {
+	return value.value.(string)
 }
 
 type integerValue struct {
 	baseValue
 }
 
-//
-// Equal operation for stringValue
-//
-
-func (value *stringValue) EqualString(other *stringValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: value.value.(string) == other.value.(string),
-		},
-	}, nil
-}
-
-//
-// Not Equal operation for stringValue
-//
-
-func (value *stringValue) NotEqualString(other *stringValue) (Value, gerror.Error) {
-	result, err := value.EqualString(other)
-	if err != nil {
-		return nil, err
-	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
-}
-
-//
-// Greater than operation for stringValue
-//
-
-func (value *stringValue) GreaterThanString(other *stringValue) (Value, gerror.Error) {
-	left := value.value.(string)
-	right := value.value.(string)
-
-	for i := 0; i < math.MinInt(len(left), len(right)); i++ {
-		if left[i] < right[i] {
-			return &boolValue{
-				baseValue: baseValue{
-					typ: BOOL_TYPE,
-					value: false,
-				},
-			}, nil
-		} else if left[i] > right[i] {
-			return &boolValue{
-				baseValue: baseValue{
-					typ: BOOL_TYPE,
-					value: true,
-				},
-			}, nil
-		}
-	}
-
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: len(left) > len(right),
-		},
-	}, nil
-}
-
-//
-// Greater than or equal operation for stringValue
-//
-
-func (value *stringValue) GreaterThanOrEqualString(other *stringValue) (Value, gerror.Error) {
-	left := value.value.(string)
-	right := value.value.(string)
-
-	for i := 0; i < math.MinInt(len(left), len(right)); i++ {
-		if left[i] < right[i] {
-			return &boolValue{
-				baseValue: baseValue{
-					typ: BOOL_TYPE,
-					value: false,
-				},
-			}, nil
-		} else if left[i] > right[i] {
-			return &boolValue{
-				baseValue: baseValue{
-					typ: BOOL_TYPE,
-					value: true,
-				},
-			}, nil
-		}
-	}
-
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: len(left) >= len(right),
-		},
-	}, nil
+func (value *integerValue) String() string {
+	return fmt.Sprintf("%d", value.value.(int64))
 }
 
-//
-// Less operation for stringValue
-//
-
-func (value *stringValue) LessThanString(other *stringValue) (Value, gerror.Error) {
-	result, err := value.GreaterThanOrEqualString(other)
-	if err != nil {
-		return nil, err
-	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
-}
-
-//
-// Less than or equal operation for stringValue
-//
-
-func (value *stringValue) LessThanOrEqualString(other *stringValue) (Value, gerror.Error) {
-	result, err := value.GreaterThanString(other)
-	if err != nil {
-		return nil, err
-	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
-}
-
-//
-// Add operation for integerValue
-//
-
-func (value *integerValue) AddString(other *stringValue) (Value, gerror.Error) {
-	return &stringValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: fmt.Sprintf("%d", value.value.(int64)) + other.value.(string),
-		},
-	}, nil
-}
-
-func (value *integerValue) AddInteger(other *integerValue) (Value, gerror.Error) {
-	return &integerValue{
-		baseValue: baseValue{
-			typ: INTEGER_TYPE,
-			value: value.value.(int64) + other.value.(int64),
-		},
-	}, nil
-}
-
-func (value *integerValue) AddFloat(other *floatValue) (Value, gerror.Error) {
-	return &floatValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: float64(value.value.(int64)) + other.value.(float64),
-		},
-	}, nil
-}
-
-//
-// Subtract operation for integerValue
-//
-
-func (value *integerValue) SubtractInteger(other *integerValue) (Value, gerror.Error) {
-	return &integerValue{
-		baseValue: baseValue{
-			typ: INTEGER_TYPE,
-			value: value.value.(int64) - other.value.(int64),
-		},
-	}, nil
-}
-
-func (value *integerValue) SubtractFloat(other *floatValue) (Value, gerror.Error) {
-	return &floatValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: float64(value.value.(int64)) - other.value.(float64),
-		},
-	}, nil
-}
-
-//
-// Multiply operation for integerValue
-//
-
-func (value *integerValue) MultiplyString(other *stringValue) (Value, gerror.Error) {
-	cnt := int(value.value.(int64))
-	if cnt < 0 {
-		return nil, gerror.NewInvalidOperationError(nil, MULTIPLY,
-			"negtive integer", value.GetType().String())
-	}
-
-	buffer := []byte{}
-	unit := ([]byte)(other.value.(string))
-
-	for i := 0; i < cnt; i++ {
-		buffer = append(buffer, unit...)
-	}
-
-	return &stringValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: string(buffer),
-		},
-	}, nil
-}
-
-func (value *integerValue) MultiplyInteger(other *integerValue) (Value, gerror.Error) {
-	return &integerValue{
-		baseValue: baseValue{
-			typ: INTEGER_TYPE,
-			value: value.value.(int64) * other.value.(int64),
-		},
-	}, nil
-}
-
-func (value *integerValue) MultiplyFloat(other *floatValue) (Value, gerror.Error) {
-	return &floatValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: float64(value.value.(int64)) * other.value.(float64),
-		},
-	}, nil
-}
-
-//
-// Divide operation for integerValue
-//
-
-func (value *integerValue) DivideInteger(other *integerValue) (Value, gerror.Error) {
-	return &integerValue{
-		baseValue: baseValue{
-			typ: INTEGER_TYPE,
-			value: value.value.(int64) / other.value.(int64),
-		},
-	}, nil
+type floatValue struct {
+	baseValue
 }
 
-func (value *integerValue) DivideFloat(other *floatValue) (Value, gerror.Error) {
-	return &floatValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: float64(value.value.(int64)) / other.value.(float64),
-		},
-	}, nil
+func (value *floatValue) String() string {
+	return fmt.Sprintf("%f", value.value.(float64))
 }
 
-//
-// Mod operation for integerValue
-//
-
-func (value *integerValue) ModInteger(other *integerValue) (Value, gerror.Error) {
-	return &integerValue{
-		baseValue: baseValue{
-			typ: INTEGER_TYPE,
-			value: value.value.(int64) % other.value.(int64),
-		},
-	}, nil
-}
-
-//
-// Equal operation for integerValue
-//
-
-func (value *integerValue) EqualInteger(other *integerValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: value.value.(int64) == other.value.(int64),
-		},
-	}, nil
+type boolValue struct {
+	baseValue
 }
 
-//
-// Not Equal operation for integerValue
-//
-
-func (value *integerValue) NotEqualInteger(other *integerValue) (Value, gerror.Error) {
-	result, err := value.EqualInteger(other)
-	if err != nil {
-		return nil, err
+func (value *boolValue) String() string {
+	if value.value.(bool) {
+		return "true"
+	} else {
+		return "false"
 	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
 }
 
-//
-// Greater than operation for integerValue
-//
-
-func (value *integerValue) GreaterThanInteger(other *integerValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: value.value.(int64) > other.value.(int64),
-		},
-	}, nil
+type nullValue struct {
+	baseValue
 }
 
-func (value *integerValue) GreaterThanFloat(other *floatValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: float64(value.value.(int64)) > other.value.(float64),
-		},
-	}, nil
+func (value *nullValue) String() string {
+	return "null"
 }
 
-//
-// Greater than or equal operation for integerValue
-//
-
-func (value *integerValue) GreaterThanOrEqualInteger(other *integerValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: value.value.(int64) >= other.value.(int64),
-		},
-	}, nil
+type arrayValue struct {
+	baseValue
 }
 
-func (value *integerValue) GreaterThanOrEqualFloat(other *floatValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: float64(value.value.(int64)) >= other.value.(float64),
-		},
-	}, nil
+func (value *arrayValue) String() string {
+	return formatValue(value, map[*Struct]bool{})
 }
 
-//
-// Less than operation for integerValue
-//
-
-func (value *integerValue) LessThanInteger(other *integerValue) (Value, gerror.Error) {
-	result, err := value.GreaterThanOrEqualInteger(other)
-	if err != nil {
-		return nil, err
-	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
+type fileValue struct {
+	baseValue
 }
 
-func (value *integerValue) LessThanFloat(other *floatValue) (Value, gerror.Error) {
-	result, err := value.GreaterThanOrEqualFloat(other)
-	if err != nil {
-		return nil, err
-	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
+func (value *fileValue) String() string {
+	return "<File " + value.value.(*File).GetName() + ">"
 }
 
-//
-// Less than or equal operation for integerValue
-//
-
-func (value *integerValue) LessThanOrEqualInteger(other *integerValue) (Value, gerror.Error) {
-	result, err := value.GreaterThanInteger(other)
-	if err != nil {
-		return nil, err
-	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
+type errorValue struct {
+	baseValue
 }
 
-func (value *integerValue) LessThanOrEqualFloat(other *floatValue) (Value, gerror.Error) {
-	result, err := value.GreaterThanFloat(other)
-	if err != nil {
-		return nil, err
-	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
+func (value *errorValue) String() string {
+	return value.value.(*RuntimeError).GetMessage()
 }
 
-type floatValue struct {
+type rangeValue struct {
 	baseValue
 }
 
-//
-// Add operation for floatValue
-//
-
-func (value *floatValue) AddString(other *stringValue) (Value, gerror.Error) {
-	return &stringValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: fmt.Sprintf("%f", value.value.(float64)) + other.value.(string),
-		},
-	}, nil
-}
-
-func (value *floatValue) AddInteger(other *integerValue) (Value, gerror.Error) {
-	return &floatValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: value.value.(float64) + float64(other.value.(int64)),
-		},
-	}, nil
+func (value *rangeValue) String() string {
+	return value.value.(*Range).String()
 }
 
-func (value *floatValue) AddFloat(other *floatValue) (Value, gerror.Error) {
-	return &floatValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: value.value.(float64) + other.value.(float64),
-		},
-	}, nil
+type structValue struct {
+	baseValue
 }
 
-//
-// Subtract operation for floatValue
-//
-
-func (value *floatValue) SubtractInteger(other *integerValue) (Value, gerror.Error) {
-	return &integerValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: value.value.(float64) - float64(other.value.(int64)),
-		},
-	}, nil
+func (value *structValue) String() string {
+	return value.value.(*Struct).String()
 }
 
-func (value *floatValue) SubtractFloat(other *floatValue) (Value, gerror.Error) {
-	return &floatValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: value.value.(float64) - other.value.(float64),
-		},
-	}, nil
+type bigIntegerValue struct {
+	baseValue
 }
 
-//
-// Multiply operation for integerValue
-//
-
-func (value *floatValue) MultiplyInteger(other *integerValue) (Value, gerror.Error) {
-	return &integerValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: value.value.(float64) * float64(other.value.(int64)),
-		},
-	}, nil
+func (value *bigIntegerValue) String() string {
+	return value.value.(*big.Int).String()
 }
 
-func (value *floatValue) MultiplyFloat(other *floatValue) (Value, gerror.Error) {
-	return &floatValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: value.value.(float64) * other.value.(float64),
-		},
-	}, nil
+// Channels and tasks are implemented by the executor, which prints them.
+type channelValue struct {
+	baseValue
 }
 
-//
-// Divide operation for integerValue
-//
-
-func (value *floatValue) DivideInteger(other *integerValue) (Value, gerror.Error) {
-	return &integerValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: value.value.(float64) / float64(other.value.(int64)),
-		},
-	}, nil
+func (value *channelValue) String() string {
+	return value.value.(fmt.Stringer).String()
 }
 
-func (value *floatValue) DivideFloat(other *floatValue) (Value, gerror.Error) {
-	return &floatValue{
-		baseValue: baseValue{
-			typ: FLOAT_TYPE,
-			value: value.value.(float64) / other.value.(float64),
-		},
-	}, nil
+type taskValue struct {
+	baseValue
 }
 
-//
-// Equal operation for floatValue
-//
-
-func (value *floatValue) EqualFloat(other *floatValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: value.value.(float64) == other.value.(float64),
-		},
-	}, nil
+func (value *taskValue) String() string {
+	return value.value.(fmt.Stringer).String()
 }
 
 //
-// Not Equal operation for integerValue
+// operators of builtin types
 //
 
-func (value *floatValue) NotEqualFloat(other *floatValue) (Value, gerror.Error) {
-	result, err := value.EqualFloat(other)
-	if err != nil {
-		return nil, err
+func init() {
+	// string concatenation, the other operand is converted by its printable form
+	for _, typ := range []ValueType{STRING_TYPE, INTEGER_TYPE, FLOAT_TYPE, BOOL_TYPE, NULL_TYPE} {
+		RegisterOperator(ADD, STRING_TYPE, typ, concatenate)
+		RegisterOperator(ADD, typ, STRING_TYPE, concatenate)
 	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
-}
-
-//
-// Greater than operation for integerValue
-//
-
-func (value *floatValue) GreaterThanInteger(other *integerValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: value.value.(float64) > float64(other.value.(int64)),
-		},
-	}, nil
-}
-
-func (value *floatValue) GreaterThanFloat(other *floatValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: value.value.(float64) > other.value.(float64),
-		},
-	}, nil
-}
-
-//
-// Greater than or equal operation for integerValue
-//
+	RegisterOperator(MULTIPLY, STRING_TYPE, INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return repeat(left, right)
+	})
+	RegisterOperator(MULTIPLY, INTEGER_TYPE, STRING_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return repeat(right, left)
+	})
+	registerEquality(STRING_TYPE)
+	registerComparison(STRING_TYPE, func(left, right Value) int {
+		return strings.Compare(left.GetValue().(string), right.GetValue().(string))
+	})
+
+	// integers are promoted to float if the other operand is float
+	RegisterPromotion(INTEGER_TYPE, FLOAT_TYPE, func(value Value) Value {
+		return NewValue(FLOAT_TYPE, float64(value.GetValue().(int64)))
+	})
+
+	// integers are promoted to big integer on overflow
+	RegisterOperator(ADD, INTEGER_TYPE, INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return addInteger(left.GetValue().(int64), right.GetValue().(int64)), nil
+	})
+	RegisterOperator(SUBTRACT, INTEGER_TYPE, INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return subtractInteger(left.GetValue().(int64), right.GetValue().(int64)), nil
+	})
+	RegisterOperator(MULTIPLY, INTEGER_TYPE, INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return multiplyInteger(left.GetValue().(int64), right.GetValue().(int64)), nil
+	})
+	RegisterOperator(DIVIDE, INTEGER_TYPE, INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		if right.GetValue().(int64) == 0 {
+			return nil, gerror.NewDivisionByZeroError(nil)
+		}
+		return divideInteger(left.GetValue().(int64), right.GetValue().(int64)), nil
+	})
+	RegisterOperator(MOD, INTEGER_TYPE, INTEGER_TYPE, func(left, right Value) (Value, gerror.Error) {
+		if right.GetValue().(int64) == 0 {
+			return nil, gerror.NewDivisionByZeroError(nil)
+		}
+		return NewValue(INTEGER_TYPE, left.GetValue().(int64)%right.GetValue().(int64)), nil
+	})
+	registerEquality(INTEGER_TYPE)
+	registerComparison(INTEGER_TYPE, func(left, right Value) int {
+		l, r := left.GetValue().(int64), right.GetValue().(int64)
+		if l < r {
+			return -1
+		} else if l > r {
+			return 1
+		}
+		return 0
+	})
+
+	RegisterOperator(ADD, FLOAT_TYPE, FLOAT_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return NewValue(FLOAT_TYPE, left.GetValue().(float64)+right.GetValue().(float64)), nil
+	})
+	RegisterOperator(SUBTRACT, FLOAT_TYPE, FLOAT_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return NewValue(FLOAT_TYPE, left.GetValue().(float64)-right.GetValue().(float64)), nil
+	})
+	RegisterOperator(MULTIPLY, FLOAT_TYPE, FLOAT_TYPE, func(left, right Value) (Value, gerror.Error) {
+		return NewValue(FLOAT_TYPE, left.GetValue().(float64)*right.GetValue().(float64)), nil
+	})
+	RegisterOperator(DIVIDE, FLOAT_TYPE, FLOAT_TYPE, func(left, right Value) (Value, gerror.Error) {
+		if right.GetValue().(float64) == 0 {
+			return nil, gerror.NewDivisionByZeroError(nil)
+		}
+		return NewValue(FLOAT_TYPE, left.GetValue().(float64)/right.GetValue().(float64)), nil
+	})
+	registerEquality(FLOAT_TYPE)
+	registerComparison(FLOAT_TYPE, func(left, right Value) int {
+		l, r := left.GetValue().(float64), right.GetValue().(float64)
+		if l < r {
+			return -1
+		} else if l > r {
+			return 1
+		}
+		return 0
+	})
 
-func (value *floatValue) GreaterThanOrEqualInteger(other *integerValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: value.value.(float64) >= float64(other.value.(int64)),
-		},
-	}, nil
+	registerEquality(BOOL_TYPE)
 }
 
-func (value *floatValue) GreaterThanOrEqualFloat(other *floatValue) (Value, gerror.Error) {
-	return &boolValue{
-		baseValue: baseValue{
-			typ: BOOL_TYPE,
-			value: value.value.(float64) >= other.value.(float64),
-		},
-	}, nil
+func concatenate(left, right Value) (Value, gerror.Error) {
+	return NewValue(STRING_TYPE, left.String()+right.String()), nil
 }
 
-//
-// Less than operation for integerValue
-//
-
-func (value *floatValue) LessThanInteger(other *integerValue) (Value, gerror.Error) {
-	result, err := value.GreaterThanOrEqualInteger(other)
-	if err != nil {
-		return nil, err
+// Repeat str count times, count can't be negative.
+func repeat(str, count Value) (Value, gerror.Error) {
+	cnt := int(count.GetValue().(int64))
+	if cnt < 0 {
+		return nil, gerror.NewInvalidOperationError(nil, MULTIPLY,
+			str.GetType().String(), "negtive integer")
 	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
-}
-
-func (value *floatValue) LessThanFloat(other *floatValue) (Value, gerror.Error) {
-	result, err := value.GreaterThanOrEqualFloat(other)
-	if err != nil {
-		return nil, err
+	return NewValue(STRING_TYPE, strings.Repeat(str.GetValue().(string), cnt)), nil
+}
+
+// Register EQUAL and NOT_EQUAL of typ, which compares values by ==.
+func registerEquality(typ ValueType) {
+	RegisterOperator(EQUAL, typ, typ, func(left, right Value) (Value, gerror.Error) {
+		return NewValue(BOOL_TYPE, left.GetValue() == right.GetValue()), nil
+	})
+	RegisterOperator(NOT_EQUAL, typ, typ, func(left, right Value) (Value, gerror.Error) {
+		return NewValue(BOOL_TYPE, left.GetValue() != right.GetValue()), nil
+	})
+}
+
+// Register ordering operators of typ by compare, which returns a negative
+// number if left is less than right, 0 if they are equal.
+func registerComparison(typ ValueType, compare func(left, right Value) int) {
+	relations := map[string]func(int) bool{
+		GT:        func(c int) bool { return c > 0 },
+		GTE:       func(c int) bool { return c >= 0 },
+		LT:        func(c int) bool { return c < 0 },
+		LTE:       func(c int) bool { return c <= 0 },
 	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
-}
-
-//
-// Less than or equal operation for integerValue
-//
-
-func (value *floatValue) LessThanOrEqualInteger(other *integerValue) (Value, gerror.Error) {
-	result, err := value.GreaterThanInteger(other)
-	if err != nil {
-		return nil, err
+	for op, relation := range relations {
+		relation := relation
+		RegisterOperator(op, typ, typ, func(left, right Value) (Value, gerror.Error) {
+			return NewValue(BOOL_TYPE, relation(compare(left, right))), nil
+		})
 	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
 }
-
-func (value *floatValue) LessThanOrEqualFloat(other *floatValue) (Value, gerror.Error) {
-	result, err := value.GreaterThanFloat(other)
-	if err != nil {
-		return nil, err
-	}
-	result.SetValue(!result.GetValue().(bool))
-	return result, nil
-}
-
-type boolValue struct {
-	baseValue
-}
-
-//
-// Add operation for boolValue
-//
-
-func (value *boolValue) AddString(other *stringValue) (Value, gerror.Error) {
-	var str string
-	if value.value.(bool) {
-		str = "true"
-	} else {
-		str = "false"
-	}
-
-	return &floatValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: str + other.value.(string),
-		},
-	}, nil
-}
-
-type nullValue struct {
-	baseValue
-}
-
-//
-// Add operation for null value
-//
-
-func (value *nullValue) AddString(other *stringValue) (Value, gerror.Error) {
-	return &stringValue{
-		baseValue: baseValue{
-			typ: STRING_TYPE,
-			value: "null" + other.value.(string),
-		},
-	}, nil
-}
\ No newline at end of file
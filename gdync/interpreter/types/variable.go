@@ -1,12 +1,17 @@
 package types
 
 import (
+	"sync"
+
 	"github.com/mlmhl/compiler/common"
 )
 
+// The value of a variable is locked, since a global variable may be
+// accessed by tasks running concurrently.
 type Variable struct {
 	name  *Identifier
 	value Value
+	lock  sync.RWMutex
 }
 
 func NewVariable(name *Identifier, value Value) *Variable {
@@ -17,7 +22,9 @@ func NewVariable(name *Identifier, value Value) *Variable {
 }
 
 func (variable *Variable) SetValue(value Value) *Variable {
+	variable.lock.Lock()
 	variable.value = value
+	variable.lock.Unlock()
 	return variable
 }
 
@@ -26,6 +33,8 @@ func (variable *Variable) GetName() string {
 }
 
 func (variable *Variable) GetValue() Value {
+	variable.lock.RLock()
+	defer variable.lock.RUnlock()
 	return variable.value
 }
 
@@ -0,0 +1,451 @@
+package interpreter
+
+import (
+	"strings"
+	"testing"
+
+	gerror "github.com/mlmhl/compiler/gdync/errors"
+)
+
+type testScript struct {
+	name  string
+	lines []string
+}
+
+// scripts executed by both backends, each one ends at its first runtime error
+var differentialScripts []testScript = []testScript{
+	{"arithmetic", []string{
+		"a = 7",
+		"b = 2.5",
+		"Printf(\"%v %v %v %v %v\\n\", a + 1, a - b, a * b, a / 2, a % 4)",
+		"Printf(\"%v %v %v\\n\", -a, -b, \"s\" + a + b)",
+		"Printf(\"%v %v %v %v\\n\", a > 2, a <= 7, b == 2.5, !(a != 7))",
+		"Printf(\"%v %v\\n\", a > 1 && b < 1, a > 1 || b < 1)",
+	}},
+	{"control flow", []string{
+		"sum = 0",
+		"for (i = 0; i < 20; i = i + 1) {",
+		"    if (i % 2 == 0) {",
+		"        continue",
+		"    } elif (i > 15) {",
+		"        break",
+		"    } else {",
+		"        sum = sum + i",
+		"    }",
+		"}",
+		"j = 0",
+		"while (true) {",
+		"    j = j + 1",
+		"    if (j < 5) {",
+		"        continue",
+		"    }",
+		"    while (j < 100) {",
+		"        j = j * 2",
+		"        if (j > 50) {",
+		"            break",
+		"        }",
+		"    }",
+		"    break",
+		"}",
+		"for (j = j; true; j = j + 1) {",
+		"    if (j > 70) {",
+		"        break",
+		"    }",
+		"}",
+		"Printf(\"%d %d\\n\", sum, j)",
+	}},
+	{"functions", []string{
+		"count = 0",
+		"def fib(n) {",
+		"    global count",
+		"    count = count + 1",
+		"    if (n < 2) {",
+		"        return n",
+		"    }",
+		"    return fib(n - 1) + fib(n - 2)",
+		"}",
+		"def first(a, b) {",
+		"    for (i = a; i < b; i = i + 1) {",
+		"        if (i % 7 == 0) {",
+		"            return i",
+		"        }",
+		"    }",
+		"}",
+		"def noop() {",
+		"    x = 1",
+		"}",
+		"Printf(\"%d %d\\n\", fib(15), count)",
+		"Printf(\"%v %v %v\\n\", first(1, 20), first(1, 5), noop())",
+	}},
+	{"operators", []string{
+		"x = 5",
+		"x += 2",
+		"x *= 3",
+		"x %= 4",
+		"y = x++ + ++x",
+		"z = x-- - --x",
+		"def sign(n) {",
+		"    return n > 0 ? 1 : n < 0 ? -1 : 0",
+		"}",
+		"def sum(n) {",
+		"    total = 0",
+		"    for (i = 0; i < n; i++) {",
+		"        total += i",
+		"    }",
+		"    return total",
+		"}",
+		"Printf(\"%d %d %d %d %d %d\\n\", x, y, z, sign(-3), sign(0), sum(10))",
+		"s = \"a\"",
+		"s++",
+	}},
+	{"logical", []string{
+		"calls = 0",
+		"def check(x) {",
+		"    global calls",
+		"    calls++",
+		"    return x > 1",
+		"}",
+		"a = null",
+		"Printf(\"%v %v\\n\", a != null && a > 0, a == null || a > 0)",
+		"Printf(\"%v %v %v\\n\", check(1) && check(2), check(2) || check(3), calls)",
+		"Printf(\"%v\\n\", check(2) && check(3) || check(0))",
+		"n = 1",
+		"Printf(\"%v\\n\", check(2) && n)",
+	}},
+	{"stdlib", []string{
+		"s = \"hello, world\"",
+		"Printf(\"%d %s %v\\n\", len(s), substr(s, 7, 5), split(s, \", \"))",
+		"Printf(\"%v %v %v\\n\", sqrt(16.0), abs(-3), floor(2.7))",
+		"Printf(\"%v %v %v\\n\", toInt(\"42\") + 1, toFloat(1), toString(3) + \"!\")",
+		"write(stdout, \"done\\n\")",
+	}},
+	{"division by zero", []string{
+		"Printf(\"before\\n\")",
+		"def divide(a, b) {",
+		"    return a / b",
+		"}",
+		"Printf(\"%d\\n\", divide(4, 2))",
+		"Printf(\"%d\\n\", divide(4, 0))",
+		"Printf(\"after\\n\")",
+	}},
+	{"condition", []string{
+		"x = 3",
+		"if (x) {",
+		"    x = 1",
+		"}",
+	}},
+	{"unassigned local", []string{
+		"def f(flag) {",
+		"    if (flag) {",
+		"        a = 1",
+		"    }",
+		"    return a",
+		"}",
+		"Printf(\"%d\\n\", f(true))",
+		"f(false)",
+	}},
+	{"arguments", []string{
+		"def f(a, b) {",
+		"    return a + b",
+		"}",
+		"Printf(\"%d\\n\", f(1, 2))",
+		"f(1)",
+	}},
+	{"native error", []string{
+		"def root(x) {",
+		"    return sqrt(x)",
+		"}",
+		"Printf(\"%v\\n\", root(4))",
+		"root(-1)",
+	}},
+	{"invalid operation", []string{
+		"s = \"a\"",
+		"while (s != \"aaaa\") {",
+		"    s = s + \"a\"",
+		"}",
+		"Printf(\"%s\\n\", s)",
+		"y = -s",
+	}},
+	{"exceptions", []string{
+		"def f(n) {",
+		"    for (i = 0; i < n; i++) {",
+		"        try {",
+		"            if (i == 1) {",
+		"                continue",
+		"            }",
+		"            if (i == 3) {",
+		"                return i",
+		"            }",
+		"            Printf(\"body %d\\n\", i)",
+		"        } finally {",
+		"            Printf(\"finally %d\\n\", i)",
+		"        }",
+		"    }",
+		"}",
+		"def g() {",
+		"    try {",
+		"        throw 1",
+		"    } catch (e) {",
+		"        return e + 1",
+		"    } finally {",
+		"        Printf(\"leave g\\n\")",
+		"    }",
+		"}",
+		"def h() {",
+		"    while (true) {",
+		"        try {",
+		"            throw \"h\"",
+		"        } finally {",
+		"            break",
+		"        }",
+		"    }",
+		"    return \"swallowed\"",
+		"}",
+		"Printf(\"%v %v %v\\n\", f(5), g(), h())",
+		"s = \"a\"",
+		"try {",
+		"    try {",
+		"        s++",
+		"    } finally {",
+		"        Printf(\"inner\\n\")",
+		"    }",
+		"} catch (e) {",
+		"    Printf(\"%v\\n\", e)",
+		"    err = e",
+		"}",
+		"throw err",
+	}},
+	{"foreach", []string{
+		"def join(s) {",
+		"    out = \"\"",
+		"    for (c in s) {",
+		"        if (c == \"b\") {",
+		"            continue",
+		"        }",
+		"        if (c == \"d\") {",
+		"            break",
+		"        }",
+		"        out += c",
+		"    }",
+		"    return out",
+		"}",
+		"def find(words, target) {",
+		"    for (i in range(len(words))) {",
+		"        for (w in split(words, \",\")) {",
+		"            try {",
+		"                if (w == target) {",
+		"                    return w + i",
+		"                }",
+		"            } finally {",
+		"                Printf(\"%s \", w)",
+		"            }",
+		"        }",
+		"    }",
+		"}",
+		"total = 0",
+		"for (i in range(10, 0, -3)) {",
+		"    total += i",
+		"}",
+		"Printf(\"%s %d %d %v\\n\", join(\"abcde\"), total, i, find(\"x,y\", \"y\"))",
+		"for (n in 5) {",
+		"}",
+	}},
+	{"switch", []string{
+		"def kind(x) {",
+		"    switch (x) {",
+		"        case 1, 2:",
+		"            return \"small\"",
+		"        case -3, 3.5:",
+		"            return \"odd\"",
+		"        case \"a\":",
+		"            Printf(\"letter \")",
+		"            break",
+		"            Printf(\"unreachable\")",
+		"        case null:",
+		"            return \"null\"",
+		"        default:",
+		"            return \"other\"",
+		"    }",
+		"    return \"after\"",
+		"}",
+		"Printf(\"%s %s %s %s %s %s\\n\", kind(1), kind(-3), kind(3.5), kind(\"a\"), kind(null), kind(1.0))",
+		"total = 0",
+		"for (i in range(6)) {",
+		"    switch (i % 3) {",
+		"        case 0:",
+		"            continue",
+		"        case 1:",
+		"            total += 10",
+		"    }",
+		"    total += i",
+		"}",
+		"Printf(\"%d\\n\", total)",
+		"switch (\"x\") {",
+		"case \"y\":",
+		"    Printf(\"no\\n\")",
+		"}",
+		"n = 0",
+		"switch (split(\"a,b\", \",\")) {",
+		"case \"a\":",
+		"    n = 1",
+		"default:",
+		"    Printf(\"%d\\n\", 1 / n)",
+		"}",
+	}},
+	{"structs", []string{
+		"struct Point { x, y }",
+		"struct Line { a, b }",
+		"struct Empty {}",
+		"p = Point(1, 2)",
+		"Printf(\"%s %v %v\\n\", p, p == Point(1, 2), p != Point(1, 2.0))",
+		"l = Line(p, Point())",
+		"l.b.x = 5",
+		"l.a.y += 10",
+		"Printf(\"%s %d %s\\n\", l, p.y, Empty())",
+		"def mirror(q) {",
+		"    return Point(q.y, q.x)",
+		"}",
+		"Printf(\"%d %d\\n\", mirror(p).x, (mirror(p)).y)",
+		"try {",
+		"    p.z = 1",
+		"} catch (e) {",
+		"    Printf(\"%s\\n\", e)",
+		"}",
+		"Printf(\"%d\\n\", l.a.w)",
+	}},
+	{"big integers", []string{
+		"x = 9223372036854775807",
+		"y = x + 1",
+		"Printf(\"%d %v %s\\n\", y, y * y, \"y=\" + y)",
+		"Printf(\"%d %d\\n\", -9223372036854775808 / -1, 100000000000000000000 % 7)",
+		"Printf(\"%v %v %v\\n\", y - 1 == x, y > 1.5, 9007199254740993 > 9007199254740992.0)",
+		"n = 1",
+		"for (i in range(70)) {",
+		"    n *= 2",
+		"}",
+		"n++",
+		"Printf(\"%d %f\\n\", n, n * 0.5)",
+		"switch (y) {",
+		"    case 9223372036854775808:",
+		"        Printf(\"big case\\n\")",
+		"}",
+		"zero = 0",
+		"Printf(\"%d\\n\", y / zero)",
+	}},
+	{"top level jump", []string{
+		"i = 0",
+		"while (true) {",
+		"    i = i + 1",
+		"    if (i > 3) {",
+		"        return i",
+		"    }",
+		"}",
+		"if (i > 0) {",
+		"    break",
+		"}",
+		"Printf(\"%d\\n\", i)",
+	}},
+}
+
+// Run a script, returns its output and errors. Options configure the
+// interpreter before running.
+func runScript(source string, vm bool, options ...func(*Interpreter)) (string, []gerror.Error) {
+	output := &strings.Builder{}
+	inter := NewInterpreter()
+	inter.UseVM(vm)
+	inter.SetStdout(output)
+	for _, option := range options {
+		option(inter)
+	}
+	errs := inter.InterpretReader("script", strings.NewReader(source))
+	return output.String(), errs
+}
+
+func TestVM(t *testing.T) {
+	for _, script := range differentialScripts {
+		t.Logf("Test: vm with %s ...", script.name)
+
+		source := strings.Join(script.lines, "\n")
+		target, targetErrs := runScript(source, false)
+		output, errs := runScript(source, true)
+
+		if output != target {
+			t.Fatalf("Wrong output: Wanted (%s), got (%s)", target, output)
+		}
+		if len(errs) != len(targetErrs) {
+			t.Fatalf("Wrong error count: Wanted %d, got %d", len(targetErrs), len(errs))
+		}
+		for i, err := range errs {
+			if err.GetMessage() != targetErrs[i].GetMessage() {
+				t.Fatalf("Wrong error: Wanted (%s), got (%s)",
+					targetErrs[i].GetMessage(), err.GetMessage())
+			}
+			if !err.GetLocation().Equal(targetErrs[i].GetLocation()) {
+				t.Fatalf("Wrong error location: Wanted %v, got %v",
+					targetErrs[i].GetLocation(), err.GetLocation())
+			}
+		}
+
+		t.Log("Passed")
+	}
+}
+
+func TestVMRepl(t *testing.T) {
+	t.Log("Test: repl with vm ...")
+
+	input := strings.Join([]string{
+		"def add(a, b) {",
+		"    return a + b",
+		"}",
+		"x = add(1, 2)",
+		"x * 2",
+		"add(x, \"a\")",
+		"y = x - undefined",
+		"Printf(\"%d\\n\", x)",
+	}, "\n")
+
+	target := &strings.Builder{}
+	NewInterpreter().Repl(strings.NewReader(input), target)
+
+	output := &strings.Builder{}
+	inter := NewInterpreter()
+	inter.UseVM(true)
+	inter.Repl(strings.NewReader(input), output)
+
+	if output.String() != target.String() {
+		t.Fatalf("Wrong output: Wanted (%s), got (%s)", target.String(), output.String())
+	}
+
+	t.Log("Passed")
+}
+
+func TestVMCall(t *testing.T) {
+	t.Log("Test: call with vm ...")
+
+	inter := NewInterpreter()
+	inter.UseVM(true)
+	errs := inter.InterpretReader("script", strings.NewReader(strings.Join([]string{
+		"def factorial(n) {",
+		"    if (n <= 1) {",
+		"        return 1",
+		"    }",
+		"    return n * factorial(n - 1)",
+		"}",
+	}, "\n")))
+	if len(errs) != 0 {
+		t.Fatalf("Unexpected error: %s", errs[0].GetMessage())
+	}
+
+	result, err := inter.Call("factorial", 10)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err.GetMessage())
+	}
+	if result != int64(3628800) {
+		t.Fatalf("Wrong result: Wanted 3628800, got %v", result)
+	}
+	if _, err = inter.Call("factorial"); err == nil {
+		t.Fatalf("Call with too few arguments should fail")
+	}
+
+	t.Log("Passed")
+}
@@ -2,14 +2,134 @@ package main
 
 import (
 	"flag"
+	"os"
+	"path/filepath"
 
+	"github.com/mlmhl/compiler/gdync/dap"
+	gerror "github.com/mlmhl/compiler/gdync/errors"
 	"github.com/mlmhl/compiler/gdync/interpreter"
+	"github.com/mlmhl/compiler/gdync/interpreter/ast"
+	"github.com/mlmhl/compiler/gdync/interpreter/clog"
 )
 
 func main() {
-	var fileName = flag.String("fileName", "test", "file name")
+	var fileName = flag.String("fileName", "interpreter/testdata/run/heart.gd", "file name")
+	var repl = flag.Bool("repl", false, "start an interactive session")
+	var vm = flag.Bool("vm", false, "execute scripts by the bytecode vm")
+	var debug = flag.Bool("debug", false, "execute the file under a debugger")
+	var profile = flag.String("profile", "",
+		"profile the execution, report to stderr and write a pprof profile to the file")
+	var trace = flag.String("trace", "",
+		"trace the execution to stderr, the format is text or json")
+	var dumpAST = flag.String("ast", "",
+		"print the parsed tree of the file instead of executing it, the format is text or json")
+	var debugAdapter = flag.Bool("dap", false, "serve the debug adapter protocol over stdio")
+	var importPath = flag.String("importPath", "",
+		"directories searched for imported files, separated by "+string(filepath.ListSeparator))
 	flag.Parse()
 
+	modes := 0
+	for _, used := range []bool{*debug, *profile != "", *trace != "", *repl, *dumpAST != "", *debugAdapter} {
+		if used {
+			modes++
+		}
+	}
+	if modes > 1 {
+		os.Stderr.WriteString("Only one of -debug, -profile, -trace, -repl, -ast and -dap can be used\n")
+		os.Exit(2)
+	}
+
 	inter := interpreter.NewInterpreter()
-	inter.Interpret(*fileName)
-}
\ No newline at end of file
+	inter.UseVM(*vm)
+	if *importPath != "" {
+		inter.SetSearchPath(filepath.SplitList(*importPath))
+	}
+	if *debugAdapter {
+		if err := dap.NewServer(os.Stdin, os.Stdout).Serve(); err != nil {
+			os.Stderr.WriteString(err.Error() + "\n")
+			os.Exit(1)
+		}
+		return
+	}
+	if *repl {
+		inter.Repl(os.Stdin, os.Stdout)
+		return
+	}
+	if *dumpAST != "" {
+		printAST(inter, *fileName, *dumpAST)
+		return
+	}
+
+	interpret := inter.Interpret
+	if *debug {
+		interpret = func(file string) []gerror.Error {
+			return inter.Debug(file, os.Stdin, os.Stdout)
+		}
+	}
+	var profiler *interpreter.Profiler
+	if *profile != "" {
+		profiler = interpreter.NewProfiler()
+		interpret = func(file string) []gerror.Error {
+			return inter.Profile(file, profiler)
+		}
+	}
+	if *trace != "" {
+		sink := interpreter.NewTextTraceSink(os.Stderr)
+		if *trace == "json" {
+			sink = interpreter.NewJSONTraceSink(os.Stderr)
+		} else if *trace != "text" {
+			os.Stderr.WriteString("Unknown trace format " + *trace + "\n")
+			os.Exit(2)
+		}
+		interpret = func(file string) []gerror.Error {
+			errs, err := inter.Trace(file, sink)
+			if err != nil {
+				os.Stderr.WriteString("Can't write trace: " + err.Error() + "\n")
+			}
+			return errs
+		}
+	}
+	errs := interpret(*fileName)
+	if profiler != nil && profiler.Executed() {
+		writeProfile(profiler, *profile)
+	}
+	if len(errs) > 0 {
+		clog.NewWriterLogger(os.Stderr).Errors(errs)
+		os.Exit(1)
+	}
+}
+
+func writeProfile(profiler *interpreter.Profiler, fileName string) {
+	profiler.WriteReport(os.Stderr)
+	file, err := os.Create(fileName)
+	if err == nil {
+		err = profiler.WriteProfile(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		os.Stderr.WriteString("Can't write profile: " + err.Error() + "\n")
+		os.Exit(1)
+	}
+}
+
+func printAST(inter *interpreter.Interpreter, fileName, format string) {
+	write := ast.WriteText
+	if format == "json" {
+		write = ast.WriteJSON
+	} else if format != "text" {
+		os.Stderr.WriteString("Unknown ast format " + format + "\n")
+		os.Exit(2)
+	}
+
+	nodes, errs := inter.Parse(fileName)
+	if len(errs) > 0 {
+		clog.NewWriterLogger(os.Stderr).Errors(errs)
+		os.Exit(1)
+	}
+	if err := write(os.Stdout, ast.Dump(nodes...)); err != nil {
+		os.Stderr.WriteString("Can't write ast: " + err.Error() + "\n")
+		os.Exit(1)
+	}
+}
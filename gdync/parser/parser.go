@@ -2,6 +2,8 @@ package parser
 
 import (
 	"bufio"
+	"io"
+	"math/big"
 	"os"
 	"strconv"
 
@@ -63,6 +65,18 @@ func NewParser() *Parser {
 	regex.AddRegexExpression(token.LTE, token.LTE_ID)
 
 	regex.AddRegexExpression(token.ASSIGN, token.ASSIGN_ID)
+	regex.AddRegexExpression(token.ADD_ASSIGN, token.ADD_ASSIGN_ID)
+	regex.AddRegexExpression(token.SUBTRACT_ASSIGN, token.SUBTRACT_ASSIGN_ID)
+	regex.AddRegexExpression(token.MULTIPLY_ASSIGN, token.MULTIPLY_ASSIGN_ID)
+	regex.AddRegexExpression(token.DIVIDE_ASSIGN, token.DIVIDE_ASSIGN_ID)
+	regex.AddRegexExpression(token.MOD_ASSIGN, token.MOD_ASSIGN_ID)
+
+	regex.AddRegexExpression(token.INCREMENT, token.INCREMENT_ID)
+	regex.AddRegexExpression(token.DECREMENT, token.DECREMENT_ID)
+
+	regex.AddRegexExpression(token.QUESTION, token.QUESTION_ID)
+	regex.AddRegexExpression(token.COLON, token.COLON_ID)
+	regex.AddRegexExpression(token.DOT, token.DOT_ID)
 
 	regex.AddRegexExpression(token.FOR, token.FOR_ID)
 	regex.AddRegexExpression(token.WHILE, token.WHILE_ID)
@@ -81,6 +95,23 @@ func NewParser() *Parser {
 	regex.AddRegexExpression(token.NULL, token.NULL_ID)
 	regex.AddRegexExpression(token.GLOBAL, token.GLOBAL_ID)
 
+	regex.AddRegexExpression(token.TRY, token.TRY_ID)
+	regex.AddRegexExpression(token.CATCH, token.CATCH_ID)
+	regex.AddRegexExpression(token.FINALLY, token.FINALLY_ID)
+	regex.AddRegexExpression(token.THROW, token.THROW_ID)
+
+	regex.AddRegexExpression(token.IMPORT, token.IMPORT_ID)
+	regex.AddRegexExpression(token.AS, token.AS_ID)
+	regex.AddRegexExpression(token.IN, token.IN_ID)
+
+	regex.AddRegexExpression(token.SWITCH, token.SWITCH_ID)
+	regex.AddRegexExpression(token.CASE, token.CASE_ID)
+	regex.AddRegexExpression(token.DEFAULT, token.DEFAULT_ID)
+
+	regex.AddRegexExpression(token.STRUCT, token.STRUCT_ID)
+
+	regex.AddRegexExpression(token.SPAWN, token.SPAWN_ID)
+
 	regex.AddRegexExpression(token.WHITESPACE, token.WHITESPACE_ID)
 
 	regex.Compile()
@@ -94,18 +125,23 @@ func (parser *Parser) Parse(fileName string) gerror.Error {
 	if file, err := os.Open(fileName); err != nil {
 		return gerror.NewInternalError(err.Error())
 	} else {
-		parser.scanner = bufio.NewScanner(file)
-		parser.fileName  =fileName
-		parser.line = ""
-		parser.lineNumber = 0
-		parser.position = 0
-
-		parser.tokens = container.NewStack()
-
+		parser.ParseReader(fileName, file)
 		return nil
 	}
 }
 
+// ParseReader reset the parser to read source code from reader,
+// fileName is only used to build the location of tokens.
+func (parser *Parser) ParseReader(fileName string, reader io.Reader) {
+	parser.scanner = bufio.NewScanner(reader)
+	parser.fileName = fileName
+	parser.line = ""
+	parser.lineNumber = 0
+	parser.position = 0
+
+	parser.tokens = container.NewStack()
+}
+
 // Get next token
 func (parser *Parser) Next() (*token.Token, gerror.Error) {
 	if !parser.HasNext() {
@@ -120,15 +156,29 @@ func (parser *Parser) Next() (*token.Token, gerror.Error) {
 	}
 
 	for {
+		if strings.HasPrefix(parser.line[parser.position:], token.COMMENT) {
+			// skip the comment till the end of line
+			parser.position = len(parser.line)
+			return parser.Next()
+		}
 		length, types := parser.regex.Match(parser.line[parser.position:])
 		pos := parser.position + length
 		if len(types) == 0 {
-			return nil, gerror.NewSyntaxError("Unsupported syntax",
-				common.NewLocation(parser.lineNumber, parser.position, parser.fileName))
+			location := common.NewLocation(parser.lineNumber, parser.position, parser.fileName)
+			// skip the unsupported syntax, so that the following tokens can be parsed
+			if length == 0 && pos < len(parser.line) {
+				pos++
+			}
+			parser.position = pos
+			return nil, gerror.NewSyntaxError("Unsupported syntax", location)
 		} else {
 			if types[0] == token.WHITESPACE_ID {
 				// skip white space
 				parser.position = pos
+				if parser.position == len(parser.line) {
+					// trailing white space, continue with next line
+					return parser.Next()
+				}
 				continue
 			}
 
@@ -137,21 +187,25 @@ func (parser *Parser) Next() (*token.Token, gerror.Error) {
 			tok := token.NewToken(common.NewLocation(parser.lineNumber,
 				parser.position, parser.fileName)).SetType(typ)
 
+			// update current position
+			parser.position = pos
+
 			switch typ {
 			case token.STRING_ID:
 				tok.SetValue(value)
 
 			case token.INTEGER_ID:
-				if v, err := strconv.Atoi(value); err != nil {
-					return nil, gerror.NewSyntaxError("Unsupported integer syntax",
-						common.NewLocation(parser.lineNumber, parser.position, parser.fileName))
+				if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+					tok.SetValue(v)
+				} else if b, ok := new(big.Int).SetString(value, 10); ok {
+					// too large for int64
+					tok.SetValue(b)
 				} else {
-					tok.SetValue(int64(v))
+					return nil, gerror.NewSyntaxError("Unsupported integer syntax", tok.GetLocation())
 				}
 			case token.FLOAT_ID:
 				if v, err := strconv.ParseFloat(value, 64); err != nil {
-					return nil, gerror.NewSyntaxError("Unsupported float synatx",
-					common.NewLocation(parser.lineNumber, parser.position, parser.fileName))
+					return nil, gerror.NewSyntaxError("Unsupported float synatx", tok.GetLocation())
 				} else {
 					tok.SetValue(v)
 				}
@@ -166,12 +220,9 @@ func (parser *Parser) Next() (*token.Token, gerror.Error) {
 
 			case token.IDENTIFIER_ID:
 				// identifier's value is variable name.
-				tok.SetValue(parser.line[parser.position:pos])
+				tok.SetValue(value)
 			}
 
-			// update current position
-			parser.position = pos
-
 			return tok, nil
 		}
 	}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/mlmhl/compiler/gdync/token"
@@ -59,3 +60,94 @@ func TestParser(t *testing.T) {
 
 	t.Log("Passed")
 }
+
+func TestParseOperators(t *testing.T) {
+	t.Log("Test: Parse operators ...")
+
+	parser := NewParser()
+	parser.ParseReader("test", strings.NewReader("x+=1;y--;++z;a?b:c%=d"))
+
+	types := []int{
+		token.IDENTIFIER_ID, token.ADD_ASSIGN_ID, token.INTEGER_ID, token.SEMICOLON_ID,
+		token.IDENTIFIER_ID, token.DECREMENT_ID, token.SEMICOLON_ID,
+		token.INCREMENT_ID, token.IDENTIFIER_ID, token.SEMICOLON_ID,
+		token.IDENTIFIER_ID, token.QUESTION_ID, token.IDENTIFIER_ID, token.COLON_ID,
+		token.IDENTIFIER_ID, token.MOD_ASSIGN_ID, token.IDENTIFIER_ID,
+		token.FINISHED_ID,
+	}
+	for i, target := range types {
+		if tok, err := parser.Next(); err != nil {
+			t.Fatalf("Parser error: %s", err.GetMessage())
+		} else if tok.GetType() != target {
+			t.Fatalf("Wrong token(%d), Wanted %s, got %s", i,
+				token.GetDescription(target), token.GetDescription(tok.GetType()))
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestParseComments(t *testing.T) {
+	t.Log("Test: Parse comments ...")
+
+	parser := NewParser()
+	parser.ParseReader("test", strings.NewReader(strings.Join([]string{
+		"// comment",
+		"x = \"a // b\" // comment",
+		"    // indented comment",
+		"y = x/2",
+	}, "\n")))
+
+	tokens := []*token.Token{
+		token.NewToken(common.NewLocation(2, 0,
+			"test")).SetType(token.IDENTIFIER_ID).SetValue("x"),
+		token.NewToken(common.NewLocation(2, 2,
+			"test")).SetType(token.ASSIGN_ID),
+		token.NewToken(common.NewLocation(2, 4,
+			"test")).SetType(token.STRING_ID).SetValue("\"a // b\""),
+		token.NewToken(common.NewLocation(4, 0,
+			"test")).SetType(token.IDENTIFIER_ID).SetValue("y"),
+		token.NewToken(common.NewLocation(4, 2,
+			"test")).SetType(token.ASSIGN_ID),
+		token.NewToken(common.NewLocation(4, 4,
+			"test")).SetType(token.IDENTIFIER_ID).SetValue("x"),
+		token.NewToken(common.NewLocation(4, 5,
+			"test")).SetType(token.DIVIDE_ID),
+		token.NewToken(common.NewLocation(4, 6,
+			"test")).SetType(token.INTEGER_ID).SetValue(int64(2)),
+		token.NewToken(common.NewLocation(-1, -1,
+			"test")).SetType(token.FINISHED_ID),
+	}
+	for i, target := range tokens {
+		if tok, err := parser.Next(); err != nil {
+			t.Fatalf("Parser error: %s", err.GetMessage())
+		} else if !tok.Equal(target) {
+			t.Fatalf("Wrong token(%d), Wanted %v, got %v", i, target, tok)
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestParseTrailingWhitespace(t *testing.T) {
+	t.Log("Test: Parse trailing whitespace ...")
+
+	parser := NewParser()
+	parser.ParseReader("test", strings.NewReader("x = 1 \t\ny = 2\t \n  \t\n"))
+
+	types := []int{
+		token.IDENTIFIER_ID, token.ASSIGN_ID, token.INTEGER_ID,
+		token.IDENTIFIER_ID, token.ASSIGN_ID, token.INTEGER_ID,
+		token.FINISHED_ID,
+	}
+	for i, target := range types {
+		if tok, err := parser.Next(); err != nil {
+			t.Fatalf("Parser error: %s", err.GetMessage())
+		} else if tok.GetType() != target {
+			t.Fatalf("Wrong token(%d), Wanted %s, got %s", i,
+				token.GetDescription(target), token.GetDescription(tok.GetType()))
+		}
+	}
+
+	t.Log("Passed")
+}
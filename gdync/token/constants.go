@@ -9,8 +9,8 @@ const (
 	INTEGER = "(0|((1|2|3|4|5|6|7|8|9)(0|1|2|3|4|5|6|7|8|9)*))"
 	FLOAT = "((" + NUMBER + ")+\\.(" + NUMBER + ")+)"
 
-	TRUE = "true"
-	FALSE = "false"
+	TRUE = "(true)"
+	FALSE = "(false)"
 
 	LSP = "(\\()"
 	RSP = "(\\))"
@@ -40,6 +40,18 @@ const (
 	LTE = "(<=)"
 
 	ASSIGN = "(=)"
+	ADD_ASSIGN = "(\\+=)"
+	SUBTRACT_ASSIGN = "(\\-=)"
+	MULTIPLY_ASSIGN = "(\\*=)"
+	DIVIDE_ASSIGN = "(/=)"
+	MOD_ASSIGN = "(%=)"
+
+	INCREMENT = "(\\+\\+)"
+	DECREMENT = "(\\-\\-)"
+
+	QUESTION = "(\\?)"
+	COLON = "(:)"
+	DOT = "(\\.)"
 
 	FOR = "(for)"
 	WHILE = "(while)"
@@ -56,6 +68,23 @@ const (
 	NULL = "(null)"
 	GLOBAL = "(global)"
 
+	TRY = "(try)"
+	CATCH = "(catch)"
+	FINALLY = "(finally)"
+	THROW = "(throw)"
+
+	IMPORT = "(import)"
+	AS = "(as)"
+	IN = "(in)"
+
+	SWITCH = "(switch)"
+	CASE = "(case)"
+	DEFAULT = "(default)"
+
+	STRUCT = "(struct)"
+
+	SPAWN = "(spawn)"
+
 	WHITESPACE = "(( |\t|\n)+)"
 
 	COMMENT = "//"
@@ -103,6 +132,18 @@ const (
 	LTE_ID
 
 	ASSIGN_ID
+	ADD_ASSIGN_ID
+	SUBTRACT_ASSIGN_ID
+	MULTIPLY_ASSIGN_ID
+	DIVIDE_ASSIGN_ID
+	MOD_ASSIGN_ID
+
+	INCREMENT_ID
+	DECREMENT_ID
+
+	QUESTION_ID
+	COLON_ID
+	DOT_ID
 
 	FOR_ID
 	WHILE_ID
@@ -119,6 +160,23 @@ const (
 	NULL_ID
 	GLOBAL_ID
 
+	TRY_ID
+	CATCH_ID
+	FINALLY_ID
+	THROW_ID
+
+	IMPORT_ID
+	AS_ID
+	IN_ID
+
+	SWITCH_ID
+	CASE_ID
+	DEFAULT_ID
+
+	STRUCT_ID
+
+	SPAWN_ID
+
 	WHITESPACE_ID
 
 	IDENTIFIER_ID
@@ -162,6 +220,18 @@ var descriptions map[int]string = map[int]string {
 	LTE_ID: "less than and euqal",
 
 	ASSIGN_ID: "assign",
+	ADD_ASSIGN_ID: "add assign",
+	SUBTRACT_ASSIGN_ID: "subtract assign",
+	MULTIPLY_ASSIGN_ID: "multiply assign",
+	DIVIDE_ASSIGN_ID: "divide assign",
+	MOD_ASSIGN_ID: "mod assign",
+
+	INCREMENT_ID: "increment",
+	DECREMENT_ID: "decrement",
+
+	QUESTION_ID: "question mark",
+	COLON_ID: "colon",
+	DOT_ID: "dot",
 
 	FOR_ID: "for",
 	WHILE_ID: "while",
@@ -180,5 +250,22 @@ var descriptions map[int]string = map[int]string {
 	NULL_ID: "null",
 	GLOBAL_ID: "global",
 
+	TRY_ID: "try",
+	CATCH_ID: "catch",
+	FINALLY_ID: "finally",
+	THROW_ID: "throw",
+
+	IMPORT_ID: "import",
+	AS_ID: "as",
+	IN_ID: "in",
+
+	SWITCH_ID: "switch",
+	CASE_ID: "case",
+	DEFAULT_ID: "default",
+
+	STRUCT_ID: "struct",
+
+	SPAWN_ID: "spawn",
+
 	WHITESPACE_ID: "white space",
 }
\ No newline at end of file
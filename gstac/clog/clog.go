@@ -5,7 +5,7 @@ import (
 	"os"
 
 	"fmt"
-	error "github.com/mlmhl/compiler/gstac/errors"
+	gerror "github.com/mlmhl/compiler/gstac/errors"
 	gio "github.com/mlmhl/goutil/io"
 )
 
@@ -33,25 +33,38 @@ func NewLogger(outputFile string) (*Logger, error) {
 	return &Logger{output}, nil
 }
 
+// log all errors in a diagnostics list
+func (logger *Logger) Errors(errs []gerror.Error) {
+	for _, err := range errs {
+		if _, ok := err.(*gerror.InternalError); ok {
+			logger.InternalError(err)
+		} else {
+			logger.logError(err)
+		}
+	}
+}
+
 // log a internal error
-func (logger *Logger) InternalError(err error.Error) {
+func (logger *Logger) InternalError(err gerror.Error) {
 	logger.output.Write([]byte(err.GetMessage() + "\n"))
-	os.Exit(1)
 }
 
 // log a compile error
-func (logger *Logger) CompileError(err error.Error) {
+func (logger *Logger) CompileError(err gerror.Error) {
 	logger.logError(err)
 }
 
 // log a runtime error
-func (logger *Logger) RuntimeError(err error.Error) {
+func (logger *Logger) RuntimeError(err gerror.Error) {
 	logger.logError(err)
 }
 
-func (logger *Logger) logError(err error.Error) {
+func (logger *Logger) logError(err gerror.Error) {
 	location := err.GetLocation()
+	if location == nil {
+		logger.output.Write([]byte(err.GetMessage() + "\n"))
+		return
+	}
 	logger.output.Write([]byte(fmt.Sprintf("%s,%d,%d: %s\n", location.GetFileName(),
 		location.GetLine(), location.GetPosition(), err.GetMessage())))
-	os.Exit(1)
 }
@@ -545,9 +545,12 @@ func NewDeclarationStatement(declaration *Declaration) *DeclarationStatement {
 	}
 }
 
-func (statement *DeclarationStatement) Fix(context *Context) {
-	context.AddVariable(statement.declaration.GetName(), statement.declaration)
-	statement.declaration.Fix(context)
+func (statement *DeclarationStatement) Fix(context *Context) errors.Error {
+	err := context.AddVariable(statement.declaration.GetName(), statement.declaration)
+	if err != nil {
+		return err
+	}
+	return statement.declaration.Fix(context)
 }
 
 func (statement *DeclarationStatement) Generate(context *Context, exe *executable.Executable) errors.Error {
@@ -400,9 +400,18 @@ func (declaration *Declaration) GetLocation() *common.Location {
 	return declaration.location
 }
 
-func (declaration *Declaration) Fix(context *Context) {
-	declaration.initializer.Fix(context)
-	declaration.initializer.CastTo(declaration.typ, context)
+func (declaration *Declaration) Fix(context *Context) errors.Error {
+	if declaration.initializer == nil {
+		return nil
+	}
+
+	var err errors.Error
+	declaration.initializer, err = declaration.initializer.Fix(context)
+	if err != nil {
+		return err
+	}
+	declaration.initializer, err = declaration.initializer.CastTo(declaration.typ, context)
+	return err
 }
 
 func (declaration *Declaration) Generate(context *Context, exe *executable.Executable) errors.Error {
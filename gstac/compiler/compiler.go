@@ -3,7 +3,6 @@ package compiler
 import (
 	"github.com/mlmhl/compiler/gstac/compiler/ast"
 	"github.com/mlmhl/compiler/gstac/parser"
-	"github.com/mlmhl/compiler/gdync/interpreter/clog"
 	"github.com/mlmhl/compiler/gstac/errors"
 	"github.com/mlmhl/compiler/gstac/executable"
 )
@@ -13,7 +12,8 @@ type Compiler struct {
 	globalContext *ast.Context
 	statements []ast.Statement
 
-	logger *clog.Logger
+	// diagnostics of the latest compilation
+	errors []errors.Error
 }
 
 func NewCompiler() *Compiler {
@@ -21,17 +21,27 @@ func NewCompiler() *Compiler {
 		parser: parser.NewParser(),
 		globalContext: ast.NewContext(nil, nil, nil),
 		statements: []ast.Statement{},
+		errors: []errors.Error{},
 	}
 }
 
-func (compiler *Compiler) Compile(sourceFile string, executableFile string) {
+// Compile report all syntax errors of the source file, then all semantic
+// errors, the executable file is generated only if there is no error.
+func (compiler *Compiler) Compile(sourceFile string, executableFile string) []errors.Error {
+	compiler.errors = []errors.Error{}
+
 	err := compiler.parser.Parse(sourceFile)
 	if err != nil {
-		compiler.logger.InternalError(err)
+		return []errors.Error{err}
 	}
 	compiler.create()
-	compiler.fix()
-	compiler.generate(executableFile)
+	if len(compiler.errors) == 0 {
+		compiler.fix()
+	}
+	if len(compiler.errors) == 0 {
+		compiler.generate(executableFile)
+	}
+	return compiler.errors
 }
 
 // Syntax Analysis
@@ -45,12 +55,12 @@ func (compiler *Compiler) fix() {
 
 	for _, statement := range(compiler.statements) {
 		if err = statement.Fix(compiler.globalContext); err != nil {
-			compiler.logger.CompileError(err)
+			compiler.errors = append(compiler.errors, err)
 		}
 	}
 	for _, function := range(compiler.globalContext.GetFunctionList()) {
 		if err = function.Fix(compiler.globalContext); err != nil {
-			compiler.logger.CompileError(err)
+			compiler.errors = append(compiler.errors, err)
 		}
 	}
 }
@@ -67,7 +77,7 @@ func (compiler *Compiler) generate(fileName string) {
 	for _, function := range(compiler.globalContext.GetFunctionList()) {
 		err := function.Generate(compiler.globalContext, executable)
 		if err != nil {
-			compiler.logger.CompileError(err)
+			compiler.errors = append(compiler.errors, err)
 		}
 	}
 	executable.EndList()
@@ -78,7 +88,7 @@ func (compiler *Compiler) generate(fileName string) {
 	for _, statement := range(compiler.statements) {
 		err := statement.Generate(compiler.globalContext, executable)
 		if err != nil {
-			compiler.logger.CompileError(err)
+			compiler.errors = append(compiler.errors, err)
 		}
 	}
 	executable.EndObject()
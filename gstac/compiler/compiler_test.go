@@ -0,0 +1,58 @@
+package compiler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestErrorRecovery(t *testing.T) {
+	t.Log("Test: error recovery ...")
+
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "test.gs")
+	source := strings.Join([]string{
+		"int i = 5",
+		"int j = )",
+		"i = @ 3",
+		"if (i > 0 {",
+		"    i = 1",
+		"}",
+		"int k = 2 * (3 + 1",
+		"int add(int a, int b) {",
+		"    return a +",
+		"}",
+		"while (i) { i = ( }",
+		"i = i - 1",
+	}, "\n")
+	if err := os.WriteFile(fileName, []byte(source), 0644); err != nil {
+		t.Fatalf("Can't write source: %s", err.Error())
+	}
+
+	targets := []string{
+		"Can't parse right small parentheses",
+		"Unsupported syntax",
+		"Condition expression should end with left large parentheses",
+		"Can't find integer to match left small parentheses at " + fileName + ", 7, 12",
+		"Can't parse right large parentheses",
+		"Can't parse right large parentheses",
+	}
+	lines := []int{2, 3, 4, 8, 10, 11}
+
+	errs := NewCompiler().Compile(fileName, filepath.Join(dir, "test.gse"))
+	if len(errs) != len(targets) {
+		t.Fatalf("Wrong error count: Wanted %d, got %d", len(targets), len(errs))
+	}
+	for i, err := range errs {
+		if err.GetMessage() != targets[i] {
+			t.Fatalf("Wrong error(%d): Wanted (%s), got (%s)", i, targets[i], err.GetMessage())
+		}
+		if err.GetLocation().GetLine() != lines[i] {
+			t.Fatalf("Wrong error line(%d): Wanted %d, got %d",
+				i, lines[i], err.GetLocation().GetLine())
+		}
+	}
+
+	t.Log("Passed")
+}
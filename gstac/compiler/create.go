@@ -9,22 +9,25 @@ import (
 
 func (compiler *Compiler) compileUnit() {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			// parser has skipped the unsupported syntax
+			compiler.errors = append(compiler.errors, err)
+			continue
 		}
 		if tok.GetType() == token.FINISHED_ID {
 			break
 		}
-		parser.RollBack(tok)
+		parser.RollBack(1)
 		compiler.definitionOrStatement()
 	}
 }
 
 func (compiler *Compiler) definitionOrStatement() {
+	defer compiler.recoverStatement(false)
+
 	cursor := compiler.parser.GetCursor()
 
 	function, err := compiler.functionDefinition()
@@ -32,7 +35,8 @@ func (compiler *Compiler) definitionOrStatement() {
 		// This is a function definition.
 		err = compiler.globalContext.AddFunction(function.GetName(), function)
 		if err != nil {
-			compiler.logger.CompileError(err)
+			// the definition itself has been created, no need to recover
+			compiler.errors = append(compiler.errors, err)
 		}
 	} else {
 		// rollback if failed to pass a `type` token
@@ -47,7 +51,6 @@ func (compiler *Compiler) definitionOrStatement() {
 
 func (compiler *Compiler) functionDefinition() (*ast.Function, errors.Error) {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var err errors.Error
 	var tok *token.Token
@@ -65,7 +68,7 @@ func (compiler *Compiler) functionDefinition() (*ast.Function, errors.Error) {
 	// parser identifier
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.IDENTIFIER_ID {
 		return nil, errors.NewSyntaxError(
@@ -73,7 +76,7 @@ func (compiler *Compiler) functionDefinition() (*ast.Function, errors.Error) {
 				token.GetDescription(tok.GetType())),
 			tok.GetLocation())
 	}
-	identifier = ast.NewIdentifier(tok.GetValue(), tok.GetLocation())
+	identifier = ast.NewIdentifier(tok.GetValue().(string), tok.GetLocation())
 
 	// parser parameters
 	paramList, err = compiler.parameterList()
@@ -90,11 +93,10 @@ func (compiler *Compiler) functionDefinition() (*ast.Function, errors.Error) {
 
 func (compiler *Compiler) basicTypeSpecifier() (ast.Type, errors.Error) {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	tok, err := parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 
 	switch tok.GetType() {
@@ -117,7 +119,6 @@ func (compiler *Compiler) basicTypeSpecifier() (ast.Type, errors.Error) {
 // Up to now, array type specifier is the only composite type.
 func (compiler *Compiler) typeSpecifier() (ast.Type, errors.Error) {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var base ast.Type
 	var err errors.Error
@@ -134,7 +135,7 @@ func (compiler *Compiler) typeSpecifier() (ast.Type, errors.Error) {
 	for {
 		lTok, err = parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if lTok.GetType() != token.LMP_ID {
 			parser.RollBack(1)
@@ -143,7 +144,7 @@ func (compiler *Compiler) typeSpecifier() (ast.Type, errors.Error) {
 
 		rTok, err = parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if rTok.GetType() != token.RMP_ID {
 			err = errors.NewParenthesesNotMatchedError(token.GetDescription(token.LMP_ID),
@@ -159,14 +160,13 @@ func (compiler *Compiler) typeSpecifier() (ast.Type, errors.Error) {
 
 func (compiler *Compiler) parameterList() ([]*ast.Parameter, errors.Error) {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var tok *token.Token
 	var err errors.Error
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.LSP_ID {
 		return nil, errors.NewSyntaxError(
@@ -179,7 +179,7 @@ func (compiler *Compiler) parameterList() ([]*ast.Parameter, errors.Error) {
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() == token.RSP_ID {
 		// empty parameter list
@@ -198,7 +198,7 @@ func (compiler *Compiler) parameterList() ([]*ast.Parameter, errors.Error) {
 
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if tok.GetType() != token.IDENTIFIER_ID {
 			return nil, errors.NewSyntaxError(
@@ -208,11 +208,11 @@ func (compiler *Compiler) parameterList() ([]*ast.Parameter, errors.Error) {
 			)
 		}
 		parameterList = append(parameterList, ast.NewParameter(typ,
-			ast.NewIdentifier(tok.GetValue(), tok.GetLocation()), location))
+			ast.NewIdentifier(tok.GetValue().(string), tok.GetLocation()), location))
 
 		tok, err = parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if tok.GetType() == token.RSP_ID {
 			break
@@ -229,7 +229,6 @@ func (compiler *Compiler) parameterList() ([]*ast.Parameter, errors.Error) {
 
 func (compiler *Compiler) statementListForBlock() []ast.Statement {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var tok *token.Token
 	var err errors.Error
@@ -237,17 +236,18 @@ func (compiler *Compiler) statementListForBlock() []ast.Statement {
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.LLP_ID {
-		logger.CompileError(errors.NewSyntaxError(
+		parser.RollBack(1)
+		compiler.compileError(errors.NewSyntaxError(
 			"Block should start with "+token.GetDescription(tok.GetType()),
 			tok.GetLocation()))
 	}
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() == token.RLP_ID {
 		return nil
@@ -259,10 +259,12 @@ func (compiler *Compiler) statementListForBlock() []ast.Statement {
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.RLP_ID {
-		logger.CompileError(errors.NewSyntaxError(
+		// source finished before the block
+		parser.RollBack(1)
+		compiler.compileError(errors.NewSyntaxError(
 			"Block should stop with a "+token.GetDescription(tok.GetType()),
 			tok.GetLocation()))
 	}
@@ -272,7 +274,6 @@ func (compiler *Compiler) statementListForBlock() []ast.Statement {
 
 func (compiler *Compiler) statementList() []ast.Statement {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var tok *token.Token
 	var err errors.Error
@@ -280,17 +281,19 @@ func (compiler *Compiler) statementList() []ast.Statement {
 	statements := []ast.Statement{}
 
 	for {
-		statements = append(statements, compiler.statement())
+		if statement := compiler.blockStatement(); statement != nil {
+			statements = append(statements, statement)
+		}
 
 		tok, err = parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		parser.RollBack(1)
 
 		// statement list is around by large parentheses,
 		// so a right large parentheses means statement list ended,
-		if tok.GetType() == token.RLP_ID {
+		if tok.GetType() == token.RLP_ID || tok.GetType() == token.FINISHED_ID {
 			break
 		}
 	}
@@ -298,13 +301,18 @@ func (compiler *Compiler) statementList() []ast.Statement {
 	return statements
 }
 
+// Create a statement inside a block, returns nil if it's abandoned.
+func (compiler *Compiler) blockStatement() (statement ast.Statement) {
+	defer compiler.recoverStatement(true)
+	return compiler.statement()
+}
+
 func (compiler *Compiler) statement() ast.Statement {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	tok, err := parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	parser.RollBack(1)
 
@@ -338,10 +346,10 @@ func (compiler *Compiler) statement() ast.Statement {
 	// statement maybe ended with a `;`
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.SEMICOLON_ID {
-		parser.RollBack(tok)
+		parser.RollBack(1)
 	}
 
 	return statement
@@ -373,7 +381,6 @@ func (compiler *Compiler) ifStatement() ast.Statement {
 
 func (compiler *Compiler) elifStatements() []*ast.ElifStatement {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var tok *token.Token
 	var err errors.Error
@@ -383,7 +390,7 @@ func (compiler *Compiler) elifStatements() []*ast.ElifStatement {
 	for {
 		tok, err = parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if tok.GetType() != token.ELIF_ID {
 			parser.RollBack(1)
@@ -401,14 +408,13 @@ func (compiler *Compiler) elifStatements() []*ast.ElifStatement {
 
 func (compiler *Compiler) elseStatement() *ast.ElseStatement {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var tok *token.Token
 	var err errors.Error
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.ELSE_ID {
 		parser.RollBack(1)
@@ -431,17 +437,16 @@ func (compiler *Compiler) forStatement() ast.Statement {
 
 func (compiler *Compiler) createExpressionForForStatement(forStatement *ast.ForStatement) {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var tok *token.Token
 	var err errors.Error
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.LSP_ID {
-		logger.CompileError(errors.NewSyntaxError(
+		compiler.compileError(errors.NewSyntaxError(
 			"For statement's expression should start with "+
 				token.GetDescription(tok.GetType()), tok.GetLocation()))
 	}
@@ -453,26 +458,26 @@ func (compiler *Compiler) createExpressionForForStatement(forStatement *ast.ForS
 
 		tok, err = parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if tok.GetType() == token.RSP_ID {
 			break
 		} else if tok.GetType() != token.SEMICOLON_ID {
-			logger.CompileError(errors.NewSyntaxError(
+			compiler.compileError(errors.NewSyntaxError(
 				fmt.Sprintf("Can't use %s in for statement's expressions",
 					token.GetDescription(tok.GetType())), tok.GetLocation()))
 		}
 	}
 
 	if len(expressions) != 3 {
-		logger.CompileError(errors.NewSyntaxError(
+		compiler.compileError(errors.NewSyntaxError(
 			fmt.Sprintf("Wrong for statement's expression size: wanted 3, got %d",
 				len(expressions)), tok.GetLocation()))
 	}
 
 	forStatement.SetInit(expressions[0])
 	forStatement.SetCondition(expressions[1])
-	forStatement.SetPost(expressions[1])
+	forStatement.SetPost(expressions[2])
 }
 
 func (compiler *Compiler) whileStatement() ast.Statement {
@@ -504,7 +509,6 @@ func (compiler *Compiler) returnStatement() ast.Statement {
 
 func (compiler *Compiler) declarationStatement() ast.Statement {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var tok *token.Token
 	var err errors.Error
@@ -514,23 +518,23 @@ func (compiler *Compiler) declarationStatement() ast.Statement {
 
 	typ, err = compiler.typeSpecifier()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
-	if tok.GetType != token.IDENTIFIER_ID {
-		logger.CompileError(errors.NewSyntaxError(
+	if tok.GetType() != token.IDENTIFIER_ID {
+		compiler.compileError(errors.NewSyntaxError(
 			fmt.Sprintf("Can't use %s in declaration statement",
 				token.GetDescription(tok.GetType())), tok.GetLocation()))
 	}
-	identifier = ast.NewIdentifier(tok.GetValue(), tok.GetLocation())
+	identifier = ast.NewIdentifier(tok.GetValue().(string), tok.GetLocation())
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.ASSIGN_ID {
 		parser.RollBack(1)
@@ -548,17 +552,17 @@ func (compiler *Compiler) expressionStatement() ast.Statement {
 
 func (compiler *Compiler) conditionExpression() ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var tok *token.Token
 	var err errors.Error
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.LSP_ID {
-		logger.CompileError(errors.NewSyntaxError(
+		parser.RollBack(1)
+		compiler.compileError(errors.NewSyntaxError(
 			"Condition expression should start with "+
 				token.GetDescription(tok.GetType()),
 			tok.GetLocation()))
@@ -568,10 +572,11 @@ func (compiler *Compiler) conditionExpression() ast.Expression {
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.RSP_ID {
-		logger.CompileError(errors.NewSyntaxError(
+		parser.RollBack(1)
+		compiler.compileError(errors.NewSyntaxError(
 			"Condition expression should end with "+
 				token.GetDescription(tok.GetType()),
 			tok.GetLocation()))
@@ -599,36 +604,44 @@ func (compiler *Compiler) expression() ast.Expression {
 // assign expression
 func (compiler *Compiler) assignExpression() (ast.Expression, errors.Error) {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var tok *token.Token
 	var err errors.Error
 
 	var left ast.Expression
 
+	tok, err = parser.Next()
+	if err != nil {
+		compiler.compileError(err)
+	}
+	parser.RollBack(1)
+	if tok.GetType() != token.IDENTIFIER_ID {
+		return nil, errors.NewSyntaxError(fmt.Sprintf("Can't assign to %s",
+			token.GetDescription(tok.GetType())), tok.GetLocation())
+	}
+
 	left = compiler.primaryExpression()
 
 	tok, err = parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if !token.IsAssignOperator(tok.GetType()) {
 		return nil, errors.NewSyntaxError(fmt.Sprintf("Can't use %s in assign expression",
 			token.GetDescription(tok.GetType())), tok.GetLocation())
 	}
 
-	return ast.NewAssignExpression(tok.GetType(), left, compiler.expression())
+	return ast.NewAssignExpression(tok.GetType(), left, compiler.expression()), nil
 }
 
 func (compiler *Compiler) logicalOrExpression() ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	result := compiler.logicalAndExpression()
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if tok.GetType() != token.OR_ID {
 			parser.RollBack(1)
@@ -642,13 +655,12 @@ func (compiler *Compiler) logicalOrExpression() ast.Expression {
 
 func (compiler *Compiler) logicalAndExpression() ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	result := compiler.equalityExpression()
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if tok.GetType() != token.AND_ID {
 			parser.RollBack(1)
@@ -662,13 +674,12 @@ func (compiler *Compiler) logicalAndExpression() ast.Expression {
 
 func (compiler *Compiler) equalityExpression() ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	result := compiler.relationExpression()
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if tok.GetType() == token.EQUAL_ID {
 			result = ast.NewEqualExpression(result, compiler.relationExpression())
@@ -685,13 +696,12 @@ func (compiler *Compiler) equalityExpression() ast.Expression {
 
 func (compiler *Compiler) relationExpression() ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	result := compiler.additiveExpression()
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		switch tok.GetType() {
 		case token.GT_ID:
@@ -703,28 +713,27 @@ func (compiler *Compiler) relationExpression() ast.Expression {
 		case token.LTE_ID:
 			result = ast.NewLessThanAndEqualExpression(result, compiler.additiveExpression())
 		default:
-			break
+			parser.RollBack(1)
+			return result
 		}
 	}
-
-	return result
 }
 
 func (compiler *Compiler) additiveExpression() ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	result := compiler.multiplicativeExpression()
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if tok.GetType() == token.ADD_ID {
 			result = ast.NewAddExpression(result, compiler.multiplicativeExpression())
 		} else if tok.GetType() == token.SUBTRACT_ID {
 			result = ast.NewSubtractExpression(result, compiler.multiplicativeExpression())
 		} else {
+			parser.RollBack(1)
 			break
 		}
 	}
@@ -734,13 +743,12 @@ func (compiler *Compiler) additiveExpression() ast.Expression {
 
 func (compiler *Compiler) multiplicativeExpression() ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	result := compiler.unaryExpression()
 	for {
 		tok, err := parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		switch tok.GetType() {
 		case token.MULTIPLY_ID:
@@ -751,20 +759,17 @@ func (compiler *Compiler) multiplicativeExpression() ast.Expression {
 			result = ast.NewModExpression(result, compiler.unaryExpression())
 		default:
 			parser.RollBack(1)
-			break
+			return result
 		}
 	}
-
-	return result
 }
 
 func (compiler *Compiler) unaryExpression() ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	tok, err := parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 
 	if tok.GetType() == token.SUBTRACT_ID {
@@ -772,29 +777,27 @@ func (compiler *Compiler) unaryExpression() ast.Expression {
 	} else if tok.GetType() == token.NOT_ID {
 		return ast.NewLogicalNotExpression(compiler.unaryExpression(), tok.GetLocation())
 	} else {
+		parser.RollBack(1)
 		return compiler.primaryExpression()
 	}
 }
 
 func (compiler *Compiler) primaryExpression() ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	tok, err := parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 
 	if tok.GetType() == token.NEW_ID {
 		return compiler.arrayCreationExpression(tok)
 	} else {
-		parser.RollBack(1)
-
 		result := compiler.primaryExpressionWithoutArrayCreation(tok)
 
 		tok, err = parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 
 		if tok.GetType() == token.LMP_ID {
@@ -804,7 +807,7 @@ func (compiler *Compiler) primaryExpression() ast.Expression {
 
 				tok, err = parser.Next()
 				if err != nil {
-					logger.CompileError(err)
+					compiler.compileError(err)
 				}
 				if tok.GetType() != token.LMP_ID {
 					// needn't roll back, go on process array index syntax
@@ -827,7 +830,6 @@ func (compiler *Compiler) primaryExpression() ast.Expression {
 
 func (compiler *Compiler) primaryExpressionWithoutArrayCreation(first *token.Token) ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	switch first.GetType() {
 	case token.LSP_ID:
@@ -843,20 +845,25 @@ func (compiler *Compiler) primaryExpressionWithoutArrayCreation(first *token.Tok
 	case token.FLOAT_VALUE_ID:
 		return ast.NewFloatExpression(first.GetValue().(float64), first.GetLocation())
 	case token.STRING_VALUE_ID:
-		return ast.NewStringExpression(first.GetValue().(string), first.GetLocation())
+		expression, err := ast.NewStringExpression(first.GetValue().(string), first.GetLocation())
+		if err != nil {
+			compiler.compileError(err)
+		}
+		return expression
 	case token.LLP_ID:
 		return compiler.arrayLiteralExpression(first)
 	}
 
 	if first.GetType() != token.IDENTIFIER_ID {
-		logger.CompileError(errors.NewSyntaxError("Can't parse"+
+		parser.RollBack(1)
+		compiler.compileError(errors.NewSyntaxError("Can't parse "+
 			token.GetDescription(first.GetType()), first.GetLocation()))
 	}
 
-	identifier := ast.NewIdentifier(first.GetValue(), first.GetLocation())
+	identifier := ast.NewIdentifier(first.GetValue().(string), first.GetLocation())
 	second, err := parser.Next()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if second.GetType() == token.LSP_ID {
 		// function call
@@ -872,10 +879,11 @@ func (compiler *Compiler) subExpression(leftParentheses *token.Token) ast.Expres
 
 	tok, err := compiler.parser.Next()
 	if err != nil {
-		compiler.logger.CompileError(err)
+		compiler.compileError(err)
 	}
 	if tok.GetType() != token.RSP_ID {
-		compiler.logger.CompileError(errors.NewParenthesesNotMatchedError(
+		compiler.parser.RollBack(1)
+		compiler.compileError(errors.NewParenthesesNotMatchedError(
 			token.GetDescription(leftParentheses.GetType()),
 			token.GetDescription(tok.GetType()),
 			leftParentheses.GetLocation(), tok.GetLocation()))
@@ -896,12 +904,12 @@ func (compiler *Compiler) arrayLiteralExpression(
 
 		tok, err = compiler.parser.Next()
 		if err != nil {
-			compiler.logger.CompileError(err)
+			compiler.compileError(err)
 		}
 		if tok.GetType() == token.RLP_ID {
 			break
 		} else if tok.GetType() != token.COMMA_ID {
-			compiler.logger.CompileError(errors.NewSyntaxError(fmt.Sprintf(
+			compiler.compileError(errors.NewSyntaxError(fmt.Sprintf(
 				"Can't use %s in array literal expression", token.GetDescription(tok.GetType())),
 				tok.GetLocation()))
 		}
@@ -921,14 +929,14 @@ func (compiler *Compiler) argumentList(leftParentheses *token.Token) []*ast.Argu
 
 		tok, err = compiler.parser.Next()
 		if err != nil {
-			compiler.logger.CompileError(err)
+			compiler.compileError(err)
 		}
 
-		if tok.GetType() == token.LSP_ID {
+		if tok.GetType() == token.RSP_ID {
 			break
 		} else if tok.GetType() != token.COMMA_ID {
-			compiler.logger.CompileError(errors.NewSyntaxError(fmt.Sprintf(
-				"Can't use %s in argument list", token.GetDescription(tok.GetType())), tok.GetType()))
+			compiler.compileError(errors.NewSyntaxError(fmt.Sprintf(
+				"Can't use %s in argument list", token.GetDescription(tok.GetType())), tok.GetLocation()))
 		}
 	}
 
@@ -937,7 +945,6 @@ func (compiler *Compiler) argumentList(leftParentheses *token.Token) []*ast.Argu
 
 func (compiler *Compiler) arrayCreationExpression(newTok *token.Token) ast.Expression {
 	parser := compiler.parser
-	logger := compiler.logger
 
 	var tok *token.Token
 	var err errors.Error
@@ -946,7 +953,7 @@ func (compiler *Compiler) arrayCreationExpression(newTok *token.Token) ast.Expre
 
 	typ, err = compiler.basicTypeSpecifier()
 	if err != nil {
-		logger.CompileError(err)
+		compiler.compileError(err)
 	}
 
 	dimensions := []ast.Expression{}
@@ -954,18 +961,19 @@ func (compiler *Compiler) arrayCreationExpression(newTok *token.Token) ast.Expre
 	for {
 		tok, err = parser.Next()
 		if err != nil {
-			logger.CompileError(err)
+			compiler.compileError(err)
 		}
 
 		if tok.GetType() != token.LMP_ID {
 			parser.RollBack(1)
+			break
 		}
 
 		dimensions = append(dimensions, compiler.dimensionExpression(tok))
 	}
 
 	if len(dimensions) == 0 {
-		logger.CompileError(errors.NewSyntaxError(
+		compiler.compileError(errors.NewSyntaxError(
 			"Can't use `new` on basic type", newTok.GetLocation()))
 	}
 
@@ -977,11 +985,11 @@ func (compiler *Compiler) dimensionExpression(leftParentheses *token.Token) ast.
 
 	tok, err := compiler.parser.Next()
 	if err != nil {
-		compiler.logger.CompileError(err)
+		compiler.compileError(err)
 	}
 
 	if tok.GetType() != token.RMP_ID {
-		compiler.logger.CompileError(errors.NewParenthesesNotMatchedError(
+		compiler.compileError(errors.NewParenthesesNotMatchedError(
 			token.GetDescription(leftParentheses.GetType()),
 			token.GetDescription(tok.GetType()),
 			leftParentheses.GetLocation(), tok.GetLocation()))
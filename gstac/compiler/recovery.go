@@ -0,0 +1,109 @@
+package compiler
+
+import (
+	"github.com/mlmhl/compiler/gstac/errors"
+	"github.com/mlmhl/compiler/gstac/token"
+)
+
+//
+// Syntax error recovery, works like the one of the gdync interpreter:
+// the broken statement or function definition is dropped, and creation
+// goes on from the next statement.
+//
+
+// bailout is raised by compileError and recovered by recoverStatement.
+type bailout struct{}
+
+// Record a syntax error and abandon current statement.
+func (compiler *Compiler) compileError(err errors.Error) {
+	compiler.errors = append(compiler.errors, err)
+	panic(bailout{})
+}
+
+// Should be deferred by the creation of a statement. inBlock means the
+// statement is surrounded by large parentheses, whose right one must be
+// kept to finish the block.
+func (compiler *Compiler) recoverStatement(inBlock bool) {
+	if r := recover(); r != nil {
+		if _, ok := r.(bailout); !ok {
+			panic(r)
+		}
+		compiler.synchronize(inBlock)
+	}
+}
+
+// Skip tokens until a semicolon, the end of a block, a keyword starting a
+// statement or a token in the line after the error, parentheses opened
+// after the error are skipped together.
+func (compiler *Compiler) synchronize(inBlock bool) {
+	parser := compiler.parser
+
+	line := -1
+	if location := compiler.errors[len(compiler.errors)-1].GetLocation(); location != nil {
+		line = location.GetLine()
+	}
+
+	depth := 0
+	for {
+		tok, err := parser.Next()
+		if err != nil {
+			// parser has skipped the unsupported syntax
+			compiler.errors = append(compiler.errors, err)
+			continue
+		}
+
+		switch tok.GetType() {
+		case token.FINISHED_ID:
+			parser.RollBack(1)
+			return
+		case token.SEMICOLON_ID:
+			if depth == 0 {
+				return
+			}
+		case token.ELIF_ID, token.ELSE_ID:
+			// belong to the abandoned if statement
+		case token.LLP_ID:
+			depth++
+		case token.RLP_ID:
+			if depth == 0 {
+				if inBlock {
+					parser.RollBack(1)
+				}
+				return
+			}
+			depth--
+			if depth == 0 && !compiler.followedByElse() {
+				return
+			}
+		default:
+			if depth == 0 && (tok.GetLocation().GetLine() > line ||
+				startsStatement(tok.GetType())) {
+				parser.RollBack(1)
+				return
+			}
+		}
+	}
+}
+
+// An abandoned if statement's elif and else blocks should be skipped too.
+func (compiler *Compiler) followedByElse() bool {
+	tok, err := compiler.parser.Next()
+	if err != nil {
+		compiler.errors = append(compiler.errors, err)
+		return false
+	}
+	compiler.parser.RollBack(1)
+	return tok.GetType() == token.ELIF_ID || tok.GetType() == token.ELSE_ID
+}
+
+// Declarations and function definitions start with a type.
+func startsStatement(typ int) bool {
+	switch typ {
+	case token.BOOL_TYPE_ID, token.INTEGER_TYPE_ID, token.FLOAT_TYPE_ID, token.STRING_TYPE_ID,
+		token.IF_ID, token.WHILE_ID, token.FOR_ID,
+		token.RETURN_ID, token.BREAK_ID, token.CONTINUE_ID:
+		return true
+	default:
+		return false
+	}
+}
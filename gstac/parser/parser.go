@@ -146,12 +146,21 @@ func (parser *Parser) Next() (*token.Token, error.Error) {
 		length, types := parser.regex.Match(parser.line[parser.position:])
 		pos := parser.position + length
 		if len(types) == 0 {
-			return nil, error.NewSyntaxError("Unsupported syntax",
-				common.NewLocation(parser.lineNumber, parser.position, parser.fileName))
+			location := common.NewLocation(parser.lineNumber, parser.position, parser.fileName)
+			// skip the unsupported syntax, so that the following tokens can be parsed
+			if length == 0 && pos < len(parser.line) {
+				pos++
+			}
+			parser.position = pos
+			return nil, error.NewSyntaxError("Unsupported syntax", location)
 		} else {
 			if types[0] == token.WHITESPACE_ID {
 				// skip white space
 				parser.position = pos
+				if parser.position == len(parser.line) {
+					// trailing white space, continue with next line
+					return parser.Next()
+				}
 				continue
 			}
 
@@ -160,21 +169,22 @@ func (parser *Parser) Next() (*token.Token, error.Error) {
 			tok := token.NewToken(common.NewLocation(parser.lineNumber,
 				parser.position, parser.fileName)).SetType(typ)
 
+			// update current position
+			parser.position = pos
+
 			switch typ {
 			case token.STRING_VALUE_ID:
 				tok.SetValue(value)
 
 			case token.INTEGER_VALUE_ID:
 				if v, err := strconv.Atoi(value); err != nil {
-					return nil, error.NewSyntaxError("Unsupported integer syntax",
-						common.NewLocation(parser.lineNumber, parser.position, parser.fileName))
+					return nil, error.NewSyntaxError("Unsupported integer syntax", tok.GetLocation())
 				} else {
 					tok.SetValue(int64(v))
 				}
 			case token.FLOAT_VALUE_ID:
 				if v, err := strconv.ParseFloat(value, 64); err != nil {
-					return nil, error.NewSyntaxError("Unsupported float synatx",
-						common.NewLocation(parser.lineNumber, parser.position, parser.fileName))
+					return nil, error.NewSyntaxError("Unsupported float synatx", tok.GetLocation())
 				} else {
 					tok.SetValue(v)
 				}
@@ -189,10 +199,9 @@ func (parser *Parser) Next() (*token.Token, error.Error) {
 
 			case token.IDENTIFIER_ID:
 				// identifier's value is variable name.
-				tok.SetValue(parser.line[parser.position:pos])
+				tok.SetValue(value)
 			}
 
-			parser.position = pos
 			parser.appendToBuffer(tok)
 
 			return tok, nil
@@ -246,8 +255,9 @@ func (parser *Parser) RollBack(size int) {
 }
 
 func (parser *Parser) Commit() {
+	// keep the tokens which have been rolled back
+	parser.buffer = parser.buffer[parser.cursor+1:]
 	parser.cursor = -1
-	parser.buffer = []*token.Token{}
 }
 
 func (parser *Parser) appendToBuffer(tok *token.Token) {
@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/mlmhl/compiler/gstac/token"
@@ -78,4 +80,61 @@ func TestParser(t *testing.T) {
 	}
 
 	t.Log("Passed")
-}
\ No newline at end of file
+}
+func TestParseTrailingWhitespace(t *testing.T) {
+	t.Log("Test: Parse trailing whitespace ...")
+
+	fileName := filepath.Join(t.TempDir(), "test")
+	if err := os.WriteFile(fileName, []byte("int i = 5 \t\ni-- \n  \t\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	parser := NewParser()
+	parser.Parse(fileName)
+
+	types := []int{
+		token.INTEGER_TYPE_ID, token.IDENTIFIER_ID, token.ASSIGN_ID, token.INTEGER_VALUE_ID,
+		token.IDENTIFIER_ID, token.DECREMENT_ID,
+		token.FINISHED_ID,
+	}
+	for i, target := range types {
+		if tok, err := parser.Next(); err != nil {
+			t.Fatalf("Parser error: %s", err.GetMessage())
+		} else if tok.GetType() != target {
+			t.Fatalf("Wrong token(%d), Wanted %s, got %s", i,
+				token.GetDescription(target), token.GetDescription(tok.GetType()))
+		}
+	}
+
+	t.Log("Passed")
+}
+
+func TestCommitRolledBack(t *testing.T) {
+	t.Log("Test: Commit keeps rolled back tokens ...")
+
+	fileName := filepath.Join(t.TempDir(), "test")
+	if err := os.WriteFile(fileName, []byte("int i = 5\ni--\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	parser := NewParser()
+	parser.Parse(fileName)
+
+	for i := 0; i < 5; i++ {
+		if _, err := parser.Next(); err != nil {
+			t.Fatalf("Parser error: %s", err.GetMessage())
+		}
+	}
+	// the statement ends before the identifier of next line
+	parser.RollBack(1)
+	parser.Commit()
+
+	tok, err := parser.Next()
+	if err != nil {
+		t.Fatalf("Parser error: %s", err.GetMessage())
+	}
+	if tok.GetType() != token.IDENTIFIER_ID || tok.GetValue() != "i" {
+		t.Fatalf("Wrong token: Wanted identifier(i), got %s(%v)",
+			token.GetDescription(tok.GetType()), tok.GetValue())
+	}
+
+	t.Log("Passed")
+}
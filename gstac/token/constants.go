@@ -45,14 +45,14 @@ const (
 	LTE = "(<=)"
 
 	ASSIGN = "(=)"
-	ADD_ASSIGN = "(+=)"
-	SUB_ASSIGN = "(-=)"
-	MUL_ASSIGN = "(*=)"
+	ADD_ASSIGN = "(\\+=)"
+	SUB_ASSIGN = "(\\-=)"
+	MUL_ASSIGN = "(\\*=)"
 	DIV_ASSIGN = "(/=)"
 	MOD_ASSIGN = "(%=)"
 
-	INCREMENT = "(++)"
-	DECREMENT = "(--)"
+	INCREMENT = "(\\+\\+)"
+	DECREMENT = "(\\-\\-)"
 
 	FOR = "(for)"
 	WHILE = "(while)"